@@ -468,6 +468,11 @@ func (h *AnthropicHandler) convertResponse(resp AnthropicResponse) (*entity.AIRe
 		aiResponse.Content = strings.Join(textParts, "\n")
 	}
 
+	aiResponse.Usage = entity.Usage{
+		InputTokens:  resp.Usage.InputTokens,
+		OutputTokens: resp.Usage.OutputTokens,
+	}
+
 	return &aiResponse, nil
 }
 
@@ -186,6 +186,10 @@ func (h *OpenAICompatibleHandler) generateCodeNative(ctx context.Context, prompt
 	return &entity.AIResponse{
 		Content:   choice.Content,
 		ToolCalls: toolCalls,
+		Usage: entity.Usage{
+			InputTokens:  resp.Usage.PromptTokens,
+			OutputTokens: resp.Usage.CompletionTokens,
+		},
 	}, nil
 }
 
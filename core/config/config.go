@@ -7,10 +7,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/manifoldco/promptui"
 
 	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/sandbox"
+	"github.com/vadiminshakov/autonomy/core/workspace"
 )
 
 const (
@@ -31,6 +34,19 @@ type Config struct {
 	Temperature  float64                 `json:"temperature,omitempty"`
 	UseAuthToken bool                    `json:"use_auth_token,omitempty"`
 	Tools        []entity.ToolDefinition `json:"tools,omitempty"`
+
+	// ToolTimeout is the default tool timeout in seconds, ToolTimeouts overrides it per tool
+	ToolTimeout  int            `json:"tool_timeout,omitempty"`
+	ToolTimeouts map[string]int `json:"tool_timeouts,omitempty"`
+
+	// ApprovalMode decides which tool calls need the user's approval: always, mutating, auto or plan
+	ApprovalMode string `json:"approval_mode,omitempty"`
+
+	// Sandbox runs bash commands in a Linux sandbox
+	Sandbox sandbox.Config `json:"sandbox"`
+
+	// Workspace confines the file tools to the project and the allowed directories
+	Workspace workspace.Config `json:"workspace"`
 }
 
 func configFilePath() (string, error) {
@@ -41,7 +57,28 @@ func configFilePath() (string, error) {
 	return filepath.Join(home, configDirName, configFileName), nil
 }
 
+var (
+	overrideMu sync.RWMutex
+	override   *Config
+)
+
+// Override makes LoadConfigFile return cfg instead of reading the config file.
+// Frontends that receive configuration from their client use it.
+func Override(cfg Config) {
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+	override = &cfg
+}
+
 func LoadConfigFile() (Config, error) {
+	overrideMu.RLock()
+	if override != nil {
+		cfg := *override
+		overrideMu.RUnlock()
+		return cfg, nil
+	}
+	overrideMu.RUnlock()
+
 	var cfg Config
 
 	path, err := configFilePath()
@@ -231,6 +268,20 @@ func readInput(prompt string) (string, error) {
 	return strings.TrimSpace(input), nil
 }
 
+// DefaultBaseURL returns the API endpoint of a cloud provider, or "" when it has none
+func DefaultBaseURL(provider string) string {
+	switch strings.ToLower(provider) {
+	case "openai":
+		return defaultOpenAIURL
+	case "anthropic":
+		return DefaultAnthropicURL
+	case "openrouter":
+		return defaultOpenRouterURL
+	default:
+		return ""
+	}
+}
+
 func (c *Config) HasValidCredentials() bool {
 	return c.APIKey != "" || c.BaseURL != ""
 }
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+const trustedFileName = "trusted_projects.json"
+
+// trustedProjects lists the projects whose own configuration, e.g. hooks, the user allowed to run
+type trustedProjects struct {
+	Projects []string `json:"projects"`
+}
+
+func trustedFilePath() (string, error) {
+	path, err := configFilePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), trustedFileName), nil
+}
+
+// projectPath returns the real absolute path of dir, the key a project is trusted by
+func projectPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = real
+	}
+	return abs, nil
+}
+
+func loadTrustedProjects() (trustedProjects, error) {
+	var trusted trustedProjects
+
+	path, err := trustedFilePath()
+	if err != nil {
+		return trusted, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return trusted, nil
+	}
+	if err != nil {
+		return trusted, err
+	}
+
+	return trusted, json.Unmarshal(data, &trusted)
+}
+
+// IsTrusted reports whether the user trusted the project in dir to run commands from its configuration
+func IsTrusted(dir string) bool {
+	project, err := projectPath(dir)
+	if err != nil {
+		return false
+	}
+
+	trusted, err := loadTrustedProjects()
+	if err != nil {
+		return false
+	}
+
+	return slices.Contains(trusted.Projects, project)
+}
+
+// Trust remembers that the user trusts the project in dir
+func Trust(dir string) error {
+	project, err := projectPath(dir)
+	if err != nil {
+		return err
+	}
+
+	trusted, err := loadTrustedProjects()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(trusted.Projects, project) {
+		return nil
+	}
+	trusted.Projects = append(trusted.Projects, project)
+
+	path, err := trustedFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(trusted, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
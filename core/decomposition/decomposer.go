@@ -12,12 +12,12 @@ import (
 )
 
 type TaskStep struct {
-	ID           string   `json:"id"`
-	Description  string   `json:"description"`
-	Reason       string   `json:"reason"`
-	Dependencies []string `json:"dependencies,omitempty"`
-	Status       string   `json:"status,omitempty"`       // pending, in_progress, completed, failed
-	MaxAttempts  int      `json:"max_attempts,omitempty"` // maximum number of attempts
+	ID           string   `json:"id" yaml:"id"`
+	Description  string   `json:"description" yaml:"description"`
+	Reason       string   `json:"reason" yaml:"reason"`
+	Dependencies []string `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	Status       string   `json:"status,omitempty" yaml:"-"`       // pending, in_progress, completed, failed
+	MaxAttempts  int      `json:"max_attempts,omitempty" yaml:"-"` // maximum number of attempts
 }
 
 type DecompositionResult struct {
@@ -26,6 +26,17 @@ type DecompositionResult struct {
 	Reasoning    string     `json:"reasoning"`
 }
 
+// ReplanRequest describes a failed plan execution that needs a revised plan
+type ReplanRequest struct {
+	OriginalTask   string
+	CompletedSteps []TaskStep
+	FailedStep     TaskStep
+	Failure        string
+	RemainingSteps []TaskStep
+	StateSummary   string
+	Feedback       string
+}
+
 type TaskDecomposer struct {
 	aiClient ai.AIClient
 }
@@ -36,9 +47,14 @@ func NewTaskDecomposer(cfg config.Config) (*TaskDecomposer, error) {
 		return nil, fmt.Errorf("failed to create AI client: %v", err)
 	}
 
+	return NewTaskDecomposerWithClient(client), nil
+}
+
+// NewTaskDecomposerWithClient creates a decomposer that reuses an existing AI client
+func NewTaskDecomposerWithClient(client ai.AIClient) *TaskDecomposer {
 	return &TaskDecomposer{
 		aiClient: client,
-	}, nil
+	}
 }
 
 func (td *TaskDecomposer) DecomposeTask(
@@ -60,8 +76,111 @@ func (td *TaskDecomposer) DecomposeTask(
 	return result, nil
 }
 
+// DecomposeTaskWithFeedback decomposes the task again after the user rejected a plan
+func (td *TaskDecomposer) DecomposeTaskWithFeedback(
+	ctx context.Context,
+	taskDescription string,
+	rejected *DecompositionResult,
+	feedback string,
+) (*DecompositionResult, error) {
+	prompt := td.buildDecompositionPrompt(taskDescription)
+	prompt.Messages = append(prompt.Messages,
+		entity.Message{Role: "assistant", Content: rejected.GetStepSummary()},
+		entity.Message{Role: "user", Content: fmt.Sprintf(
+			"The user rejected this plan with the following feedback:\n%s\n\nCreate a new plan that addresses the feedback.", feedback)},
+	)
+
+	response, err := td.aiClient.GenerateCode(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI response: %v", err)
+	}
+
+	result, err := td.parseDecompositionResponse(response.Content, taskDescription)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse decomposition response: %v", err)
+	}
+
+	return result, nil
+}
+
+// ReplanTask produces a revised plan for the work that remains after a step failed
+func (td *TaskDecomposer) ReplanTask(ctx context.Context, req ReplanRequest) (*DecompositionResult, error) {
+	prompt := td.buildReplanPrompt(req)
+
+	response, err := td.aiClient.GenerateCode(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI response: %v", err)
+	}
+
+	result, err := td.parseDecompositionResponse(response.Content, req.OriginalTask)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse replan response: %v", err)
+	}
+
+	if len(result.Steps) == 0 {
+		return nil, fmt.Errorf("revised plan contains no steps")
+	}
+
+	return result, nil
+}
+
 func (td *TaskDecomposer) buildDecompositionPrompt(taskDescription string) entity.PromptData {
-	systemPrompt := `You are a task decomposition expert. Follow this structured approach for breaking down programming tasks:
+	userMessage := fmt.Sprintf("Break down this task into executable steps: %s", taskDescription)
+
+	return entity.PromptData{
+		SystemPrompt: decompositionSystemPrompt,
+		Messages: []entity.Message{
+			{Role: "user", Content: userMessage},
+		},
+		Tools: []entity.ToolDefinition{},
+	}
+}
+
+func (td *TaskDecomposer) buildReplanPrompt(req ReplanRequest) entity.PromptData {
+	var msg strings.Builder
+
+	msg.WriteString(fmt.Sprintf("The execution plan for this task failed and needs to be revised: %s\n\n", req.OriginalTask))
+
+	if len(req.CompletedSteps) > 0 {
+		msg.WriteString("Steps already completed (do NOT repeat them):\n")
+		for i, step := range req.CompletedSteps {
+			msg.WriteString(fmt.Sprintf("  %d. %s\n", i+1, step.Description))
+		}
+		msg.WriteString("\n")
+	}
+
+	msg.WriteString(fmt.Sprintf("Failed step: %s\n", req.FailedStep.Description))
+	msg.WriteString(fmt.Sprintf("Failure details: %s\n\n", req.Failure))
+
+	if len(req.RemainingSteps) > 0 {
+		msg.WriteString("Steps that were planned after the failed one:\n")
+		for i, step := range req.RemainingSteps {
+			msg.WriteString(fmt.Sprintf("  %d. %s\n", i+1, step.Description))
+		}
+		msg.WriteString("\n")
+	}
+
+	if req.StateSummary != "" {
+		msg.WriteString(fmt.Sprintf("Current task state:\n%s\n\n", req.StateSummary))
+	}
+
+	if req.Feedback != "" {
+		msg.WriteString(fmt.Sprintf("User feedback on the previous revision:\n%s\n\n", req.Feedback))
+	}
+
+	msg.WriteString("Create a revised plan covering ONLY the remaining work, starting with a step that " +
+		"works around or fixes the cause of the failure.")
+
+	return entity.PromptData{
+		SystemPrompt: decompositionSystemPrompt,
+		Messages: []entity.Message{
+			{Role: "user", Content: msg.String()},
+		},
+		Tools: []entity.ToolDefinition{},
+	}
+}
+
+const decompositionSystemPrompt = `You are a task decomposition expert. Follow this structured approach for breaking down programming tasks:
 
 1. ANALYZE: Understand the task requirements, current state, and desired outcome
 2. PLAN: Design a minimal, logical sequence of steps
@@ -127,17 +246,6 @@ Provide your response as a JSON object with this structure:
 
 IMPORTANT: Your response must be valid JSON only, no additional text.`
 
-	userMessage := fmt.Sprintf("Break down this task into executable steps: %s", taskDescription)
-
-	return entity.PromptData{
-		SystemPrompt: systemPrompt,
-		Messages: []entity.Message{
-			{Role: "user", Content: userMessage},
-		},
-		Tools: []entity.ToolDefinition{},
-	}
-}
-
 // parseDecompositionResponse parses the AI response into a structured result
 func (td *TaskDecomposer) parseDecompositionResponse(content, originalTask string) (*DecompositionResult, error) {
 	// Clean the response - remove any markdown formatting
@@ -165,15 +273,30 @@ func (td *TaskDecomposer) parseDecompositionResponse(content, originalTask strin
 		return nil, fmt.Errorf("failed to parse JSON response: %v\nContent: %s", err, content)
 	}
 
-	for i := range rawResult.Steps {
-		step := &rawResult.Steps[i]
+	result := &DecompositionResult{
+		OriginalTask: originalTask,
+		Steps:        rawResult.Steps,
+		Reasoning:    rawResult.Reasoning,
+	}
+
+	if err := result.Normalize(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Normalize validates the steps and fills in default IDs, statuses and attempt limits
+func (dr *DecompositionResult) Normalize() error {
+	for i := range dr.Steps {
+		step := &dr.Steps[i]
 
 		if step.ID == "" {
 			step.ID = fmt.Sprintf("step_%d", i+1)
 		}
 
-		if step.Description == "" {
-			return nil, fmt.Errorf("step %s missing description", step.ID)
+		if strings.TrimSpace(step.Description) == "" {
+			return fmt.Errorf("step %s missing description", step.ID)
 		}
 
 		// initialize status and attempts
@@ -185,11 +308,7 @@ func (td *TaskDecomposer) parseDecompositionResponse(content, originalTask strin
 		}
 	}
 
-	return &DecompositionResult{
-		OriginalTask: originalTask,
-		Steps:        rawResult.Steps,
-		Reasoning:    rawResult.Reasoning,
-	}, nil
+	return nil
 }
 
 func (dr *DecompositionResult) GetStepSummary() string {
@@ -0,0 +1,58 @@
+package decomposition
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffPlans renders a step-level diff between two plans.
+// Steps are matched by description, so renumbered or re-identified steps are still treated as unchanged.
+func DiffPlans(oldSteps, newSteps []TaskStep) string {
+	lcs := stepsLCS(oldSteps, newSteps)
+
+	var diff strings.Builder
+	i, j := 0, 0
+
+	for i < len(oldSteps) || j < len(newSteps) {
+		switch {
+		case i < len(oldSteps) && j < len(newSteps) && sameStep(oldSteps[i], newSteps[j]):
+			diff.WriteString(fmt.Sprintf("  %s\n", newSteps[j].Description))
+			i++
+			j++
+		case j < len(newSteps) && (i == len(oldSteps) || lcs[i][j+1] >= lcs[i+1][j]):
+			diff.WriteString(fmt.Sprintf("+ %s\n", newSteps[j].Description))
+			j++
+		default:
+			diff.WriteString(fmt.Sprintf("- %s\n", oldSteps[i].Description))
+			i++
+		}
+	}
+
+	return diff.String()
+}
+
+// stepsLCS builds the longest-common-subsequence table for two step lists
+func stepsLCS(a, b []TaskStep) [][]int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if sameStep(a[i], b[j]) {
+				table[i][j] = table[i+1][j+1] + 1
+			} else if table[i+1][j] >= table[i][j+1] {
+				table[i][j] = table[i+1][j]
+			} else {
+				table[i][j] = table[i][j+1]
+			}
+		}
+	}
+
+	return table
+}
+
+func sameStep(a, b TaskStep) bool {
+	return strings.EqualFold(strings.TrimSpace(a.Description), strings.TrimSpace(b.Description))
+}
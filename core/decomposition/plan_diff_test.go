@@ -0,0 +1,44 @@
+package decomposition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPlans(t *testing.T) {
+	oldSteps := []TaskStep{
+		{ID: "step_2", Description: "Implement the parser"},
+		{ID: "step_3", Description: "Add tests"},
+	}
+	newSteps := []TaskStep{
+		{ID: "step_1", Description: "Install the missing dependency"},
+		{ID: "step_2", Description: "implement the parser"},
+		{ID: "step_3", Description: "Update documentation"},
+	}
+
+	diff := DiffPlans(oldSteps, newSteps)
+
+	require.Equal(t, "+ Install the missing dependency\n"+
+		"  implement the parser\n"+
+		"+ Update documentation\n"+
+		"- Add tests\n", diff)
+}
+
+func TestDiffPlansIdentical(t *testing.T) {
+	steps := []TaskStep{{Description: "a"}, {Description: "b"}}
+
+	require.Equal(t, "  a\n  b\n", DiffPlans(steps, steps))
+}
+
+func TestParseReplanResponse(t *testing.T) {
+	td := &TaskDecomposer{}
+	content := "```json\n{\"reasoning\": \"work around the failure\", \"steps\": [{\"description\": \"retry with fix\"}]}\n```"
+
+	result, err := td.parseDecompositionResponse(content, "original")
+	require.NoError(t, err)
+	require.Len(t, result.Steps, 1)
+	require.Equal(t, "step_1", result.Steps[0].ID)
+	require.Equal(t, "pending", result.Steps[0].Status)
+	require.Equal(t, "original", result.OriginalTask)
+}
@@ -0,0 +1,68 @@
+package decomposition
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// editablePlan is the subset of a plan a user may change before execution
+type editablePlan struct {
+	Reasoning string     `json:"reasoning,omitempty" yaml:"reasoning,omitempty"`
+	Steps     []TaskStep `json:"steps" yaml:"steps"`
+}
+
+const planEditHeader = `# Edit the plan below and save the file to apply your changes.
+# Steps run top to bottom; remove a step to skip it, add a step to extend the plan.
+# The file may be rewritten as JSON if you prefer.
+
+`
+
+// MarshalPlanForEdit renders the editable part of a plan as a commented YAML document
+func MarshalPlanForEdit(plan *DecompositionResult) ([]byte, error) {
+	data, err := yaml.Marshal(editablePlan{
+		Reasoning: plan.Reasoning,
+		Steps:     plan.Steps,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize plan: %v", err)
+	}
+
+	return append([]byte(planEditHeader), data...), nil
+}
+
+// ParsePlan parses a user-edited plan in JSON or YAML form
+func ParsePlan(data []byte, originalTask string) (*DecompositionResult, error) {
+	var edited editablePlan
+
+	content := strings.TrimSpace(string(data))
+	if strings.HasPrefix(content, "{") {
+		if err := json.Unmarshal([]byte(content), &edited); err != nil {
+			return nil, fmt.Errorf("invalid plan JSON: %v", err)
+		}
+	} else if err := yaml.Unmarshal([]byte(content), &edited); err != nil {
+		return nil, fmt.Errorf("invalid plan YAML: %v", err)
+	}
+
+	if len(edited.Steps) == 0 {
+		return nil, fmt.Errorf("plan must contain at least one step")
+	}
+
+	result := &DecompositionResult{
+		OriginalTask: originalTask,
+		Steps:        edited.Steps,
+		Reasoning:    edited.Reasoning,
+	}
+
+	for i := range result.Steps {
+		result.Steps[i].Status = ""
+	}
+
+	if err := result.Normalize(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
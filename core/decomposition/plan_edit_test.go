@@ -0,0 +1,45 @@
+package decomposition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanEditRoundTrip(t *testing.T) {
+	plan := &DecompositionResult{
+		OriginalTask: "add login",
+		Reasoning:    "two steps are enough",
+		Steps: []TaskStep{
+			{ID: "step_1", Description: "Add handler", Reason: "entry point", Status: "pending", MaxAttempts: 3},
+			{ID: "step_2", Description: "Add tests", Dependencies: []string{"step_1"}, Status: "pending", MaxAttempts: 3},
+		},
+	}
+
+	data, err := MarshalPlanForEdit(plan)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "status")
+
+	parsed, err := ParsePlan(data, "add login")
+	require.NoError(t, err)
+	require.Equal(t, plan, parsed)
+}
+
+func TestParsePlanJSON(t *testing.T) {
+	parsed, err := ParsePlan([]byte(`{"steps": [{"description": "Only step"}]}`), "task")
+	require.NoError(t, err)
+	require.Len(t, parsed.Steps, 1)
+	require.Equal(t, "step_1", parsed.Steps[0].ID)
+	require.Equal(t, "pending", parsed.Steps[0].Status)
+}
+
+func TestParsePlanErrors(t *testing.T) {
+	_, err := ParsePlan([]byte("steps: []"), "task")
+	require.Error(t, err)
+
+	_, err = ParsePlan([]byte(`{"steps": [{"id": "a", "description": "  "}]}`), "task")
+	require.Error(t, err)
+
+	_, err = ParsePlan([]byte("steps: [unterminated"), "task")
+	require.Error(t, err)
+}
@@ -48,6 +48,7 @@ Available tools:
 - read_file: read a specific file with line numbers
 - write_file: create or modify files
 - lsp_edit: edit files with precise line-based operations
+- str_replace: replace exact text in a file
 
 - search_dir: search for patterns in files
 - find_files: find files by name/pattern
@@ -99,4 +100,11 @@ func NewToolCall(id, toolType string, function FunctionCall) ToolCall {
 type AIResponse struct {
 	Content   string     `json:"content"`
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Usage     Usage      `json:"usage,omitempty"`
+}
+
+// Usage is the token accounting reported by the provider for one request
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
 }
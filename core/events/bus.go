@@ -0,0 +1,145 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Sink consumes execution events. Handle is called synchronously in publish order.
+type Sink interface {
+	Handle(e Event)
+}
+
+// SinkFunc adapts a function to a Sink
+type SinkFunc func(e Event)
+
+func (f SinkFunc) Handle(e Event) {
+	f(e)
+}
+
+// Bus fans events out to its subscribers
+type Bus struct {
+	mu     sync.RWMutex
+	subs   []subscription
+	nextID int
+}
+
+type subscription struct {
+	id   int
+	sink Sink
+}
+
+// NewBus creates a bus with the given initial subscribers
+func NewBus(sinks ...Sink) *Bus {
+	b := &Bus{}
+	for _, sink := range sinks {
+		b.Subscribe(sink)
+	}
+	return b
+}
+
+// Subscribe adds a sink and returns a function that removes it
+func (b *Bus) Subscribe(sink Sink) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.subs = append(b.subs, subscription{id: id, sink: sink})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		for i, sub := range b.subs {
+			if sub.id == id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish stamps the event and delivers it to every subscriber in subscription order
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		sub.sink.Handle(e)
+	}
+}
+
+// JSONLinesSink writes every event as one JSON object per line
+type JSONLinesSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{enc: json.NewEncoder(w)}
+}
+
+func (s *JSONLinesSink) Handle(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// a broken writer must not stop the task
+	_ = s.enc.Encode(e)
+}
+
+// Recorder keeps every event in memory, mainly for tests
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Handle(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+// Events returns a copy of the recorded events
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+// Types returns the types of the recorded events in order
+func (r *Recorder) Types() []Type {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	types := make([]Type, len(r.events))
+	for i, e := range r.events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+// OfType returns the recorded events of the given type
+func (r *Recorder) OfType(t Type) []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []Event
+	for _, e := range r.events {
+		if e.Type == t {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
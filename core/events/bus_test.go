@@ -0,0 +1,57 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBusDeliversInOrder(t *testing.T) {
+	first := NewRecorder()
+	second := NewRecorder()
+	bus := NewBus(first)
+	unsubscribe := bus.Subscribe(second)
+
+	bus.Publish(Event{Type: TaskStarted, Text: "task"})
+	unsubscribe()
+	bus.Publish(Event{Type: TaskCompleted})
+
+	require.Equal(t, []Type{TaskStarted, TaskCompleted}, first.Types())
+	require.Equal(t, []Type{TaskStarted}, second.Types())
+	require.False(t, first.Events()[0].Time.IsZero())
+}
+
+func TestJSONLinesSink(t *testing.T) {
+	var buf bytes.Buffer
+	bus := NewBus(NewJSONLinesSink(&buf))
+
+	bus.Publish(Event{Type: ToolCallStarted, Tool: &ToolCall{ID: "1", Name: "bash", Args: map[string]any{"command": "ls"}}})
+	bus.Publish(Event{Type: Notice, Level: LevelWarning, Text: "careful"})
+
+	scanner := bufio.NewScanner(&buf)
+	var decoded []Event
+	for scanner.Scan() {
+		var e Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		decoded = append(decoded, e)
+	}
+
+	require.Len(t, decoded, 2)
+	require.Equal(t, "bash", decoded[0].Tool.Name)
+	require.Equal(t, "ls", decoded[0].Tool.Args["command"])
+	require.Equal(t, LevelWarning, decoded[1].Level)
+}
+
+func TestRecorderOfType(t *testing.T) {
+	rec := NewRecorder()
+	rec.Handle(Event{Type: Notice, Text: "a"})
+	rec.Handle(Event{Type: Error, Error: "b"})
+	rec.Handle(Event{Type: Notice, Text: "c"})
+
+	notices := rec.OfType(Notice)
+	require.Len(t, notices, 2)
+	require.Equal(t, "c", notices[1].Text)
+}
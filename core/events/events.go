@@ -0,0 +1,101 @@
+package events
+
+import (
+	"time"
+)
+
+// Type identifies the kind of an execution event
+type Type string
+
+const (
+	TaskStarted       Type = "task_started"
+	AssistantDelta    Type = "assistant_delta"
+	ThinkingStarted   Type = "thinking_started"
+	ThinkingFinished  Type = "thinking_finished"
+	ToolCallStarted   Type = "tool_call_started"
+	ToolOutput        Type = "tool_output"
+	ToolCallFinished  Type = "tool_call_finished"
+	PlanCreated       Type = "plan_created"
+	StepStatus        Type = "step_status"
+	Usage             Type = "usage"
+	Notice            Type = "notice"
+	ApprovalRequested Type = "approval_requested"
+	Error             Type = "error"
+	TaskCompleted     Type = "task_completed"
+)
+
+// Level is the severity of a notice
+type Level string
+
+const (
+	LevelInfo    Level = "info"
+	LevelWarning Level = "warning"
+)
+
+// Event is a single step of task execution. Only the fields relevant to Type are set.
+type Event struct {
+	Type     Type        `json:"type"`
+	Time     time.Time   `json:"time"`
+	Text     string      `json:"text,omitempty"`
+	Level    Level       `json:"level,omitempty"`
+	Tool     *ToolCall   `json:"tool,omitempty"`
+	Plan     *Plan       `json:"plan,omitempty"`
+	Step     *Step       `json:"step,omitempty"`
+	Usage    *TokenUsage `json:"usage,omitempty"`
+	Approval *Approval   `json:"approval,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// ToolCall describes a tool invocation; Result and Error are set once it finished
+type ToolCall struct {
+	ID       string         `json:"id"`
+	Name     string         `json:"name"`
+	Args     map[string]any `json:"args,omitempty"`
+	Result   string         `json:"result,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Duration time.Duration  `json:"duration,omitempty"`
+}
+
+// Plan is a decomposed task; Diff is set when the plan replaces a previous one
+type Plan struct {
+	Steps []PlanStep `json:"steps"`
+	Diff  string     `json:"diff,omitempty"`
+}
+
+// PlanStep is one step of a plan
+type PlanStep struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Status      string `json:"status,omitempty"`
+}
+
+// Step reports a status change of a plan step
+type Step struct {
+	Index       int    `json:"index"`
+	Total       int    `json:"total"`
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+// TokenUsage is the token accounting of one model request and the running total of the task
+type TokenUsage struct {
+	InputTokens       int `json:"input_tokens"`
+	OutputTokens      int `json:"output_tokens"`
+	TotalInputTokens  int `json:"total_input_tokens"`
+	TotalOutputTokens int `json:"total_output_tokens"`
+}
+
+// Approval is a decision the client has to make before the task continues.
+// Kind is "plan" or "tool"; tool approvals carry the call, its preview and the pattern
+// an "always allow" answer remembers.
+type Approval struct {
+	ID      string    `json:"id"`
+	Kind    string    `json:"kind"`
+	Plan    *Plan     `json:"plan,omitempty"`
+	Tool    *ToolCall `json:"tool,omitempty"`
+	Preview string    `json:"preview,omitempty"`
+	Pattern string    `json:"pattern,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+}
@@ -0,0 +1,143 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vadiminshakov/autonomy/core/policy"
+)
+
+const (
+	defaultTimeout = 60 * time.Second
+	// waitDelay bounds how long a killed hook may hold its output pipes open
+	waitDelay = 2 * time.Second
+	// blockExitCode makes a shell hook block the action with stderr as the reason
+	blockExitCode = 2
+)
+
+// Command is a shell hook from hooks.json
+type Command struct {
+	// Tools limits tool hooks to these tool names; empty matches every tool
+	Tools []string `json:"tools,omitempty"`
+	// Files limits tool hooks to calls whose path argument matches this glob, e.g. "*.go"
+	Files   string `json:"files,omitempty"`
+	Command string `json:"command"`
+	// Timeout in seconds
+	Timeout int `json:"timeout,omitempty"`
+}
+
+func (c Command) matches(in Input) bool {
+	if len(c.Tools) > 0 {
+		found := false
+		for _, name := range c.Tools {
+			if name == in.Tool || name == "*" {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if c.Files != "" {
+		path := targetFile(in.Args)
+		if path == "" {
+			return false
+		}
+		matched, _ := filepath.Match(c.Files, path)
+		baseMatched, _ := filepath.Match(c.Files, filepath.Base(path))
+		return matched || baseMatched
+	}
+
+	return true
+}
+
+// run executes the command with the input as JSON on stdin. Exit status 0 proceeds,
+// 2 blocks with stderr as the reason and anything else is reported as a failed hook.
+func (c Command) run(ctx context.Context, in Input) (Output, error) {
+	timeout := defaultTimeout
+	if c.Timeout > 0 {
+		timeout = time.Duration(c.Timeout) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := policy.Check(ctx, "hook", c.Command); err != nil {
+		return Output{}, fmt.Errorf("hook %q not run: %v", c.Command, err)
+	}
+
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return Output{}, fmt.Errorf("failed to encode hook input: %w", err)
+	}
+
+	cmd := shellCommand(ctx, c.Command)
+	cmd.Dir = in.Dir
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"AUTONOMY_EVENT="+string(in.Event),
+		"AUTONOMY_TOOL="+in.Tool,
+		"AUTONOMY_FILE="+targetFile(in.Args),
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return Output{}, fmt.Errorf("hook %q timed out after %v", c.Command, timeout)
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return parseOutput(stdout.String()), nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == blockExitCode:
+		reason := strings.TrimSpace(stderr.String())
+		if reason == "" {
+			reason = strings.TrimSpace(stdout.String())
+		}
+		return Output{Block: true, Reason: reason}, nil
+	default:
+		return Output{}, fmt.Errorf("hook %q failed: %v: %s", c.Command, err, strings.TrimSpace(stderr.String()))
+	}
+}
+
+// parseOutput reads a JSON decision, or takes plain output as a message
+func parseOutput(stdout string) Output {
+	text := strings.TrimSpace(stdout)
+	if text == "" {
+		return Output{}
+	}
+
+	if strings.HasPrefix(text, "{") {
+		var o Output
+		if err := json.Unmarshal([]byte(text), &o); err == nil {
+			return o
+		}
+	}
+
+	return Output{Message: text}
+}
+
+// targetFile returns the file a tool call operates on, if any
+func targetFile(args map[string]any) string {
+	for _, key := range []string{"path", "file_path", "file"} {
+		if path, ok := args[key].(string); ok && path != "" {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,234 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/vadiminshakov/autonomy/core/config"
+)
+
+// Event is a point in the task lifecycle where hooks fire
+type Event string
+
+const (
+	PreTool       Event = "pre_tool"
+	PostTool      Event = "post_tool"
+	PreCompletion Event = "pre_completion"
+	TaskEnd       Event = "task_end"
+)
+
+const (
+	configDirName  = ".autonomy"
+	configFileName = "hooks.json"
+)
+
+// Input is the context a hook receives, as JSON on stdin for shell hooks
+type Input struct {
+	Event   Event          `json:"event"`
+	Dir     string         `json:"cwd"`
+	Task    string         `json:"task,omitempty"`
+	Tool    string         `json:"tool,omitempty"`
+	Args    map[string]any `json:"args,omitempty"`
+	Result  string         `json:"result,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Summary string         `json:"summary,omitempty"`
+	Status  string         `json:"status,omitempty"`
+}
+
+// Output is a hook's decision. Shell hooks print it as JSON on stdout;
+// plain text output is taken as a message.
+type Output struct {
+	// Block stops the action: the tool is not run, its result becomes an error, or completion is refused
+	Block  bool   `json:"block,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	// Args replaces the tool arguments (pre_tool)
+	Args map[string]any `json:"args,omitempty"`
+	// Result replaces the tool result (post_tool)
+	Result *string `json:"result,omitempty"`
+	// Message annotates the action for the agent
+	Message string `json:"message,omitempty"`
+}
+
+// Callback is a hook registered from Go code
+type Callback func(ctx context.Context, in Input) (Output, error)
+
+// Outcome combines the decisions of every hook that fired
+type Outcome struct {
+	Blocked  bool
+	Reason   string
+	Args     map[string]any
+	Result   *string
+	Messages []string
+	// Errors are hooks that failed to run; they never block the action
+	Errors []error
+}
+
+// Config is the content of a hooks.json file
+type Config struct {
+	Hooks map[Event][]Command `json:"hooks"`
+}
+
+var (
+	callbacksMu sync.RWMutex
+	callbacks   = make(map[Event][]Callback)
+)
+
+// Register adds a Go callback that fires on event before the configured shell hooks
+func Register(event Event, cb Callback) {
+	callbacksMu.Lock()
+	defer callbacksMu.Unlock()
+	callbacks[event] = append(callbacks[event], cb)
+}
+
+func registered(event Event) []Callback {
+	callbacksMu.RLock()
+	defer callbacksMu.RUnlock()
+	return append([]Callback(nil), callbacks[event]...)
+}
+
+// Manager runs the hooks of one project
+type Manager struct {
+	dir      string
+	commands map[Event][]Command
+}
+
+// ErrUntrusted is returned with the user's hooks when the hooks of an untrusted project were skipped
+var ErrUntrusted = errors.New("project is not trusted")
+
+// Load reads ~/.autonomy/hooks.json and <dir>/.autonomy/hooks.json; project hooks run after the user's.
+// The hooks of a project only run once the user trusted it, since they come with the repository.
+// Missing files are not an error.
+func Load(dir string) (*Manager, error) {
+	m := &Manager{dir: dir, commands: make(map[Event][]Command)}
+
+	var paths []string
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, configDirName, configFileName))
+	}
+
+	var untrusted error
+	project := filepath.Join(dir, configDirName, configFileName)
+	if len(paths) == 0 || paths[0] != project {
+		if _, err := os.Stat(project); err == nil && !config.IsTrusted(dir) {
+			untrusted = fmt.Errorf("%w, %s ignored", ErrUntrusted, project)
+		} else {
+			paths = append(paths, project)
+		}
+	}
+
+	for _, path := range paths {
+		if err := m.loadFile(path); err != nil {
+			return m, err
+		}
+	}
+
+	return m, untrusted
+}
+
+func (m *Manager) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read hooks: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("invalid hooks file %s: %w", path, err)
+	}
+
+	for event, commands := range cfg.Hooks {
+		switch event {
+		case PreTool, PostTool, PreCompletion, TaskEnd:
+		default:
+			return fmt.Errorf("invalid hooks file %s: unknown event %q", path, event)
+		}
+
+		for _, c := range commands {
+			if c.Command == "" {
+				return fmt.Errorf("invalid hooks file %s: %s hook without command", path, event)
+			}
+		}
+		m.commands[event] = append(m.commands[event], commands...)
+	}
+
+	return nil
+}
+
+// Run fires the hooks of in.Event in order. Argument and result changes are passed on
+// to the next hook; the first hook that blocks stops the chain.
+func (m *Manager) Run(ctx context.Context, in Input) Outcome {
+	var out Outcome
+	if in.Dir == "" && m != nil {
+		in.Dir = m.dir
+	}
+
+	apply := func(o Output) bool {
+		if o.Message != "" {
+			out.Messages = append(out.Messages, o.Message)
+		}
+		if o.Args != nil {
+			out.Args = o.Args
+			in.Args = o.Args
+		}
+		if o.Result != nil {
+			out.Result = o.Result
+			in.Result = *o.Result
+		}
+		if o.Block {
+			out.Blocked = true
+			out.Reason = o.Reason
+			if out.Reason == "" {
+				out.Reason = fmt.Sprintf("blocked by %s hook", in.Event)
+			}
+		}
+		return o.Block
+	}
+
+	for _, cb := range registered(in.Event) {
+		o, err := cb(ctx, in)
+		if err != nil {
+			out.Errors = append(out.Errors, err)
+			continue
+		}
+		if apply(o) {
+			return out
+		}
+	}
+
+	if m == nil {
+		return out
+	}
+
+	for _, c := range m.commands[in.Event] {
+		if !c.matches(in) {
+			continue
+		}
+
+		o, err := c.run(ctx, in)
+		if err != nil {
+			out.Errors = append(out.Errors, err)
+			continue
+		}
+		if apply(o) {
+			return out
+		}
+	}
+
+	return out
+}
+
+// Empty reports whether no hook can fire on event
+func (m *Manager) Empty(event Event) bool {
+	if len(registered(event)) > 0 {
+		return false
+	}
+	return m == nil || len(m.commands[event]) == 0
+}
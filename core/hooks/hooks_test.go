@@ -0,0 +1,136 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadiminshakov/autonomy/core/config"
+)
+
+func writeHooks(t *testing.T, content string) string {
+	t.Setenv("HOME", t.TempDir())
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, configDirName), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, configDirName, configFileName), []byte(content), 0o644))
+	require.NoError(t, config.Trust(dir))
+
+	return dir
+}
+
+func TestShellHooks(t *testing.T) {
+	dir := writeHooks(t, `{"hooks": {
+		"pre_tool": [
+			{"tools": ["write_file"], "files": "*.pb.go", "command": "echo generated files are read-only >&2; exit 2"},
+			{"tools": ["bash"], "command": "echo '{\"args\": {\"command\": \"echo safe\"}, \"message\": \"rewritten\"}'"}
+		],
+		"post_tool": [
+			{"tools": ["write_file"], "files": "*.go", "command": "echo formatted $AUTONOMY_FILE"},
+			{"command": "exit 1"}
+		]
+	}}`)
+
+	m, err := Load(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	blocked := m.Run(ctx, Input{Event: PreTool, Tool: "write_file", Args: map[string]any{"path": "api/service.pb.go"}})
+	require.True(t, blocked.Blocked)
+	require.Equal(t, "generated files are read-only", blocked.Reason)
+
+	allowed := m.Run(ctx, Input{Event: PreTool, Tool: "write_file", Args: map[string]any{"path": "main.go"}})
+	require.False(t, allowed.Blocked)
+
+	rewritten := m.Run(ctx, Input{Event: PreTool, Tool: "bash", Args: map[string]any{"command": "rm -rf /"}})
+	require.Equal(t, "echo safe", rewritten.Args["command"])
+	require.Equal(t, []string{"rewritten"}, rewritten.Messages)
+
+	post := m.Run(ctx, Input{Event: PostTool, Tool: "write_file", Args: map[string]any{"path": "main.go"}})
+	require.False(t, post.Blocked)
+	require.Equal(t, []string{"formatted main.go"}, post.Messages)
+	require.Len(t, post.Errors, 1)
+}
+
+func TestHookReceivesInputOnStdin(t *testing.T) {
+	dir := writeHooks(t, `{"hooks": {"task_end": [{"command": "cat > result.json"}]}}`)
+
+	m, err := Load(dir)
+	require.NoError(t, err)
+
+	m.Run(context.Background(), Input{Event: TaskEnd, Status: "completed", Summary: "done"})
+
+	data, err := os.ReadFile(filepath.Join(dir, "result.json"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"event": "task_end", "cwd": "`+dir+`", "status": "completed", "summary": "done"}`, string(data))
+}
+
+func TestUntrustedProjectHooks(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := t.TempDir()
+	for _, d := range []string{home, dir} {
+		require.NoError(t, os.MkdirAll(filepath.Join(d, configDirName), 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(home, configDirName, configFileName),
+		[]byte(`{"hooks": {"task_end": [{"command": "echo user"}]}}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, configDirName, configFileName),
+		[]byte(`{"hooks": {"task_end": [{"command": "echo project"}]}}`), 0o644))
+
+	// the user's hooks still run
+	m, err := Load(dir)
+	require.ErrorIs(t, err, ErrUntrusted)
+	require.Equal(t, []string{"user"}, m.Run(context.Background(), Input{Event: TaskEnd}).Messages)
+
+	require.NoError(t, config.Trust(dir))
+	m, err = Load(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"user", "project"}, m.Run(context.Background(), Input{Event: TaskEnd}).Messages)
+}
+
+func TestHookTimeoutKillsChildren(t *testing.T) {
+	dir := writeHooks(t, `{"hooks": {"task_end": [{"command": "(sleep 30; echo late) & sleep 30", "timeout": 1}]}}`)
+
+	m, err := Load(dir)
+	require.NoError(t, err)
+
+	start := time.Now()
+	outcome := m.Run(context.Background(), Input{Event: TaskEnd})
+	require.Less(t, time.Since(start), 10*time.Second)
+	require.Len(t, outcome.Errors, 1)
+	require.ErrorContains(t, outcome.Errors[0], "timed out after 1s")
+}
+
+func TestInvalidHooksFile(t *testing.T) {
+	dir := writeHooks(t, `{"hooks": {"on_save": [{"command": "true"}]}}`)
+
+	_, err := Load(dir)
+	require.ErrorContains(t, err, "unknown event")
+}
+
+func TestRegisteredCallbacks(t *testing.T) {
+	t.Cleanup(func() {
+		callbacksMu.Lock()
+		delete(callbacks, PreCompletion)
+		callbacksMu.Unlock()
+	})
+
+	Register(PreCompletion, func(_ context.Context, in Input) (Output, error) {
+		if in.Summary == "" {
+			return Output{Block: true, Reason: "summary is required"}, nil
+		}
+		return Output{}, nil
+	})
+
+	var m *Manager
+	require.False(t, m.Empty(PreCompletion))
+	require.True(t, m.Empty(TaskEnd))
+
+	require.True(t, m.Run(context.Background(), Input{Event: PreCompletion}).Blocked)
+	require.False(t, m.Run(context.Background(), Input{Event: PreCompletion, Summary: "done"}).Blocked)
+}
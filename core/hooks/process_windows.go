@@ -0,0 +1,16 @@
+//go:build windows
+
+package hooks
+
+import (
+	"context"
+	"os/exec"
+)
+
+// shellCommand runs a hook through bash; Windows has no process groups to signal,
+// so only bash itself is killed and WaitDelay releases the output of orphaned children
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
@@ -0,0 +1,140 @@
+package instructions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxFileSize caps how much of one instructions file goes into the prompt
+const maxFileSize = 32 * 1024
+
+// fileNames are looked up in every directory, in this order
+var fileNames = []string{
+	"AUTONOMY.md",
+	filepath.Join(".autonomy", "instructions.md"),
+}
+
+// Scope tells where an instructions file comes from
+type Scope string
+
+const (
+	ScopeUser    Scope = "user"
+	ScopeProject Scope = "project"
+	ScopeNested  Scope = "directory"
+)
+
+// Source is one instructions file
+type Source struct {
+	Path    string
+	Dir     string
+	Scope   Scope
+	Content string
+}
+
+// FindRoot returns the repository root containing dir, or dir itself outside a repository
+func FindRoot(dir string) string {
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dir
+		}
+		current = parent
+	}
+}
+
+// Load returns the instructions that apply to work in dir, lowest precedence first:
+// the user's home, the repository root, then every directory down to dir.
+func Load(dir string) []Source {
+	var sources []Source
+
+	if home, err := os.UserHomeDir(); err == nil {
+		sources = append(sources, readDir(home, ScopeUser)...)
+	}
+
+	root := FindRoot(dir)
+	if home, _ := os.UserHomeDir(); root != home {
+		sources = append(sources, readDir(root, ScopeProject)...)
+	}
+
+	return append(sources, Between(root, dir)...)
+}
+
+// Between returns the instructions of the directories below root down to dir, outermost first.
+// Directories outside root yield nothing.
+func Between(root, dir string) []Source {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+
+	var sources []Source
+	current := root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		sources = append(sources, readDir(current, ScopeNested)...)
+	}
+
+	return sources
+}
+
+func readDir(dir string, scope Scope) []Source {
+	var sources []Source
+
+	for _, name := range fileNames {
+		path := filepath.Join(dir, name)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		content := strings.TrimSpace(truncate(string(data)))
+		if content == "" {
+			continue
+		}
+
+		sources = append(sources, Source{Path: path, Dir: dir, Scope: scope, Content: content})
+	}
+
+	return sources
+}
+
+func truncate(content string) string {
+	if len(content) <= maxFileSize {
+		return content
+	}
+
+	cut := maxFileSize
+	for cut > 0 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+
+	return content[:cut] + "\n... [truncated]"
+}
+
+// Format renders instructions for the prompt; later sources take precedence over earlier ones
+func Format(sources []Source) string {
+	if len(sources) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("PROJECT INSTRUCTIONS:\n")
+	b.WriteString("Follow these instructions from the user and the project. When they conflict, " +
+		"later sections take precedence over earlier ones, and all of them over general guidance above.\n")
+
+	for _, s := range sources {
+		b.WriteString(fmt.Sprintf("\n--- %s instructions (%s) ---\n", s.Scope, s.Path))
+		b.WriteString(s.Content)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
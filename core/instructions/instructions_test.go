@@ -0,0 +1,67 @@
+package instructions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestLoadMergesByPrecedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeFile(t, filepath.Join(home, "AUTONOMY.md"), "answer briefly")
+
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0o755))
+	writeFile(t, filepath.Join(root, "AUTONOMY.md"), "use testify")
+	writeFile(t, filepath.Join(root, ".autonomy", "instructions.md"), "run make lint")
+	writeFile(t, filepath.Join(root, "api", "AUTONOMY.md"), "never edit generated code")
+
+	sub := filepath.Join(root, "api", "v1")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+
+	require.Equal(t, root, FindRoot(sub))
+
+	sources := Load(sub)
+	require.Len(t, sources, 4)
+	require.Equal(t, ScopeUser, sources[0].Scope)
+	require.Equal(t, "use testify", sources[1].Content)
+	require.Equal(t, "run make lint", sources[2].Content)
+	require.Equal(t, ScopeNested, sources[3].Scope)
+
+	text := Format(sources)
+	require.Less(t, strings.Index(text, "answer briefly"), strings.Index(text, "use testify"))
+	require.Less(t, strings.Index(text, "use testify"), strings.Index(text, "never edit generated code"))
+}
+
+func TestBetween(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "AUTONOMY.md"), "root")
+	writeFile(t, filepath.Join(root, "a", "AUTONOMY.md"), "a")
+	writeFile(t, filepath.Join(root, "a", "b", "AUTONOMY.md"), "b")
+
+	sources := Between(root, filepath.Join(root, "a", "b"))
+	require.Len(t, sources, 2)
+	require.Equal(t, "a", sources[0].Content)
+	require.Equal(t, "b", sources[1].Content)
+
+	require.Empty(t, Between(root, root))
+	require.Empty(t, Between(filepath.Join(root, "a"), root))
+}
+
+func TestTruncate(t *testing.T) {
+	content := strings.Repeat("я", maxFileSize)
+	truncated := truncate(content)
+
+	require.Less(t, len(truncated), len(content))
+	require.True(t, strings.HasSuffix(truncated, "[truncated]"))
+	require.True(t, strings.HasPrefix(truncated, "яя"))
+}
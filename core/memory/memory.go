@@ -0,0 +1,348 @@
+package memory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/vadiminshakov/autonomy/core/instructions"
+)
+
+// Scope tells who a memory belongs to
+type Scope string
+
+const (
+	// ScopeProject memories are stored in the repository and apply to it only
+	ScopeProject Scope = "project"
+	// ScopeUser memories are stored in the home directory and apply everywhere
+	ScopeUser Scope = "user"
+)
+
+const (
+	memoryDir  = ".autonomy/memory"
+	memoryFile = "memories.json"
+
+	maxContentLength = 2000
+)
+
+// ErrNotFound is returned when forgetting an unknown memory
+var ErrNotFound = errors.New("memory not found")
+
+// Entry is one remembered fact
+type Entry struct {
+	ID        string    `json:"id"`
+	Content   string    `json:"content"`
+	Tags      []string  `json:"tags,omitempty"`
+	Scope     Scope     `json:"scope"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// mu serializes access to memory files from concurrent tasks
+var mu sync.Mutex
+
+// Store reads and writes the memories of a project and its user
+type Store struct {
+	paths map[Scope]string
+}
+
+// Open returns the store for the repository containing dir
+func Open(dir string) *Store {
+	s := &Store{paths: map[Scope]string{
+		ScopeProject: filepath.Join(instructions.FindRoot(dir), memoryDir, memoryFile),
+	}}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		s.paths[ScopeUser] = filepath.Join(home, memoryDir, memoryFile)
+	}
+
+	return s
+}
+
+// Remember saves a new memory
+func (s *Store) Remember(scope Scope, content string, tags []string) (Entry, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return Entry{}, fmt.Errorf("memory content is empty")
+	}
+	if len(content) > maxContentLength {
+		return Entry{}, fmt.Errorf("memory is too long (%d characters), keep it under %d", len(content), maxContentLength)
+	}
+
+	path, ok := s.paths[scope]
+	if !ok {
+		return Entry{}, fmt.Errorf("unknown memory scope %q", scope)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	entries, err := readEntries(path)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	for _, e := range entries {
+		if e.Content == content {
+			return e, nil
+		}
+	}
+
+	entry := Entry{
+		ID:        newID(content),
+		Content:   content,
+		Tags:      normalizeTags(tags),
+		Scope:     scope,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := writeEntries(path, append(entries, entry)); err != nil {
+		return Entry{}, err
+	}
+
+	return entry, nil
+}
+
+// Forget removes a memory from whichever scope holds it
+func (s *Store) Forget(id string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, path := range s.paths {
+		entries, err := readEntries(path)
+		if err != nil {
+			return err
+		}
+
+		for i, e := range entries {
+			if e.ID == id {
+				return writeEntries(path, append(entries[:i], entries[i+1:]...))
+			}
+		}
+	}
+
+	return ErrNotFound
+}
+
+// All returns the memories of both scopes, newest first
+func (s *Store) All() ([]Entry, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var all []Entry
+	for _, scope := range []Scope{ScopeProject, ScopeUser} {
+		path, ok := s.paths[scope]
+		if !ok {
+			continue
+		}
+
+		entries, err := readEntries(path)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, entries...)
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].CreatedAt.After(all[j].CreatedAt)
+	})
+
+	return all, nil
+}
+
+// Recall returns up to limit memories matching the query keywords, best matches first.
+// An empty query returns the newest memories.
+func (s *Store) Recall(query string, limit int) ([]Entry, error) {
+	all, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+
+	terms := keywords(query)
+	if len(terms) == 0 {
+		return head(all, limit), nil
+	}
+
+	type scored struct {
+		entry Entry
+		score int
+	}
+
+	var matches []scored
+	for _, e := range all {
+		if score := relevance(e, terms); score > 0 {
+			matches = append(matches, scored{e, score})
+		}
+	}
+
+	// all is sorted newest first, so ties keep the newer memory in front
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	result := make([]Entry, 0, len(matches))
+	for _, m := range matches {
+		result = append(result, m.entry)
+	}
+
+	return head(result, limit), nil
+}
+
+// Format renders memories for the prompt or a tool result
+func Format(entries []Entry) string {
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(fmt.Sprintf("- [%s] (%s) %s", e.ID, e.Scope, e.Content))
+		if len(e.Tags) > 0 {
+			b.WriteString(fmt.Sprintf(" [tags: %s]", strings.Join(e.Tags, ", ")))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func relevance(e Entry, terms []string) int {
+	words := make(map[string]bool)
+	for _, w := range keywords(e.Content) {
+		words[w] = true
+	}
+
+	tags := make(map[string]bool)
+	for _, tag := range e.Tags {
+		for _, w := range keywords(tag) {
+			tags[w] = true
+		}
+	}
+
+	score := 0
+	for _, term := range terms {
+		if tags[term] {
+			score += 2
+		}
+		if words[term] {
+			score++
+		}
+	}
+
+	return score
+}
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true, "that": true, "from": true,
+	"into": true, "are": true, "was": true, "were": true, "use": true, "all": true, "any": true,
+	"add": true, "make": true, "please": true, "should": true, "can": true, "not": true, "you": true,
+}
+
+// keywords splits text into lower-case search terms without stop words
+func keywords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+
+	seen := make(map[string]bool)
+	var terms []string
+	for _, f := range fields {
+		if len([]rune(f)) < 3 || stopWords[f] {
+			continue
+		}
+
+		f = stem(f)
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		terms = append(terms, f)
+	}
+
+	return terms
+}
+
+// stem drops common English suffixes, so "tests" and "testing" find "test"
+func stem(word string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if strings.HasSuffix(word, suffix) && len(word)-len(suffix) >= 4 {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
+
+func normalizeTags(tags []string) []string {
+	var result []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+func head(entries []Entry, limit int) []Entry {
+	if limit > 0 && len(entries) > limit {
+		return entries[:limit]
+	}
+	return entries
+}
+
+func newID(content string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", content, time.Now().UnixNano())))
+	return "mem-" + hex.EncodeToString(sum[:4])
+}
+
+func readEntries(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memories: %w", err)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid memory file %s: %w", path, err)
+	}
+
+	return entries, nil
+}
+
+// writeEntries replaces the file atomically, so a crash never leaves it half-written
+func writeEntries(path string, entries []Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create memory directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), memoryFile+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write memories: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write memories: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write memories: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write memories: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newStore(t *testing.T) (*Store, string) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0o755))
+
+	return Open(root), root
+}
+
+func TestRememberRecallForget(t *testing.T) {
+	store, root := newStore(t)
+
+	build, err := store.Remember(ScopeProject, "Run tests with `make test`, plain go test misses the race flag", []string{"testing"})
+	require.NoError(t, err)
+	_, err = store.Remember(ScopeProject, "Migrations live in db/migrations and are applied by the server on start", nil)
+	require.NoError(t, err)
+	pref, err := store.Remember(ScopeUser, "The user prefers table-driven tests", []string{"Testing"})
+	require.NoError(t, err)
+
+	require.FileExists(t, filepath.Join(root, ".autonomy", "memory", "memories.json"))
+
+	// remembering the same fact twice keeps one entry
+	again, err := store.Remember(ScopeProject, build.Content, nil)
+	require.NoError(t, err)
+	require.Equal(t, build.ID, again.ID)
+
+	found, err := store.Recall("how do I run the tests?", 10)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	require.Equal(t, build.ID, found[0].ID)
+	require.Equal(t, pref.ID, found[1].ID)
+
+	newest, err := store.Recall("", 1)
+	require.NoError(t, err)
+	require.Len(t, newest, 1)
+
+	require.NoError(t, store.Forget(build.ID))
+	require.ErrorIs(t, store.Forget(build.ID), ErrNotFound)
+
+	all, err := store.All()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+}
+
+func TestRememberValidates(t *testing.T) {
+	store, _ := newStore(t)
+
+	_, err := store.Remember(ScopeProject, "  ", nil)
+	require.Error(t, err)
+
+	_, err = store.Remember("team", "shared fact", nil)
+	require.ErrorContains(t, err, "unknown memory scope")
+}
+
+func TestKeywords(t *testing.T) {
+	require.Equal(t, []string{"run", "test", "go_test"}, keywords("Run the tests, run go_test!"))
+	require.Equal(t, []string{"test", "build"}, keywords("testing builds"))
+}
@@ -0,0 +1,74 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrDenied is returned for commands the policy blocks
+var ErrDenied = errors.New("blocked by policy")
+
+// Approver asks the user about a command that an ask rule matched
+type Approver func(ctx context.Context, script string, d Decision) (bool, error)
+
+type policyKey struct{}
+
+type approverKey struct{}
+
+// WithPolicy returns a context whose commands are checked against p
+func WithPolicy(ctx context.Context, p *Policy) context.Context {
+	return context.WithValue(ctx, policyKey{}, p)
+}
+
+// FromContext returns the policy of ctx, or the built-in one
+func FromContext(ctx context.Context) *Policy {
+	if p, ok := ctx.Value(policyKey{}).(*Policy); ok && p != nil {
+		return p
+	}
+	return Default()
+}
+
+// WithApprover returns a context in which ask decisions are resolved by a
+func WithApprover(ctx context.Context, a Approver) context.Context {
+	return context.WithValue(ctx, approverKey{}, a)
+}
+
+// Check evaluates a command with the policy of ctx, logs the decision and returns an error
+// wrapping ErrDenied unless the command may run. Without an approver, ask means deny.
+func Check(ctx context.Context, source, script string) error {
+	p := FromContext(ctx)
+
+	d := p.Evaluate(script)
+	p.Log(source, script, d)
+
+	switch d.Action {
+	case Deny:
+		return fmt.Errorf("%w: execution of command '%s' is blocked for security reasons: %s", ErrDenied, script, d.describe())
+
+	case Ask:
+		approve, _ := ctx.Value(approverKey{}).(Approver)
+		if approve == nil {
+			return fmt.Errorf("%w: command '%s' needs approval, which is not available here: %s",
+				ErrDenied, script, d.describe())
+		}
+
+		ok, err := approve(ctx, script, d)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			p.Log(source, script, Decision{Action: Deny, Command: d.Command, Rule: d.Rule, Reason: "rejected by user"})
+			return fmt.Errorf("%w: command '%s' was rejected by the user", ErrDenied, script)
+		}
+	}
+
+	return nil
+}
+
+func (d Decision) describe() string {
+	if d.Reason != "" {
+		return fmt.Sprintf("%s (%s)", d.Reason, d.Command)
+	}
+	return d.Command
+}
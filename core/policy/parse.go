@@ -0,0 +1,547 @@
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Command is one simple command of a shell script
+type Command struct {
+	// Program is the base name of the executable, after wrappers like sudo or env
+	Program string
+	Args    []string
+	// Wrappers are the prefixes that run Program, e.g. sudo, env, timeout, xargs
+	Wrappers []string
+	// Redirects are the targets of output and input redirections
+	Redirects []string
+}
+
+// Subcommand returns the first argument that is not a flag, e.g. "push" for git push
+func (c Command) Subcommand() string {
+	for _, arg := range c.Args {
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+	}
+	return ""
+}
+
+func (c Command) String() string {
+	return strings.Join(append(append(append([]string(nil), c.Wrappers...), c.Program), c.Args...), " ")
+}
+
+// Pipeline is a sequence of commands connected with pipes
+type Pipeline struct {
+	Commands []Command
+}
+
+// Parse splits a shell script into pipelines of commands. It understands quoting,
+// operators, redirections, heredocs and command substitution; scripts nested in
+// $(...), backticks, bash -c and eval are parsed as well.
+func Parse(script string) ([]Pipeline, error) {
+	p := &parser{src: []rune(script)}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+
+	for _, nested := range p.nested {
+		pipelines, err := Parse(nested)
+		if err != nil {
+			return nil, err
+		}
+		p.pipelines = append(p.pipelines, pipelines...)
+	}
+
+	return p.pipelines, nil
+}
+
+// Commands flattens pipelines into their commands
+func Commands(pipelines []Pipeline) []Command {
+	var cmds []Command
+	for _, p := range pipelines {
+		cmds = append(cmds, p.Commands...)
+	}
+	return cmds
+}
+
+type parser struct {
+	src []rune
+	pos int
+
+	words     []string
+	redirects []string
+	pipeline  Pipeline
+	pipelines []Pipeline
+	nested    []string
+	heredocs  []heredoc
+
+	redirectNext bool
+}
+
+type heredoc struct {
+	delimiter string
+	stripTabs bool
+}
+
+//nolint:gocyclo
+func (p *parser) parse() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+
+		switch {
+		case c == '\n':
+			p.pos++
+			p.endPipeline()
+			p.skipHeredocs()
+
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+
+		case c == '|':
+			p.pos++
+			if p.peek('|') {
+				p.pos++
+				p.endPipeline()
+			} else {
+				if p.peek('&') {
+					p.pos++
+				}
+				p.endCommand()
+			}
+
+		case c == '&':
+			p.pos++
+			if p.peek('>') {
+				p.pos++
+				if p.peek('>') {
+					p.pos++
+				}
+				p.redirectNext = true
+				continue
+			}
+			if p.peek('&') {
+				p.pos++
+			}
+			p.endPipeline()
+
+		case c == ';' || c == '(' || c == ')':
+			p.pos++
+			p.endPipeline()
+
+		case c == '<' || c == '>':
+			p.readRedirect()
+
+		default:
+			word, err := p.readWord()
+			if err != nil {
+				return err
+			}
+			p.addWord(word)
+		}
+	}
+
+	if p.redirectNext {
+		return fmt.Errorf("missing redirection target")
+	}
+
+	p.endPipeline()
+	return nil
+}
+
+func (p *parser) peek(c rune) bool {
+	return p.pos < len(p.src) && p.src[p.pos] == c
+}
+
+// readRedirect consumes a redirection operator; a leading file descriptor was read as a word
+func (p *parser) readRedirect() {
+	c := p.src[p.pos]
+	p.pos++
+
+	if n := len(p.words); n > 0 && isDigits(p.words[n-1]) && p.pos >= 2 && p.src[p.pos-2] != ' ' {
+		p.words = p.words[:n-1]
+	}
+
+	if c == '<' && p.peek('<') {
+		p.pos++
+		if p.peek('<') {
+			// here-string: the next word is data, not a file
+			p.pos++
+			p.skipSpaces()
+			_, _ = p.readWord()
+			return
+		}
+
+		stripTabs := false
+		if p.peek('-') {
+			p.pos++
+			stripTabs = true
+		}
+		p.skipSpaces()
+		delimiter, _ := p.readWord()
+		p.heredocs = append(p.heredocs, heredoc{delimiter: delimiter, stripTabs: stripTabs})
+		return
+	}
+
+	if p.peek('>') || p.peek('|') {
+		p.pos++
+	}
+
+	// duplication like 2>&1 has no file target
+	if p.peek('&') {
+		p.pos++
+		p.skipSpaces()
+		_, _ = p.readWord()
+		return
+	}
+
+	p.redirectNext = true
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipHeredocs consumes the bodies of heredocs started on the previous line
+func (p *parser) skipHeredocs() {
+	for _, h := range p.heredocs {
+		for p.pos < len(p.src) {
+			end := p.pos
+			for end < len(p.src) && p.src[end] != '\n' {
+				end++
+			}
+
+			line := string(p.src[p.pos:end])
+			p.pos = end
+			if p.pos < len(p.src) {
+				p.pos++
+			}
+
+			if h.stripTabs {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == h.delimiter {
+				break
+			}
+		}
+	}
+	p.heredocs = nil
+}
+
+//nolint:gocyclo
+func (p *parser) readWord() (string, error) {
+	var b strings.Builder
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+
+		switch c {
+		case ' ', '\t', '\r', '\n', ';', '&', '|', '<', '>', '(', ')':
+			return b.String(), nil
+
+		case '\\':
+			p.pos++
+			if p.pos < len(p.src) {
+				if p.src[p.pos] != '\n' {
+					b.WriteRune(p.src[p.pos])
+				}
+				p.pos++
+			}
+
+		case '\'':
+			end := p.pos + 1
+			for end < len(p.src) && p.src[end] != '\'' {
+				end++
+			}
+			if end >= len(p.src) {
+				return "", fmt.Errorf("unterminated single quote")
+			}
+			b.WriteString(string(p.src[p.pos+1 : end]))
+			p.pos = end + 1
+
+		case '"':
+			if err := p.readDoubleQuoted(&b); err != nil {
+				return "", err
+			}
+
+		case '`':
+			inner, err := p.readBackticks()
+			if err != nil {
+				return "", err
+			}
+			p.nested = append(p.nested, inner)
+			b.WriteString("`" + inner + "`")
+
+		case '$':
+			if p.pos+1 < len(p.src) && p.src[p.pos+1] == '(' {
+				inner, err := p.readSubstitution()
+				if err != nil {
+					return "", err
+				}
+				p.nested = append(p.nested, inner)
+				b.WriteString("$(" + inner + ")")
+				continue
+			}
+			b.WriteRune(c)
+			p.pos++
+
+		default:
+			b.WriteRune(c)
+			p.pos++
+		}
+	}
+
+	return b.String(), nil
+}
+
+func (p *parser) readDoubleQuoted(b *strings.Builder) error {
+	p.pos++ // opening quote
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+
+		switch {
+		case c == '"':
+			p.pos++
+			return nil
+
+		case c == '\\' && p.pos+1 < len(p.src) && strings.ContainsRune("\"\\$`\n", p.src[p.pos+1]):
+			if p.src[p.pos+1] != '\n' {
+				b.WriteRune(p.src[p.pos+1])
+			}
+			p.pos += 2
+
+		case c == '$' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '(':
+			inner, err := p.readSubstitution()
+			if err != nil {
+				return err
+			}
+			p.nested = append(p.nested, inner)
+			b.WriteString("$(" + inner + ")")
+
+		case c == '`':
+			inner, err := p.readBackticks()
+			if err != nil {
+				return err
+			}
+			p.nested = append(p.nested, inner)
+			b.WriteString("`" + inner + "`")
+
+		default:
+			b.WriteRune(c)
+			p.pos++
+		}
+	}
+
+	return fmt.Errorf("unterminated double quote")
+}
+
+// readSubstitution reads $(...) with nested parentheses and returns the inner script
+func (p *parser) readSubstitution() (string, error) {
+	start := p.pos + 2
+	depth := 0
+	quote := rune(0)
+
+	for i := start; i < len(p.src); i++ {
+		c := p.src[i]
+
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '\\':
+			i++
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				p.pos = i + 1
+				return string(p.src[start:i]), nil
+			}
+			depth--
+		}
+	}
+
+	return "", fmt.Errorf("unterminated command substitution")
+}
+
+func (p *parser) readBackticks() (string, error) {
+	var b strings.Builder
+
+	for i := p.pos + 1; i < len(p.src); i++ {
+		c := p.src[i]
+		if c == '\\' && i+1 < len(p.src) {
+			i++
+			b.WriteRune(p.src[i])
+			continue
+		}
+		if c == '`' {
+			p.pos = i + 1
+			return b.String(), nil
+		}
+		b.WriteRune(c)
+	}
+
+	return "", fmt.Errorf("unterminated backtick")
+}
+
+func (p *parser) addWord(word string) {
+	if p.redirectNext {
+		p.redirects = append(p.redirects, word)
+		p.redirectNext = false
+		return
+	}
+	p.words = append(p.words, word)
+}
+
+// endCommand finishes the current simple command and adds it to the pipeline
+func (p *parser) endCommand() {
+	words, redirects := p.words, p.redirects
+	p.words, p.redirects = nil, nil
+
+	cmd, ok := buildCommand(words)
+	if !ok {
+		if len(redirects) > 0 {
+			// a bare redirection like "> file" still writes the file
+			p.pipeline.Commands = append(p.pipeline.Commands, Command{Redirects: redirects})
+		}
+		return
+	}
+
+	cmd.Redirects = redirects
+	p.pipeline.Commands = append(p.pipeline.Commands, cmd)
+
+	// scripts passed to a shell are commands too
+	if script, ok := inlineScript(cmd); ok {
+		p.nested = append(p.nested, script)
+	}
+}
+
+func (p *parser) endPipeline() {
+	p.endCommand()
+	if len(p.pipeline.Commands) > 0 {
+		p.pipelines = append(p.pipelines, p.pipeline)
+	}
+	p.pipeline = Pipeline{}
+}
+
+// keywords that precede a command, and ones whose words are not commands at all
+var (
+	leadingKeywords = map[string]bool{
+		"if": true, "then": true, "else": true, "elif": true, "do": true,
+		"while": true, "until": true, "!": true, "{": true, "time": true,
+	}
+	skippedKeywords = map[string]bool{
+		"fi": true, "done": true, "esac": true, "}": true, "for": true, "case": true,
+		"select": true, "function": true, "in": true,
+	}
+	wrappers = map[string]bool{
+		"sudo": true, "doas": true, "env": true, "nohup": true, "nice": true, "ionice": true,
+		"timeout": true, "command": true, "exec": true, "xargs": true, "builtin": true,
+		"stdbuf": true, "time": true,
+	}
+	shells = map[string]bool{"bash": true, "sh": true, "zsh": true, "dash": true}
+)
+
+// buildCommand turns words into a command, skipping assignments, keywords and wrappers
+func buildCommand(words []string) (Command, bool) {
+	var cmd Command
+
+	i := 0
+prefix:
+	for ; i < len(words); i++ {
+		switch w := words[i]; {
+		case skippedKeywords[w]:
+			return cmd, false
+		case leadingKeywords[w], isAssignment(w):
+		default:
+			break prefix
+		}
+	}
+
+	for i < len(words) {
+		name := filepath.Base(words[i])
+		if !wrappers[name] {
+			cmd.Program = name
+			cmd.Args = words[i+1:]
+			return cmd, true
+		}
+
+		cmd.Wrappers = append(cmd.Wrappers, name)
+		i++
+
+		// skip wrapper options, variable assignments and values like "timeout 10s" or "nice -n 5"
+		for i < len(words) && (strings.HasPrefix(words[i], "-") || isAssignment(words[i]) || isWrapperValue(words[i])) {
+			i++
+		}
+	}
+
+	// a bare wrapper like "env" runs itself
+	if len(cmd.Wrappers) > 0 {
+		cmd.Program = cmd.Wrappers[len(cmd.Wrappers)-1]
+		cmd.Wrappers = cmd.Wrappers[:len(cmd.Wrappers)-1]
+		return cmd, true
+	}
+
+	return cmd, false
+}
+
+// inlineScript returns the script of bash -c "..." and eval "..."
+func inlineScript(cmd Command) (string, bool) {
+	if cmd.Program == "eval" && len(cmd.Args) > 0 {
+		return strings.Join(cmd.Args, " "), true
+	}
+
+	if shells[cmd.Program] {
+		for i, arg := range cmd.Args {
+			if arg == "-c" && i+1 < len(cmd.Args) {
+				return cmd.Args[i+1], true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func isAssignment(word string) bool {
+	eq := strings.IndexByte(word, '=')
+	if eq <= 0 {
+		return false
+	}
+	for i, r := range word[:eq] {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isWrapperValue matches numeric values and durations such as 10, 1.5 or 30s
+func isWrapperValue(s string) bool {
+	trimmed := strings.TrimRight(s, "smhd")
+	return trimmed != "" && strings.Trim(trimmed, "0123456789.") == ""
+}
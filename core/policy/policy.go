@@ -0,0 +1,416 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vadiminshakov/autonomy/core/config"
+)
+
+// Action is what a policy decides about a command
+type Action string
+
+const (
+	Allow Action = "allow"
+	Ask   Action = "ask"
+	Deny  Action = "deny"
+)
+
+const (
+	configDirName  = ".autonomy"
+	configFileName = "policy.json"
+	logFileName    = "policy.log"
+)
+
+// severity orders actions, so the most restrictive decision wins
+func (a Action) severity() int {
+	switch a {
+	case Deny:
+		return 2
+	case Ask:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Rule matches commands by program, subcommand, arguments and paths.
+// Empty fields match anything; all set fields must match.
+type Rule struct {
+	Action Action `json:"action"`
+	// Program is a glob on the executable name; wrappers like sudo match too
+	Program string `json:"program,omitempty"`
+	// Subcommand is the first argument that is not a flag, e.g. "push"
+	Subcommand string `json:"subcommand,omitempty"`
+	// Args are globs that must each match some argument, e.g. "--force*"
+	Args []string `json:"args,omitempty"`
+	// Paths are globs matched against path arguments and redirection targets;
+	// "~" is the home directory and a trailing "/**" matches everything below
+	Paths  []string `json:"paths,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+
+	source string
+	// project rules come with the repository, so they may only tighten the user's decision
+	project bool
+}
+
+// Config is the content of policy.json
+type Config struct {
+	// Default applies to commands no rule matches; allow if empty
+	Default Action `json:"default,omitempty"`
+	Rules   []Rule `json:"rules"`
+}
+
+// Decision is the verdict on a command line
+type Decision struct {
+	Action Action
+	// Command is the simple command that decided the verdict
+	Command string
+	Rule    *Rule
+	Reason  string
+}
+
+// builtinRules guard against commands that destroy the system; configured rules cannot relax them
+var builtinRules = []Rule{
+	{Action: Deny, Program: "rm", Paths: []string{"/", "/[*]", "~", "~/[*]", "$HOME", "$HOME/[*]"},
+		Reason: "removing the root or home directory"},
+	{Action: Deny, Program: "mkfs*", Reason: "formatting a filesystem"},
+	{Action: Deny, Program: "format", Reason: "formatting a disk"},
+	{Action: Deny, Program: "dd", Args: []string{"of=/dev/*"}, Reason: "writing to a raw device"},
+	{Action: Deny, Program: "shutdown", Reason: "shutting down the machine"},
+	{Action: Deny, Program: "reboot", Reason: "rebooting the machine"},
+	{Action: Deny, Program: "halt", Reason: "halting the machine"},
+	{Action: Deny, Program: "poweroff", Reason: "powering off the machine"},
+}
+
+// Policy decides which shell commands may run
+type Policy struct {
+	rules []Rule
+	def   Action
+	// projectDef is the default of the project policy, it only applies to commands no user rule matches
+	projectDef Action
+	dir        string
+	logPath    string
+}
+
+// Default returns the policy with the built-in rules only
+func Default() *Policy {
+	p := &Policy{def: Allow}
+	for _, r := range builtinRules {
+		r.source = "builtin"
+		p.rules = append(p.rules, r)
+	}
+	return p
+}
+
+// AskAll returns a policy that asks before every command; it stands in for a policy that failed to load
+func AskAll() *Policy {
+	p := Default()
+	p.def = Ask
+	return p
+}
+
+// ErrUntrusted is returned with the user's policy when the policy of an untrusted project was skipped
+var ErrUntrusted = errors.New("project is not trusted")
+
+// Load reads ~/.autonomy/policy.json and <dir>/.autonomy/policy.json on top of the built-in rules.
+// The policy of a project is only read once the user trusted it, and it can make the user's
+// decisions stricter but never looser. Decisions are logged to ~/.autonomy/policy.log.
+func Load(dir string) (*Policy, error) {
+	p := Default()
+	p.dir = dir
+
+	user := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		user = filepath.Join(home, configDirName, configFileName)
+		p.logPath = filepath.Join(home, configDirName, logFileName)
+		if err := p.loadFile(user, false); err != nil {
+			return p, err
+		}
+	}
+
+	project := filepath.Join(dir, configDirName, configFileName)
+	if project == user {
+		return p, nil
+	}
+	if _, err := os.Stat(project); err == nil && !config.IsTrusted(dir) {
+		return p, fmt.Errorf("%w, %s ignored", ErrUntrusted, project)
+	}
+
+	return p, p.loadFile(project, true)
+}
+
+func (p *Policy) loadFile(path string, project bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read policy: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+
+	if cfg.Default != "" {
+		if !validAction(cfg.Default) {
+			return fmt.Errorf("invalid policy file %s: unknown default action %q", path, cfg.Default)
+		}
+		if project {
+			p.projectDef = cfg.Default
+		} else {
+			p.def = cfg.Default
+		}
+	}
+
+	for i, r := range cfg.Rules {
+		if !validAction(r.Action) {
+			return fmt.Errorf("invalid policy file %s: rule %d has unknown action %q", path, i+1, r.Action)
+		}
+		r.source = path
+		r.project = project
+		p.rules = append(p.rules, r)
+	}
+
+	return nil
+}
+
+func validAction(a Action) bool {
+	return a == Allow || a == Ask || a == Deny
+}
+
+// Evaluate decides about a command line. Every command of it is matched against the rules,
+// and the most restrictive verdict wins, so an allow rule never hides a denied command.
+func (p *Policy) Evaluate(script string) Decision {
+	pipelines, err := Parse(script)
+	if err != nil {
+		return Decision{Action: Deny, Command: script, Reason: fmt.Sprintf("syntax error: %v", err)}
+	}
+
+	decision := Decision{Action: Allow}
+	first := true
+
+	for _, cmd := range Commands(pipelines) {
+		d := p.evaluateCommand(cmd)
+		if first || d.Action.severity() > decision.Action.severity() {
+			decision = d
+			first = false
+		}
+	}
+
+	if first {
+		decision.Command = script
+	}
+
+	return decision
+}
+
+func (p *Policy) evaluateCommand(cmd Command) Decision {
+	d := Decision{Action: p.def, Command: cmd.String(), Reason: "default policy"}
+
+	var matched bool
+	for i := range p.rules {
+		r := &p.rules[i]
+		if r.project || !r.matches(cmd, p.dir) {
+			continue
+		}
+		if !matched || r.Action.severity() > d.Action.severity() {
+			d.Action = r.Action
+			d.Rule = r
+			d.Reason = r.Reason
+			matched = true
+		}
+	}
+
+	// the project policy is applied the same way, but only counts where it is stricter than the user's
+	project := Decision{Action: Allow, Command: d.Command}
+	if !matched && p.projectDef != "" {
+		project.Action = p.projectDef
+		project.Reason = "default project policy"
+	}
+	matched = false
+	for i := range p.rules {
+		r := &p.rules[i]
+		if !r.project || !r.matches(cmd, p.dir) {
+			continue
+		}
+		if !matched || r.Action.severity() > project.Action.severity() {
+			project.Action = r.Action
+			project.Rule = r
+			project.Reason = r.Reason
+			matched = true
+		}
+	}
+
+	if project.Action.severity() > d.Action.severity() {
+		return project
+	}
+	return d
+}
+
+func (r *Rule) matches(cmd Command, dir string) bool {
+	if r.Program != "" && !r.matchesProgram(cmd) {
+		return false
+	}
+
+	if r.Subcommand != "" && !globMatch(r.Subcommand, cmd.Subcommand()) {
+		return false
+	}
+
+	for _, pattern := range r.Args {
+		if !anyMatch(cmd.Args, func(arg string) bool { return globMatch(pattern, arg) }) {
+			return false
+		}
+	}
+
+	if len(r.Paths) > 0 {
+		candidates := append(append([]string(nil), cmd.Args...), cmd.Redirects...)
+		found := false
+		for _, pattern := range r.Paths {
+			if anyMatch(candidates, func(arg string) bool { return pathMatch(pattern, arg, dir) }) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *Rule) matchesProgram(cmd Command) bool {
+	if cmd.Program != "" && globMatch(r.Program, cmd.Program) {
+		return true
+	}
+	for _, w := range cmd.Wrappers {
+		if globMatch(r.Program, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyMatch(values []string, match func(string) bool) bool {
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func globMatch(pattern, value string) bool {
+	if pattern == "*" || pattern == value {
+		return true
+	}
+	matched, _ := filepath.Match(pattern, value)
+	return matched
+}
+
+// pathMatch compares a path pattern with an argument as written and as an absolute path
+func pathMatch(pattern, arg, dir string) bool {
+	if strings.HasPrefix(arg, "-") {
+		return false
+	}
+
+	if globMatch(pattern, arg) {
+		return true
+	}
+
+	pattern = expandHome(pattern)
+	// like .gitignore, a relative pattern with a slash is anchored at the project directory
+	if !filepath.IsAbs(pattern) && strings.Contains(pattern, "/") && dir != "" {
+		pattern = filepath.Join(dir, pattern)
+	}
+	path := expandHome(arg)
+	if !filepath.IsAbs(path) && dir != "" {
+		path = filepath.Join(dir, path)
+	}
+	path = filepath.Clean(path)
+
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		prefix = filepath.Clean(prefix)
+		return path == prefix || strings.HasPrefix(path, prefix+string(filepath.Separator))
+	}
+
+	return globMatch(pattern, path)
+}
+
+func expandHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+
+	for _, prefix := range []string{"~", "$HOME", "${HOME}"} {
+		if path == prefix {
+			return home
+		}
+		if rest, ok := strings.CutPrefix(path, prefix+"/"); ok {
+			return filepath.Join(home, rest)
+		}
+	}
+
+	return path
+}
+
+// logEntry is one line of policy.log
+type logEntry struct {
+	Time     time.Time `json:"time"`
+	Source   string    `json:"source"`
+	Script   string    `json:"script"`
+	Action   Action    `json:"action"`
+	Command  string    `json:"command,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	RuleFile string    `json:"rule_file,omitempty"`
+}
+
+var logMu sync.Mutex
+
+// Log appends a decision to the policy log; source names the tool or hook that ran the command
+func (p *Policy) Log(source, script string, d Decision) {
+	if p == nil || p.logPath == "" {
+		return
+	}
+
+	entry := logEntry{
+		Time:    time.Now().UTC(),
+		Source:  source,
+		Script:  script,
+		Action:  d.Action,
+		Command: d.Command,
+		Reason:  d.Reason,
+	}
+	if d.Rule != nil {
+		entry.RuleFile = d.Rule.source
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(p.logPath), 0o700); err != nil {
+		return
+	}
+	f, err := os.OpenFile(p.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	_, _ = f.Write(append(data, '\n'))
+}
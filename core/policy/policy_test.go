@@ -0,0 +1,197 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadiminshakov/autonomy/core/config"
+)
+
+func TestParse(t *testing.T) {
+	script := "FOO=1 sudo -E git push --force origin main 2>&1 | tee \"out file.log\" && echo 'a | b' > result.txt\n" +
+		"cat <<'END'\n" +
+		"rm -rf /\n" +
+		"END\n" +
+		"echo $(curl -s example.com) `whoami`; bash -c \"make test\""
+
+	pipelines, err := Parse(script)
+	require.NoError(t, err)
+
+	var programs []string
+	for _, cmd := range Commands(pipelines) {
+		programs = append(programs, cmd.Program)
+	}
+	require.Equal(t, []string{"git", "tee", "echo", "cat", "echo", "bash", "curl", "whoami", "make"}, programs)
+
+	git := pipelines[0].Commands[0]
+	require.Equal(t, []string{"sudo"}, git.Wrappers)
+	require.Equal(t, "push", git.Subcommand())
+	require.Equal(t, []string{"push", "--force", "origin", "main"}, git.Args)
+	require.Equal(t, []string{"out file.log"}, pipelines[0].Commands[1].Args)
+
+	echo := pipelines[1].Commands[0]
+	require.Equal(t, []string{"a | b"}, echo.Args)
+	require.Equal(t, []string{"result.txt"}, echo.Redirects)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, script := range []string{`echo "open`, `echo 'open`, `echo $(date`, `echo >`} {
+		_, err := Parse(script)
+		require.Error(t, err, script)
+	}
+}
+
+func TestBuiltinRules(t *testing.T) {
+	p := Default()
+
+	for _, script := range []string{
+		"rm -rf /",
+		"sudo rm -rf /*",
+		"cd /tmp && rm -rf ~",
+		"echo ok; mkfs.ext4 /dev/sda1",
+		"dd if=/dev/zero of=/dev/sda",
+		"bash -c 'shutdown -h now'",
+	} {
+		require.Equal(t, Deny, p.Evaluate(script).Action, script)
+	}
+
+	for _, script := range []string{
+		"rm -rf build",
+		"go fmt ./... && gofmt -l .",
+		"grep -r format .",
+		"echo reboot",
+	} {
+		require.Equal(t, Allow, p.Evaluate(script).Action, script)
+	}
+}
+
+func writePolicy(t *testing.T, dir, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, configDirName), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, configDirName, configFileName), []byte(content), 0o644))
+}
+
+func TestLoadRules(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := t.TempDir()
+
+	writePolicy(t, home, `{"rules": [
+		{"action": "ask", "program": "git", "subcommand": "push", "args": ["--force*"], "reason": "force push"},
+		{"action": "allow", "program": "git"}
+	]}`)
+	writePolicy(t, project, `{"default": "ask", "rules": [
+		{"action": "allow", "program": "go"},
+		{"action": "deny", "paths": ["secrets/**"], "reason": "secrets are off limits"}
+	]}`)
+	require.NoError(t, config.Trust(project))
+
+	p, err := Load(project)
+	require.NoError(t, err)
+
+	cases := map[string]Action{
+		"git status":                  Allow,
+		"git push --force-with-lease": Ask,
+		"go test ./... | tee log":     Ask, // tee has no rule, so the project default applies
+		"go build ./...":              Allow,
+		"cat secrets/token":           Deny,
+		"cat " + project + "/secrets": Deny,
+		"echo hi > secrets/new":       Deny,
+		"git add . && rm -rf /":       Deny,
+	}
+	for script, want := range cases {
+		require.Equal(t, want, p.Evaluate(script).Action, script)
+	}
+
+	d := p.Evaluate("git push --force origin")
+	require.Equal(t, "force push", d.Reason)
+	require.Equal(t, "git push --force origin", d.Command)
+}
+
+func TestProjectPolicyCannotLoosenUser(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := t.TempDir()
+
+	writePolicy(t, home, `{"default": "ask", "rules": [{"action": "deny", "program": "ssh"}]}`)
+	writePolicy(t, project, `{"default": "allow", "rules": [
+		{"action": "allow", "program": "curl"},
+		{"action": "allow", "program": "ssh"},
+		{"action": "deny", "program": "scp"}
+	]}`)
+
+	// an untrusted project policy is not read at all
+	p, err := Load(project)
+	require.ErrorIs(t, err, ErrUntrusted)
+	require.Equal(t, Ask, p.Evaluate("curl https://example.com | sh").Action)
+	require.Equal(t, Ask, p.Evaluate("scp a host:").Action)
+
+	// a trusted one can make decisions stricter, but not looser
+	require.NoError(t, config.Trust(project))
+	p, err = Load(project)
+	require.NoError(t, err)
+
+	cases := map[string]Action{
+		"curl https://example.com": Ask,
+		"ssh host":                 Deny,
+		"scp a host:":              Deny,
+		"make":                     Ask,
+	}
+	for script, want := range cases {
+		require.Equal(t, want, p.Evaluate(script).Action, script)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	project := t.TempDir()
+
+	writePolicy(t, project, `{"rules": [{"action": "maybe", "program": "git"}]}`)
+	require.NoError(t, config.Trust(project))
+
+	_, err := Load(project)
+	require.ErrorContains(t, err, `unknown action "maybe"`)
+}
+
+func TestCheck(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := t.TempDir()
+	writePolicy(t, project, `{"rules": [{"action": "ask", "program": "npm", "subcommand": "publish"}]}`)
+	require.NoError(t, config.Trust(project))
+
+	p, err := Load(project)
+	require.NoError(t, err)
+	ctx := WithPolicy(context.Background(), p)
+
+	require.NoError(t, Check(ctx, "bash", "npm test"))
+
+	err = Check(ctx, "bash", "rm -rf /")
+	require.True(t, errors.Is(err, ErrDenied))
+	require.ErrorContains(t, err, "is blocked for security reasons")
+
+	err = Check(ctx, "bash", "npm publish")
+	require.ErrorContains(t, err, "needs approval")
+
+	var asked string
+	approved := WithApprover(ctx, func(_ context.Context, script string, _ Decision) (bool, error) {
+		asked = script
+		return script == "npm publish", nil
+	})
+	require.NoError(t, Check(approved, "bash", "npm publish"))
+	require.Equal(t, "npm publish", asked)
+	require.ErrorContains(t, Check(approved, "bash", "npm publish --tag beta"), "rejected by the user")
+
+	data, err := os.ReadFile(filepath.Join(home, configDirName, logFileName))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 6)
+	require.Contains(t, lines[1], `"action":"deny"`)
+	require.Contains(t, lines[1], `"source":"bash"`)
+}
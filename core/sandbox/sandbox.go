@@ -0,0 +1,188 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrUnavailable is returned when commands cannot be sandboxed on this system
+var ErrUnavailable = errors.New("sandbox is not available")
+
+// Backends of the sandbox
+const (
+	BackendBwrap  = "bwrap"
+	BackendNative = "native"
+)
+
+// Config is the "sandbox" section of config.json
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// Network keeps network access; by default commands only get a loopback interface
+	Network bool `json:"network,omitempty"`
+	// Writable lists paths besides the project directory and the temp directory that commands may change,
+	// e.g. "~/.cache/go-build"; "~" is the home directory
+	Writable []string `json:"writable,omitempty"`
+	// Backend forces "bwrap" or "native"; by default bubblewrap is used when it is installed
+	Backend string `json:"backend,omitempty"`
+	Limits  Limits `json:"limits"`
+}
+
+// Limits are resource limits of sandboxed commands, applied as rlimits to every process; zero means unlimited
+type Limits struct {
+	// MemoryMB limits the virtual memory of a process
+	MemoryMB   int `json:"memory_mb,omitempty"`
+	CPUSeconds int `json:"cpu_seconds,omitempty"`
+	FileSizeMB int `json:"file_size_mb,omitempty"`
+	OpenFiles  int `json:"open_files,omitempty"`
+	// Processes limits the processes of the user, including the ones outside the sandbox
+	Processes int `json:"processes,omitempty"`
+}
+
+// Sandbox runs commands with the project directory writable, the rest of the filesystem
+// read-only and, unless configured otherwise, without network access
+type Sandbox struct {
+	cfg      Config
+	writable []string
+	readOnly []string
+
+	once    sync.Once
+	backend string
+	bwrap   string
+	err     error
+}
+
+// New returns a sandbox for commands working on the project in dir
+func New(cfg Config, dir string) *Sandbox {
+	paths := append([]string{dir, os.TempDir(), "/tmp"}, cfg.Writable...)
+	s := &Sandbox{cfg: cfg, writable: resolvePaths(paths)}
+
+	protected, git := protectedPaths(dir)
+	s.readOnly = resolvePaths(protected)
+
+	// a bind mount cannot be renamed, so a command cannot put another .git with its own hooks in place
+	if git != "" {
+		for _, path := range resolvePaths([]string{git}) {
+			if withinAny(path, s.writable) {
+				s.writable = append(s.writable, path)
+			}
+		}
+	}
+
+	return s
+}
+
+// Writable returns the paths sandboxed commands may change
+func (s *Sandbox) Writable() []string {
+	return s.writable
+}
+
+// ReadOnly returns the paths inside the writable ones that sandboxed commands may not change
+func (s *Sandbox) ReadOnly() []string {
+	return s.readOnly
+}
+
+// protectedPaths lists the configuration of the agent and of git for the project in dir, and returns
+// the git directory of the project. Hooks, approvals and git hooks run outside the sandbox, so a command
+// that could change them would escape it on the next run. Only the paths that exist are protected:
+// the sandbox does not create directories in the project.
+func protectedPaths(dir string) (paths []string, gitDir string) {
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".autonomy"))
+	}
+
+	// instructions are read from every directory up to the repository root
+	for d := dir; ; d = filepath.Dir(d) {
+		paths = append(paths, filepath.Join(d, ".autonomy"))
+
+		git := filepath.Join(d, ".git")
+		if info, err := os.Stat(git); err == nil {
+			if info.IsDir() {
+				paths = append(paths, filepath.Join(git, "hooks"), filepath.Join(git, "config"))
+				gitDir = git
+			}
+			break
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	return paths, gitDir
+}
+
+func within(path, root string) bool {
+	return path == root || strings.HasPrefix(path, strings.TrimSuffix(root, "/")+"/")
+}
+
+func withinAny(path string, roots []string) bool {
+	for _, root := range roots {
+		if within(path, root) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePaths expands "~", resolves symlinks and drops duplicates and paths that do not exist
+func resolvePaths(paths []string) []string {
+	home, _ := os.UserHomeDir()
+
+	seen := make(map[string]bool)
+	var resolved []string
+
+	for _, path := range paths {
+		if home != "" && (path == "~" || strings.HasPrefix(path, "~/")) {
+			path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+		real, err := filepath.EvalSymlinks(abs)
+		if err != nil || seen[real] {
+			continue
+		}
+
+		seen[real] = true
+		resolved = append(resolved, real)
+	}
+
+	return resolved
+}
+
+// bwrapArgs are the bubblewrap options that build the sandbox; the command follows them
+func (s *Sandbox) bwrapArgs() []string {
+	args := []string{"--ro-bind", "/", "/", "--dev", "/dev"}
+	for _, path := range s.writable {
+		args = append(args, "--bind", path, path)
+	}
+	for _, path := range s.readOnly {
+		args = append(args, "--ro-bind", path, path)
+	}
+
+	args = append(args, "--unshare-user", "--unshare-ipc")
+	if !s.cfg.Network {
+		args = append(args, "--unshare-net")
+	}
+
+	// no new session: the commands stay in the process group that is killed on cancel
+	return append(args, "--die-with-parent", "--")
+}
+
+type sandboxKey struct{}
+
+// WithSandbox returns a context whose commands run in s
+func WithSandbox(ctx context.Context, s *Sandbox) context.Context {
+	return context.WithValue(ctx, sandboxKey{}, s)
+}
+
+// FromContext returns the sandbox of ctx, or nil when commands run unconfined
+func FromContext(ctx context.Context) *Sandbox {
+	s, _ := ctx.Value(sandboxKey{}).(*Sandbox)
+	return s
+}
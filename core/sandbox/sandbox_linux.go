@@ -0,0 +1,390 @@
+package sandbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// initEnv carries the spec of the sandbox to the init process
+const initEnv = "AUTONOMY_SANDBOX_INIT"
+
+// initFailed is the exit status of an init process that could not set up the sandbox
+const initFailed = 125
+
+// constants the syscall package does not define
+const (
+	rlimitNproc       = 6  // RLIMIT_NPROC
+	prSetNoNewPrivs   = 38 // PR_SET_NO_NEW_PRIVS
+	capabilityVersion = 0x20080522
+)
+
+// spec is what the init process sets up before it runs the command
+type spec struct {
+	// Isolate builds the filesystem and network of the sandbox in the namespaces the init process
+	// was started in; bubblewrap does that itself, so the init process only applies the limits
+	Isolate  bool     `json:"isolate,omitempty"`
+	Writable []string `json:"writable,omitempty"`
+	ReadOnly []string `json:"read_only,omitempty"`
+	Network  bool     `json:"network,omitempty"`
+	Limits   Limits   `json:"limits"`
+}
+
+// Check reports whether the sandbox works on this system, trying it out the first time
+func (s *Sandbox) Check() error {
+	s.once.Do(func() {
+		s.err = s.probe()
+	})
+	return s.err
+}
+
+func (s *Sandbox) probe() error {
+	switch s.cfg.Backend {
+	case "", BackendBwrap:
+		path, err := exec.LookPath("bwrap")
+		if err == nil {
+			s.backend, s.bwrap = BackendBwrap, path
+			break
+		}
+		if s.cfg.Backend == BackendBwrap {
+			return fmt.Errorf("%w: bubblewrap (bwrap) is not installed", ErrUnavailable)
+		}
+		s.backend = BackendNative
+	case BackendNative:
+		s.backend = BackendNative
+	default:
+		return fmt.Errorf("unknown sandbox backend %q, use %q or %q", s.cfg.Backend, BackendBwrap, BackendNative)
+	}
+
+	cmd := exec.Command("/bin/sh", "-c", "true")
+	if err := s.wrap(cmd); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+
+	reason := strings.TrimSpace(string(output))
+	if reason == "" {
+		reason = err.Error()
+	}
+	if s.backend == BackendBwrap {
+		return fmt.Errorf("%w: bubblewrap failed: %s", ErrUnavailable, reason)
+	}
+
+	return fmt.Errorf("%w: the kernel does not allow unprivileged user namespaces (%s); check the sysctls "+
+		"user.max_user_namespaces, kernel.unprivileged_userns_clone and kernel.apparmor_restrict_unprivileged_userns",
+		ErrUnavailable, reason)
+}
+
+// Wrap changes cmd to run in the sandbox. It must be called after the rest of cmd is set up;
+// a nil sandbox leaves cmd as it is.
+func (s *Sandbox) Wrap(cmd *exec.Cmd) error {
+	if s == nil {
+		return nil
+	}
+	if err := s.Check(); err != nil {
+		return err
+	}
+	return s.wrap(cmd)
+}
+
+func (s *Sandbox) wrap(cmd *exec.Cmd) error {
+	if cmd.Err != nil {
+		return cmd.Err
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the executable to start the sandbox: %w", err)
+	}
+
+	command := append([]string{cmd.Path}, cmd.Args[1:]...)
+	sp := spec{Limits: s.cfg.Limits}
+
+	if s.backend == BackendBwrap {
+		command = append(append([]string{s.bwrap}, s.bwrapArgs()...), command...)
+	} else {
+		sp.Isolate, sp.Writable, sp.ReadOnly, sp.Network = true, s.writable, s.readOnly, s.cfg.Network
+
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS
+		if !s.cfg.Network {
+			cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNET
+		}
+		// the init process needs to be root in the namespace to mount; it drops the capabilities before the command runs
+		cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}}
+		cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}}
+		cmd.SysProcAttr.GidMappingsEnableSetgroups = false
+	}
+
+	data, err := json.Marshal(sp)
+	if err != nil {
+		return err
+	}
+
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(env, initEnv+"="+string(data))
+	cmd.Path = self
+	cmd.Args = command
+
+	return nil
+}
+
+// Main runs the init process of a sandbox when the program was started as one and does nothing
+// otherwise. Programs that sandbox commands call it first thing in main.
+func Main() {
+	data, ok := os.LookupEnv(initEnv)
+	if !ok {
+		return
+	}
+	_ = os.Unsetenv(initEnv)
+
+	if err := runInit(data, os.Args); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		os.Exit(initFailed)
+	}
+}
+
+func runInit(data string, command []string) error {
+	var sp spec
+	if err := json.Unmarshal([]byte(data), &sp); err != nil {
+		return fmt.Errorf("invalid spec: %w", err)
+	}
+	if len(command) == 0 {
+		return errors.New("no command")
+	}
+
+	if sp.Isolate {
+		if err := isolateFilesystem(sp.Writable, sp.ReadOnly); err != nil {
+			return err
+		}
+		if !sp.Network {
+			if err := loopbackUp(); err != nil {
+				return fmt.Errorf("failed to set up the loopback interface: %w", err)
+			}
+		}
+		if err := dropCapabilities(); err != nil {
+			return fmt.Errorf("failed to drop capabilities: %w", err)
+		}
+	}
+
+	if err := setLimits(sp.Limits); err != nil {
+		return err
+	}
+
+	if err := syscall.Exec(command[0], command, os.Environ()); err != nil {
+		return fmt.Errorf("failed to run %s: %w", command[0], err)
+	}
+
+	return nil
+}
+
+// isolateFilesystem makes every mount read-only except the writable paths and the virtual
+// filesystems under /proc, /sys and /dev; the read-only paths stay read-only inside the writable ones
+func isolateFilesystem(writable, readOnly []string) error {
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+
+	// bind mounts of the writable paths onto themselves keep them writable when their parents are remounted
+	for _, path := range writable {
+		if err := syscall.Mount(path, path, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+			return fmt.Errorf("failed to bind %s: %w", path, err)
+		}
+	}
+	for _, path := range readOnly {
+		if err := syscall.Mount(path, path, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+			return fmt.Errorf("failed to bind %s: %w", path, err)
+		}
+	}
+
+	mounts, err := readMounts()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range mounts {
+		if m.readOnly || keepWritable(m.point, writable) && !withinAny(m.point, readOnly) {
+			continue
+		}
+
+		flags := uintptr(syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY | m.flags)
+		if err := syscall.Mount("", m.point, "", flags, ""); err != nil {
+			return fmt.Errorf("failed to make %s read-only: %w", m.point, err)
+		}
+	}
+
+	// the working directory still refers to the directory under the new mounts
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	return os.Chdir(wd)
+}
+
+func keepWritable(point string, writable []string) bool {
+	return withinAny(point, append([]string{"/proc", "/sys", "/dev"}, writable...))
+}
+
+type mount struct {
+	point    string
+	readOnly bool
+	// flags that cannot be changed on a mount inherited from another user namespace
+	flags int
+}
+
+var lockedFlags = map[string]int{
+	"nosuid":      syscall.MS_NOSUID,
+	"nodev":       syscall.MS_NODEV,
+	"noexec":      syscall.MS_NOEXEC,
+	"noatime":     syscall.MS_NOATIME,
+	"nodiratime":  syscall.MS_NODIRATIME,
+	"relatime":    syscall.MS_RELATIME,
+	"strictatime": syscall.MS_STRICTATIME,
+}
+
+// readMounts lists the mounts of the process, parents before children
+func readMounts() ([]mount, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []mount
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+
+		m := mount{point: unescapeMountPoint(fields[4])}
+		for _, opt := range strings.Split(fields[5], ",") {
+			if opt == "ro" {
+				m.readOnly = true
+			}
+			m.flags |= lockedFlags[opt]
+		}
+		mounts = append(mounts, m)
+	}
+
+	return mounts, scanner.Err()
+}
+
+// unescapeMountPoint decodes the octal escapes mountinfo uses for spaces and other special characters
+func unescapeMountPoint(point string) string {
+	var out strings.Builder
+	for i := 0; i < len(point); i++ {
+		if point[i] == '\\' && i+3 < len(point) {
+			if c, err := strconv.ParseUint(point[i+1:i+4], 8, 8); err == nil {
+				out.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		out.WriteByte(point[i])
+	}
+	return filepath.Clean(out.String())
+}
+
+// loopbackUp brings up the loopback interface of a new network namespace, which starts down
+func loopbackUp() error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+
+	// struct ifreq: the interface name followed by the flags
+	var ifr [40]byte
+	copy(ifr[:], "lo")
+	*(*uint16)(unsafe.Pointer(&ifr[syscall.IFNAMSIZ])) = syscall.IFF_UP | syscall.IFF_RUNNING
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCSIFFLAGS, uintptr(unsafe.Pointer(&ifr[0])))
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// dropCapabilities keeps the command from undoing the sandbox, e.g. by remounting a path read-write.
+// Root gets the capabilities of the bounding set on exec, so that set is emptied as well.
+func dropCapabilities() error {
+	for capability := uintptr(0); capability < 64; capability++ {
+		_, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_CAPBSET_DROP, capability, 0)
+		if errno == syscall.EINVAL {
+			break
+		}
+		if errno != 0 {
+			return errno
+		}
+	}
+
+	// struct __user_cap_header_struct and two empty struct __user_cap_data_struct
+	header := [2]uint32{capabilityVersion, 0}
+	var data [6]uint32
+	_, _, errno := syscall.RawSyscall(syscall.SYS_CAPSET, uintptr(unsafe.Pointer(&header)), uintptr(unsafe.Pointer(&data)), 0)
+	if errno != 0 {
+		return errno
+	}
+
+	_, _, errno = syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+func setLimits(limits Limits) error {
+	const mb = 1 << 20
+
+	for _, l := range []struct {
+		name     string
+		resource int
+		value    uint64
+	}{
+		{"memory", syscall.RLIMIT_AS, uint64(limits.MemoryMB) * mb},
+		{"cpu", syscall.RLIMIT_CPU, uint64(limits.CPUSeconds)},
+		{"file size", syscall.RLIMIT_FSIZE, uint64(limits.FileSizeMB) * mb},
+		{"open files", syscall.RLIMIT_NOFILE, uint64(limits.OpenFiles)},
+		{"processes", rlimitNproc, uint64(limits.Processes)},
+	} {
+		if l.value == 0 {
+			continue
+		}
+
+		var current syscall.Rlimit
+		if err := syscall.Getrlimit(l.resource, &current); err != nil {
+			return fmt.Errorf("failed to read the %s limit: %w", l.name, err)
+		}
+
+		// a limit can only be lowered
+		limit := syscall.Rlimit{Cur: min(l.value, current.Max), Max: min(l.value, current.Max)}
+		if err := syscall.Setrlimit(l.resource, &limit); err != nil {
+			return fmt.Errorf("failed to set the %s limit: %w", l.name, err)
+		}
+	}
+
+	return nil
+}
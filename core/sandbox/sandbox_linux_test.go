@@ -0,0 +1,176 @@
+package sandbox
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// the test binary doubles as the init process of the sandboxes it starts
+func TestMain(m *testing.M) {
+	Main()
+	os.Exit(m.Run())
+}
+
+func nativeSandbox(t *testing.T, cfg Config, dir string) *Sandbox {
+	t.Helper()
+
+	cfg.Backend = BackendNative
+	s := New(cfg, dir)
+	if err := s.Check(); err != nil {
+		t.Skipf("user namespaces are not available: %v", err)
+	}
+	return s
+}
+
+func runIn(t *testing.T, s *Sandbox, dir, script string) (string, error) {
+	t.Helper()
+
+	cmd := exec.Command("bash", "-c", script)
+	cmd.Dir = dir
+	require.NoError(t, s.Wrap(cmd))
+
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+func TestSandboxFilesystem(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	if within(wd, os.TempDir()) || within(wd, "/tmp") {
+		t.Skip("the package directory is writable in the sandbox")
+	}
+
+	project, extra := t.TempDir(), t.TempDir()
+	s := nativeSandbox(t, Config{Writable: []string{extra}}, project)
+
+	output, err := runIn(t, s, project, "echo project > a.txt && echo extra > "+filepath.Join(extra, "b.txt")+" && pwd")
+	require.NoError(t, err, output)
+	require.Equal(t, project+"\n", output)
+
+	data, err := os.ReadFile(filepath.Join(project, "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "project\n", string(data))
+
+	outside := filepath.Join(wd, "sandbox-write-test")
+	t.Cleanup(func() { _ = os.Remove(outside) })
+
+	output, err = runIn(t, s, project, "touch "+outside)
+	require.Error(t, err)
+	require.Contains(t, output, "Read-only file system")
+	require.NoFileExists(t, outside)
+
+	// the command cannot lift the restrictions
+	output, err = runIn(t, s, project, "mount -o remount,bind,rw / || exit 1")
+	require.Error(t, err, output)
+}
+
+func TestSandboxProtectsConfig(t *testing.T) {
+	project := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(project, ".autonomy"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(project, ".git", "hooks"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".git", "config"), []byte("[core]\n"), 0o644))
+
+	s := nativeSandbox(t, Config{}, project)
+
+	// hooks, approvals and git hooks run outside the sandbox on the next run
+	for _, script := range []string{
+		"echo '{}' > .autonomy/hooks.json",
+		"echo 'exit 0' > .git/hooks/pre-commit",
+		"echo '[core] hooksPath = /tmp' >> .git/config",
+	} {
+		output, err := runIn(t, s, project, script)
+		require.Error(t, err, script)
+		require.Contains(t, output, "Read-only file system", script)
+	}
+
+	// nor can the directories be replaced
+	output, err := runIn(t, s, project, "rm -r .autonomy || mv .git .git.old")
+	require.Error(t, err, output)
+	require.DirExists(t, filepath.Join(project, ".autonomy"))
+	require.NoFileExists(t, filepath.Join(project, ".autonomy", "hooks.json"))
+	require.NoFileExists(t, filepath.Join(project, ".git", "hooks", "pre-commit"))
+
+	output, err = runIn(t, s, project, "echo ok > .git/HEAD && echo ok > a.txt")
+	require.NoError(t, err, output)
+}
+
+func TestSandboxLeavesProjectAlone(t *testing.T) {
+	project := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(project, ".git"), 0o755))
+
+	s := New(Config{}, project)
+
+	// missing configuration is not created just to be bound read-only
+	require.NoDirExists(t, filepath.Join(project, ".autonomy"))
+	require.NoDirExists(t, filepath.Join(project, ".git", "hooks"))
+	for _, path := range s.ReadOnly() {
+		require.False(t, within(path, project), path)
+	}
+}
+
+func TestSandboxNetwork(t *testing.T) {
+	project := t.TempDir()
+
+	output, err := runIn(t, nativeSandbox(t, Config{}, project), project, "tail -n +3 /proc/net/dev")
+	require.NoError(t, err, output)
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	require.Len(t, lines, 1, output)
+	require.Contains(t, lines[0], "lo:")
+
+	output, err = runIn(t, nativeSandbox(t, Config{Network: true}, project), project, "cat /proc/net/dev")
+	require.NoError(t, err, output)
+
+	host, err := os.ReadFile("/proc/net/dev")
+	require.NoError(t, err)
+	require.Equal(t, strings.Count(string(host), "\n"), strings.Count(output, "\n"))
+}
+
+func TestSandboxLimits(t *testing.T) {
+	project := t.TempDir()
+	s := nativeSandbox(t, Config{Limits: Limits{FileSizeMB: 1, OpenFiles: 64}}, project)
+
+	output, err := runIn(t, s, project, "ulimit -n; head -c 1048576 /dev/zero > ok.bin")
+	require.NoError(t, err, output)
+	require.Equal(t, "64\n", output)
+
+	_, err = runIn(t, s, project, "head -c 2097152 /dev/zero > big.bin")
+	require.Error(t, err)
+
+	info, err := os.Stat(filepath.Join(project, "big.bin"))
+	require.NoError(t, err)
+	require.Equal(t, int64(1<<20), info.Size())
+}
+
+func TestSandboxBackends(t *testing.T) {
+	err := New(Config{Backend: "docker"}, t.TempDir()).Check()
+	require.ErrorContains(t, err, `unknown sandbox backend "docker"`)
+
+	if _, err := exec.LookPath("bwrap"); err != nil {
+		err = New(Config{Backend: BackendBwrap}, t.TempDir()).Check()
+		require.True(t, errors.Is(err, ErrUnavailable))
+		require.ErrorContains(t, err, "bwrap")
+	}
+}
+
+func TestBwrapArgs(t *testing.T) {
+	project, extra := t.TempDir(), t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(project, ".autonomy"), 0o755))
+	s := New(Config{Writable: []string{extra, filepath.Join(extra, "missing")}}, project)
+
+	args := strings.Join(s.bwrapArgs(), " ")
+	require.True(t, strings.HasPrefix(args, "--ro-bind / / --dev /dev --bind "+project+" "+project+" "), args)
+	require.Contains(t, args, "--bind "+extra+" "+extra)
+	require.NotContains(t, args, "missing")
+	require.Contains(t, args, "--ro-bind "+filepath.Join(project, ".autonomy")+" "+filepath.Join(project, ".autonomy"))
+	require.True(t, strings.HasSuffix(args, "--unshare-user --unshare-ipc --unshare-net --die-with-parent --"), args)
+
+	s = New(Config{Network: true}, project)
+	require.NotContains(t, s.bwrapArgs(), "--unshare-net")
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// Check reports whether the sandbox works on this system; it only works on Linux
+func (s *Sandbox) Check() error {
+	return fmt.Errorf("%w on %s, it needs Linux user namespaces", ErrUnavailable, runtime.GOOS)
+}
+
+// Wrap changes cmd to run in the sandbox; a nil sandbox leaves cmd as it is
+func (s *Sandbox) Wrap(*exec.Cmd) error {
+	if s == nil {
+		return nil
+	}
+	return s.Check()
+}
+
+// Main runs the init process of a sandbox; there is none outside Linux
+func Main() {}
@@ -0,0 +1,112 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Action is the client's answer to an approval request
+type Action string
+
+const (
+	Accept Action = "accept"
+	Reject Action = "reject"
+	Edit   Action = "edit"
+	// AllowSession and AllowProject accept a tool call and remember its pattern
+	AllowSession Action = "allow_session"
+	AllowProject Action = "allow_project"
+)
+
+// Decision resolves an approval request. Plan carries an edited plan as JSON or YAML,
+// Feedback the reason a tool call was rejected.
+type Decision struct {
+	Action   Action `json:"decision"`
+	Feedback string `json:"feedback,omitempty"`
+	Plan     string `json:"plan,omitempty"`
+}
+
+// ErrApprovalNotFound is returned when resolving an unknown or already resolved approval
+var ErrApprovalNotFound = errors.New("approval not found")
+
+// errApprovalCanceled is returned to a waiting task when its session is canceled
+var errApprovalCanceled = errors.New("approval canceled")
+
+type pendingApproval struct {
+	sessionID string
+	reply     chan Decision
+}
+
+// Approvals matches approval requests raised by running tasks with decisions sent by the client
+type Approvals struct {
+	mu      sync.Mutex
+	pending map[string]*pendingApproval
+	nextID  int
+}
+
+func NewApprovals() *Approvals {
+	return &Approvals{pending: make(map[string]*pendingApproval)}
+}
+
+// Request registers a new approval; the channel yields the decision or is closed on cancel
+func (a *Approvals) Request(sessionID string) (string, <-chan Decision) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.nextID++
+	id := fmt.Sprintf("approval-%d", a.nextID)
+	reply := make(chan Decision, 1)
+	a.pending[id] = &pendingApproval{sessionID: sessionID, reply: reply}
+
+	return id, reply
+}
+
+// Resolve delivers the decision to the task waiting for it
+func (a *Approvals) Resolve(sessionID, id string, decision Decision) error {
+	switch decision.Action {
+	case Accept, Reject, Edit, AllowSession, AllowProject:
+	default:
+		return fmt.Errorf("unknown decision %q", decision.Action)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	p, ok := a.pending[id]
+	if !ok || p.sessionID != sessionID {
+		return ErrApprovalNotFound
+	}
+
+	delete(a.pending, id)
+	p.reply <- decision
+
+	return nil
+}
+
+// Pending returns the ids of the approvals a session is waiting for
+func (a *Approvals) Pending(sessionID string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var ids []string
+	for id, p := range a.pending {
+		if p.sessionID == sessionID {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// CancelSession releases every task of the session that waits for an approval
+func (a *Approvals) CancelSession(sessionID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for id, p := range a.pending {
+		if p.sessionID == sessionID {
+			delete(a.pending, id)
+			close(p.reply)
+		}
+	}
+}
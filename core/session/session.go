@@ -0,0 +1,436 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vadiminshakov/autonomy/core/ai"
+	"github.com/vadiminshakov/autonomy/core/decomposition"
+	"github.com/vadiminshakov/autonomy/core/events"
+	"github.com/vadiminshakov/autonomy/core/task"
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+// Status is the lifecycle state of a session
+type Status string
+
+const (
+	StatusIdle      Status = "idle"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+var (
+	// ErrNotFound is returned for unknown session ids
+	ErrNotFound = errors.New("session not found")
+	// ErrBusy is returned when a task is started while another one runs. Sessions of different
+	// projects can be open together, but the tool state is process-wide, so only one of them runs at a time.
+	ErrBusy = errors.New("another session is running; only one task runs at a time, wait for it to finish or cancel it")
+	// ErrNoClient is returned when a task is started before the manager has an AI client
+	ErrNoClient = errors.New("AI client is not configured")
+)
+
+// Listener receives the events of every session
+type Listener func(sessionID string, e events.Event)
+
+// Info describes a session for clients
+type Info struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Dir       string    `json:"project_dir,omitempty"`
+	Status    Status    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	Error     string    `json:"error,omitempty"`
+	Queued    []string  `json:"queued,omitempty"`
+	Approvals []string  `json:"pending_approvals,omitempty"`
+}
+
+// Session is one conversation with the agent; it survives task runs so it can be continued
+type Session struct {
+	id      string
+	title   string
+	dir     string
+	created time.Time
+	task    *task.Task
+
+	mu      sync.Mutex
+	status  Status
+	lastErr string
+	done    chan struct{}
+	// state is the tool state of the session between runs; the process has one for the running task
+	state *tools.TaskState
+}
+
+// Done is closed when the current run of the session ends
+func (s *Session) Done() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.done
+}
+
+// ID returns the session id
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Manager owns the sessions of a frontend and the approvals they wait for
+type Manager struct {
+	mu        sync.Mutex
+	client    ai.AIClient
+	sessions  map[string]*Session
+	running   string
+	nextID    int
+	listener  Listener
+	approvals *Approvals
+}
+
+func NewManager(listener Listener) *Manager {
+	if listener == nil {
+		listener = func(string, events.Event) {}
+	}
+
+	return &Manager{
+		sessions:  make(map[string]*Session),
+		listener:  listener,
+		approvals: NewApprovals(),
+	}
+}
+
+// SetClient configures the AI client used by new sessions
+func (m *Manager) SetClient(client ai.AIClient) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.client = client
+}
+
+// Create opens an idle session whose tasks run in dir; an empty dir keeps the working directory.
+// The first message sent to the session becomes its task.
+func (m *Manager) Create(dir string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.client == nil {
+		return nil, ErrNoClient
+	}
+
+	m.nextID++
+	s := &Session{
+		id:      fmt.Sprintf("session-%d", m.nextID),
+		dir:     dir,
+		created: time.Now(),
+		status:  StatusIdle,
+	}
+
+	s.task = task.NewTask(m.client)
+	s.task.SetDir(dir)
+	s.task.SetEventBus(events.NewBus(events.SinkFunc(func(e events.Event) {
+		m.listener(s.id, e)
+	})))
+	s.task.SetPlanReviewer(&planApprover{sessionID: s.id, manager: m})
+	s.task.SetToolApprover(&toolApprover{sessionID: s.id, manager: m})
+
+	m.sessions[s.id] = s
+
+	return s, nil
+}
+
+// Submit starts a task in a new session
+func (m *Manager) Submit(text string) (*Session, error) {
+	if running := m.Running(); running != "" {
+		return nil, ErrBusy
+	}
+
+	s, err := m.Create("")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := m.Send(s.id, text); err != nil {
+		m.mu.Lock()
+		delete(m.sessions, s.id)
+		m.mu.Unlock()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Send delivers a user message: a running task gets it before its next model call,
+// an idle session continues its conversation with it. It reports whether the message was queued.
+func (m *Manager) Send(sessionID, message string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[sessionID]
+	if !ok {
+		return false, ErrNotFound
+	}
+
+	if m.running == sessionID {
+		s.task.QueueMessage(message)
+		return true, nil
+	}
+	if m.running != "" {
+		return false, ErrBusy
+	}
+
+	s.mu.Lock()
+	first := s.title == ""
+	if first {
+		s.title = message
+	}
+	s.mu.Unlock()
+
+	if first {
+		s.task.SetOriginalTask(message)
+	}
+
+	s.task.AddUserMessage(message)
+	m.startLocked(s)
+
+	return false, nil
+}
+
+// Cancel stops the running task of a session; the conversation is kept
+func (m *Manager) Cancel(sessionID string) error {
+	m.mu.Lock()
+	s, ok := m.sessions[sessionID]
+	m.mu.Unlock()
+
+	if !ok {
+		return ErrNotFound
+	}
+
+	s.task.Cancel()
+	m.approvals.CancelSession(sessionID)
+
+	return nil
+}
+
+// Approve resolves an approval the session is waiting for
+func (m *Manager) Approve(sessionID, approvalID string, decision Decision) error {
+	m.mu.Lock()
+	_, ok := m.sessions[sessionID]
+	m.mu.Unlock()
+
+	if !ok {
+		return ErrNotFound
+	}
+
+	return m.approvals.Resolve(sessionID, approvalID, decision)
+}
+
+// Get returns a session by id
+func (m *Manager) Get(sessionID string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[sessionID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s, nil
+}
+
+// Info describes a session
+func (m *Manager) Info(sessionID string) (Info, error) {
+	s, err := m.Get(sessionID)
+	if err != nil {
+		return Info{}, err
+	}
+	return m.info(s), nil
+}
+
+// List describes all sessions, oldest first
+func (m *Manager) List() []Info {
+	m.mu.Lock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].created.Before(sessions[j].created)
+	})
+
+	infos := make([]Info, len(sessions))
+	for i, s := range sessions {
+		infos[i] = m.info(s)
+	}
+	return infos
+}
+
+// Running returns the id of the running session, if any
+func (m *Manager) Running() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.running
+}
+
+func (m *Manager) info(s *Session) Info {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return Info{
+		ID:        s.id,
+		Title:     s.title,
+		Dir:       s.dir,
+		Status:    s.status,
+		CreatedAt: s.created,
+		Error:     s.lastErr,
+		Queued:    s.task.QueuedMessages(),
+		Approvals: m.approvals.Pending(s.id),
+	}
+}
+
+// Close cancels the running task and stops the shells and background processes of all sessions
+func (m *Manager) Close() {
+	m.mu.Lock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	m.mu.Unlock()
+
+	for _, s := range sessions {
+		s.task.Close()
+	}
+}
+
+// startLocked runs the session's task in the background; m.mu must be held
+func (m *Manager) startLocked(s *Session) {
+	m.running = s.id
+
+	s.mu.Lock()
+	s.status = StatusRunning
+	s.lastErr = ""
+	done := make(chan struct{})
+	s.done = done
+	state := s.state
+	s.mu.Unlock()
+
+	// the tool state is process-wide: the session gets back its own, and a new one starts clean
+	if state != nil {
+		tools.GetTaskState().Restore(state)
+	} else {
+		tools.GetTaskState().Reset()
+	}
+
+	go func() {
+		err := s.task.ProcessTask()
+
+		s.mu.Lock()
+		s.state = tools.GetTaskState().Snapshot()
+		switch {
+		case err == nil:
+			s.status = StatusCompleted
+		case errors.Is(err, task.ErrCanceled):
+			s.status = StatusCanceled
+		default:
+			s.status = StatusFailed
+			s.lastErr = err.Error()
+		}
+		s.mu.Unlock()
+
+		m.mu.Lock()
+		if m.running == s.id {
+			m.running = ""
+		}
+		m.mu.Unlock()
+
+		close(done)
+	}()
+}
+
+// planApprover asks the client to approve plans through the approvals broker
+type planApprover struct {
+	sessionID string
+	manager   *Manager
+}
+
+func (p *planApprover) ReviewPlan(plan *decomposition.DecompositionResult) (task.PlanDecision, error) {
+	steps := make([]events.PlanStep, len(plan.Steps))
+	for i, step := range plan.Steps {
+		steps[i] = events.PlanStep{ID: step.ID, Description: step.Description, Status: step.Status}
+	}
+
+	for {
+		id, reply := p.manager.approvals.Request(p.sessionID)
+		p.manager.listener(p.sessionID, events.Event{
+			Type:     events.ApprovalRequested,
+			Time:     time.Now(),
+			Approval: &events.Approval{ID: id, Kind: "plan", Plan: &events.Plan{Steps: steps}},
+		})
+
+		decision, ok := <-reply
+		if !ok {
+			return task.PlanDecision{}, errApprovalCanceled
+		}
+
+		switch decision.Action {
+		case Accept:
+			return task.PlanDecision{Action: task.PlanAccept}, nil
+
+		case Edit:
+			edited, err := decomposition.ParsePlan([]byte(decision.Plan), plan.OriginalTask)
+			if err != nil {
+				// ask again instead of failing the task on a typo
+				p.manager.listener(p.sessionID, events.Event{
+					Type:  events.Notice,
+					Time:  time.Now(),
+					Level: events.LevelWarning,
+					Text:  fmt.Sprintf("invalid edited plan: %v", err),
+				})
+				continue
+			}
+			return task.PlanDecision{Action: task.PlanEdit, Plan: edited}, nil
+
+		default:
+			return task.PlanDecision{Action: task.PlanReject, Feedback: decision.Feedback}, nil
+		}
+	}
+}
+
+// toolApprover asks the client to approve tool calls through the approvals broker
+type toolApprover struct {
+	sessionID string
+	manager   *Manager
+}
+
+func (a *toolApprover) ApproveTool(req task.ToolApproval) (task.ToolDecision, error) {
+	id, reply := a.manager.approvals.Request(a.sessionID)
+	a.manager.listener(a.sessionID, events.Event{
+		Type: events.ApprovalRequested,
+		Time: time.Now(),
+		Approval: &events.Approval{
+			ID:      id,
+			Kind:    "tool",
+			Tool:    &events.ToolCall{ID: req.CallID, Name: req.Tool, Args: req.Args},
+			Preview: req.Preview,
+			Pattern: req.Pattern,
+			Reason:  req.Reason,
+		},
+	})
+
+	decision, ok := <-reply
+	if !ok {
+		return task.ToolDecision{}, errApprovalCanceled
+	}
+
+	switch decision.Action {
+	case Accept:
+		return task.ToolDecision{Action: task.ToolApprove}, nil
+	case AllowSession:
+		return task.ToolDecision{Action: task.ToolAllowSession}, nil
+	case AllowProject:
+		return task.ToolDecision{Action: task.ToolAllowProject}, nil
+	default:
+		return task.ToolDecision{Action: task.ToolDeny, Reason: decision.Feedback}, nil
+	}
+}
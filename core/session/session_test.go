@@ -0,0 +1,288 @@
+package session
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadiminshakov/autonomy/core/decomposition"
+	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/events"
+	"github.com/vadiminshakov/autonomy/core/task"
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+// scriptedClient returns canned responses in order
+type scriptedClient struct {
+	mu        sync.Mutex
+	responses []*entity.AIResponse
+}
+
+func (c *scriptedClient) GenerateCode(ctx context.Context, _ entity.PromptData) (*entity.AIResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	response := c.responses[0]
+	c.responses = c.responses[1:]
+	return response, nil
+}
+
+func completion(summary string) *entity.AIResponse {
+	return &entity.AIResponse{ToolCalls: []entity.ToolCall{
+		{ID: "1", Name: "attempt_completion", Args: map[string]any{"summary": summary}},
+	}}
+}
+
+func TestApprovalsResolve(t *testing.T) {
+	approvals := NewApprovals()
+
+	id, reply := approvals.Request("session-1")
+	require.Equal(t, []string{id}, approvals.Pending("session-1"))
+	require.Empty(t, approvals.Pending("session-2"))
+
+	require.ErrorIs(t, approvals.Resolve("session-2", id, Decision{Action: Accept}), ErrApprovalNotFound)
+	require.Error(t, approvals.Resolve("session-1", id, Decision{Action: "maybe"}))
+
+	require.NoError(t, approvals.Resolve("session-1", id, Decision{Action: Reject, Feedback: "too broad"}))
+	decision := <-reply
+	require.Equal(t, Reject, decision.Action)
+	require.Equal(t, "too broad", decision.Feedback)
+
+	require.ErrorIs(t, approvals.Resolve("session-1", id, Decision{Action: Accept}), ErrApprovalNotFound)
+	require.Empty(t, approvals.Pending("session-1"))
+}
+
+func TestApprovalsCancelSession(t *testing.T) {
+	approvals := NewApprovals()
+
+	_, first := approvals.Request("session-1")
+	_, other := approvals.Request("session-2")
+
+	approvals.CancelSession("session-1")
+
+	_, ok := <-first
+	require.False(t, ok)
+	require.Len(t, approvals.Pending("session-2"), 1)
+	require.Len(t, other, 0)
+}
+
+func TestManagerRunsSessions(t *testing.T) {
+	tools.GetTaskState().Reset()
+
+	var mu sync.Mutex
+	var received []events.Type
+	manager := NewManager(func(sessionID string, e events.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, "session-1", sessionID)
+		received = append(received, e.Type)
+	})
+
+	_, err := manager.Submit("finish")
+	require.ErrorIs(t, err, ErrNoClient)
+
+	// each completion is followed by the reflection verdict
+	verdict := &entity.AIResponse{Content: `{"complete": true}`}
+	manager.SetClient(&scriptedClient{responses: []*entity.AIResponse{
+		completion("done"), verdict, completion("done again"), verdict,
+	}})
+
+	s, err := manager.Submit("finish")
+	require.NoError(t, err)
+	<-s.Done()
+
+	info, err := manager.Info(s.ID())
+	require.NoError(t, err)
+	require.Equal(t, StatusCompleted, info.Status)
+	require.Empty(t, manager.Running())
+
+	mu.Lock()
+	require.Equal(t, events.TaskStarted, received[0])
+	require.Equal(t, events.TaskCompleted, received[len(received)-1])
+	mu.Unlock()
+
+	// an idle session continues its conversation
+	queued, err := manager.Send(s.ID(), "and once more")
+	require.NoError(t, err)
+	require.False(t, queued)
+	<-s.Done()
+
+	require.Len(t, manager.List(), 1)
+
+	_, err = manager.Send("session-42", "hello")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSessionsKeepTheirToolState(t *testing.T) {
+	read := func(path string) *entity.AIResponse {
+		return &entity.AIResponse{ToolCalls: []entity.ToolCall{
+			{ID: "1", Name: "read_file", Args: map[string]any{"path": path}},
+		}}
+	}
+	verdict := &entity.AIResponse{Content: `{"complete": true}`}
+
+	manager := NewManager(nil)
+	manager.SetClient(&scriptedClient{responses: []*entity.AIResponse{
+		read("a.txt"), completion("read a"), verdict,
+		completion("nothing read"), verdict,
+		read("b.txt"), completion("read b"), verdict,
+	}})
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b"), 0o644))
+
+	first, err := manager.Create(dir)
+	require.NoError(t, err)
+	second, err := manager.Create(dir)
+	require.NoError(t, err)
+
+	_, err = manager.Send(first.ID(), "read a")
+	require.NoError(t, err)
+	<-first.Done()
+
+	// the second session starts clean
+	_, err = manager.Send(second.ID(), "read nothing")
+	require.NoError(t, err)
+	<-second.Done()
+	require.Empty(t, tools.GetTaskState().ReadFiles)
+
+	// the first one continues with what it has done so far
+	_, err = manager.Send(first.ID(), "read b")
+	require.NoError(t, err)
+	<-first.Done()
+	require.Equal(t, []string{"a.txt", "b.txt"}, tools.GetTaskState().ReadFiles)
+}
+
+func TestSessionWorksInItsProject(t *testing.T) {
+	tools.GetTaskState().Reset()
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var results []string
+	var dirs []string
+	manager := NewManager(func(_ string, e events.Event) {
+		if e.Type != events.ToolCallFinished || e.Tool.Name != "read_file" {
+			return
+		}
+		dir, _ := os.Getwd()
+
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, e.Tool.Result+e.Tool.Error)
+		dirs = append(dirs, dir)
+	})
+	manager.SetClient(&scriptedClient{responses: []*entity.AIResponse{
+		{ToolCalls: []entity.ToolCall{{ID: "1", Name: "read_file", Args: map[string]any{"path": "note.txt"}}}},
+		completion("done"), {Content: `{"complete": true}`},
+	}})
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "note.txt"), []byte("from the project"), 0o644))
+
+	s, err := manager.Create(dir)
+	require.NoError(t, err)
+	_, err = manager.Send(s.ID(), "read the note")
+	require.NoError(t, err)
+	<-s.Done()
+
+	// relative paths resolve in the project, while the process stays where it is
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, results, 1)
+	require.Contains(t, results[0], "from the project")
+	require.Equal(t, []string{wd}, dirs)
+}
+
+func TestPlanApprover(t *testing.T) {
+	approvals := make(chan events.Approval, 4)
+	manager := NewManager(func(_ string, e events.Event) {
+		if e.Type == events.ApprovalRequested {
+			approvals <- *e.Approval
+		}
+	})
+	reviewer := &planApprover{sessionID: "session-1", manager: manager}
+
+	plan := &decomposition.DecompositionResult{
+		OriginalTask: "build",
+		Steps:        []decomposition.TaskStep{{ID: "step_1", Description: "compile"}},
+	}
+
+	type result struct {
+		decision task.PlanDecision
+		err      error
+	}
+	results := make(chan result, 1)
+	go func() {
+		decision, err := reviewer.ReviewPlan(plan)
+		results <- result{decision, err}
+	}()
+
+	// an invalid edit asks again instead of failing
+	first := <-approvals
+	require.Equal(t, "plan", first.Kind)
+	require.Equal(t, "compile", first.Plan.Steps[0].Description)
+	require.NoError(t, manager.approvals.Resolve("session-1", first.ID, Decision{Action: Edit, Plan: "{}"}))
+
+	second := <-approvals
+	require.NotEqual(t, first.ID, second.ID)
+	require.NoError(t, manager.approvals.Resolve("session-1", second.ID, Decision{Action: Accept}))
+
+	select {
+	case r := <-results:
+		require.NoError(t, r.err)
+		require.Equal(t, task.PlanAccept, r.decision.Action)
+	case <-time.After(5 * time.Second):
+		t.Fatal("plan review did not finish")
+	}
+}
+
+func TestToolApprover(t *testing.T) {
+	approvals := make(chan events.Approval, 4)
+	manager := NewManager(func(_ string, e events.Event) {
+		if e.Type == events.ApprovalRequested {
+			approvals <- *e.Approval
+		}
+	})
+	approver := &toolApprover{sessionID: "session-1", manager: manager}
+
+	type result struct {
+		decision task.ToolDecision
+		err      error
+	}
+	results := make(chan result, 1)
+	ask := func() {
+		decision, err := approver.ApproveTool(task.ToolApproval{
+			CallID:  "call-1",
+			Tool:    "bash",
+			Args:    map[string]any{"command": "go test ./..."},
+			Preview: "$ go test ./...",
+			Pattern: "go test *",
+		})
+		results <- result{decision, err}
+	}
+
+	go ask()
+	request := <-approvals
+	require.Equal(t, "tool", request.Kind)
+	require.Equal(t, "call-1", request.Tool.ID)
+	require.Equal(t, "go test *", request.Pattern)
+	require.NoError(t, manager.approvals.Resolve("session-1", request.ID, Decision{Action: AllowProject}))
+	require.Equal(t, result{decision: task.ToolDecision{Action: task.ToolAllowProject}}, <-results)
+
+	go ask()
+	request = <-approvals
+	require.NoError(t, manager.approvals.Resolve("session-1", request.ID, Decision{Action: Reject, Feedback: "not now"}))
+	require.Equal(t, result{decision: task.ToolDecision{Action: task.ToolDeny, Reason: "not now"}}, <-results)
+}
@@ -0,0 +1,276 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vadiminshakov/autonomy/core/config"
+	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/policy"
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+// ApprovalMode decides which tool calls need the user's approval
+type ApprovalMode string
+
+const (
+	// ApprovalAlways asks before every tool call
+	ApprovalAlways ApprovalMode = "always"
+	// ApprovalMutating asks before tools that change files or run commands
+	ApprovalMutating ApprovalMode = "mutating"
+	// ApprovalAuto runs tools without asking; only commands the policy marks "ask" need approval
+	ApprovalAuto ApprovalMode = "auto"
+	// ApprovalPlan allows read-only tools only
+	ApprovalPlan ApprovalMode = "plan"
+)
+
+// ApprovalModes lists the valid approval modes
+var ApprovalModes = []ApprovalMode{ApprovalAlways, ApprovalMutating, ApprovalAuto, ApprovalPlan}
+
+// ParseApprovalMode validates a mode name
+func ParseApprovalMode(name string) (ApprovalMode, error) {
+	for _, mode := range ApprovalModes {
+		if strings.EqualFold(name, string(mode)) {
+			return mode, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown approval mode %q, expected always, mutating, auto or plan", name)
+}
+
+// ConfiguredApprovalMode returns the mode of the config file, mutating if it sets none
+func ConfiguredApprovalMode() (ApprovalMode, error) {
+	cfg, err := config.LoadConfigFile()
+	if err != nil || cfg.ApprovalMode == "" {
+		return ApprovalMutating, nil
+	}
+
+	mode, err := ParseApprovalMode(cfg.ApprovalMode)
+	if err != nil {
+		return ApprovalMutating, err
+	}
+
+	return mode, nil
+}
+
+// ToolAction is the user's answer to a tool approval request
+type ToolAction int
+
+const (
+	// ToolApprove runs the call
+	ToolApprove ToolAction = iota
+	// ToolDeny refuses the call; the reason is passed to the agent
+	ToolDeny
+	// ToolAllowSession runs the call and approves its pattern for the rest of the session
+	ToolAllowSession
+	// ToolAllowProject runs the call and approves its pattern in the project for good
+	ToolAllowProject
+)
+
+// ToolDecision is the outcome of a tool approval request
+type ToolDecision struct {
+	Action ToolAction
+	Reason string
+}
+
+// ToolApproval describes a tool call waiting for the user
+type ToolApproval struct {
+	// CallID is the id of the tool call, as in the tool_call_started event
+	CallID string
+	Tool   string
+	Args   map[string]any
+	// Preview shows what the call does: the command line or a diff of the file
+	Preview string
+	// Pattern is what an "always allow" answer remembers; empty when the call cannot be remembered
+	Pattern string
+	// Reason explains why the call needs approval when a policy rule asked for it
+	Reason string
+}
+
+// ToolApprover asks the user whether a tool call may run
+type ToolApprover interface {
+	ApproveTool(req ToolApproval) (ToolDecision, error)
+}
+
+// SetToolApprover installs the approval gate for tool calls.
+// Without an approver nobody can be asked, so calls that need approval are refused.
+func (t *Task) SetToolApprover(approver ToolApprover) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.toolApprover = approver
+}
+
+// SetApprovalMode overrides the approval mode of the config file
+func (t *Task) SetApprovalMode(mode ApprovalMode) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.config.ApprovalMode = mode
+}
+
+// loadApprovals resolves the approval mode and reads the patterns approved for the project
+func (t *Task) loadApprovals() {
+	t.mu.RLock()
+	mode := t.config.ApprovalMode
+	t.mu.RUnlock()
+
+	if mode == "" {
+		var err error
+		if mode, err = ConfiguredApprovalMode(); err != nil {
+			t.warn(fmt.Sprintf("Invalid approval mode in config, using %s: %v", mode, err))
+		}
+	}
+
+	var rules []approvalRule
+	dir, err := t.workDir()
+	if err == nil {
+		rules, err = loadProjectApprovals(dir)
+	}
+	if err != nil {
+		t.warn(fmt.Sprintf("Remembered approvals ignored: %v", err))
+	}
+
+	t.mu.Lock()
+	t.approvalMode = mode
+	t.projectApprovals = rules
+	t.mu.Unlock()
+}
+
+// approveTool enforces the approval mode before a tool runs. The returned context resolves
+// the command policy's "ask" decisions with the same approver.
+func (t *Task) approveTool(ctx context.Context, call entity.ToolCall) (context.Context, error) {
+	tool, ok := t.tools.Get(call.Name)
+	if !ok {
+		return ctx, nil
+	}
+
+	t.mu.RLock()
+	approver := t.toolApprover
+	mode := t.approvalMode
+	t.mu.RUnlock()
+
+	if mode == ApprovalPlan && !tool.ReadOnly() {
+		return ctx, fmt.Errorf("tool %s is not available in plan mode, only read-only tools can be used", call.Name)
+	}
+
+	// invalid arguments fail in the tool anyway, nobody has to approve them
+	args, err := tools.ValidateArgs(tool.Schema(), call.Args)
+	if err != nil {
+		return ctx, nil
+	}
+
+	dir, err := t.workDir()
+	if err != nil {
+		return ctx, err
+	}
+
+	approved := false
+	if needsApproval(mode, tool) && !t.rememberedApproval(dir, call.Name, args) {
+		if approver == nil {
+			return ctx, fmt.Errorf("tool %s needs approval in %s mode, but there is nobody to approve it", call.Name, mode)
+		}
+
+		req := ToolApproval{
+			CallID:  call.ID,
+			Tool:    call.Name,
+			Args:    args,
+			Preview: approvalPreview(dir, call.Name, args),
+			Pattern: approvalPattern(dir, call.Name, args),
+		}
+		if err := t.requestApproval(approver, req); err != nil {
+			return ctx, err
+		}
+		approved = true
+	}
+
+	if approver == nil {
+		return ctx, nil
+	}
+
+	return policy.WithApprover(ctx, func(_ context.Context, script string, d policy.Decision) (bool, error) {
+		// the user has just seen and approved this very call
+		if approved {
+			return true, nil
+		}
+
+		req := ToolApproval{CallID: call.ID, Tool: call.Name, Args: args, Preview: "$ " + script, Reason: d.Reason}
+		if req.Reason == "" {
+			req.Reason = "the command policy asks before running " + d.Command
+		}
+
+		if err := t.requestApproval(approver, req); err != nil {
+			var denied *errToolDenied
+			if errors.As(err, &denied) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}), nil
+}
+
+func needsApproval(mode ApprovalMode, tool tools.Tool) bool {
+	switch mode {
+	case ApprovalAlways:
+		return true
+	case ApprovalAuto:
+		return false
+	default:
+		return !tool.ReadOnly()
+	}
+}
+
+// errToolDenied is returned for tool calls the user refused
+type errToolDenied struct {
+	reason string
+}
+
+func (e *errToolDenied) Error() string {
+	if e.reason == "" {
+		return "denied by the user"
+	}
+	return "denied by the user: " + e.reason
+}
+
+// rememberProjectApproval saves an "always allow" answer for the project the task works in
+func (t *Task) rememberProjectApproval(rule approvalRule) error {
+	dir, err := t.workDir()
+	if err != nil {
+		return err
+	}
+	return saveProjectApproval(dir, rule)
+}
+
+// requestApproval asks the approver and remembers "always allow" answers
+func (t *Task) requestApproval(approver ToolApprover, req ToolApproval) error {
+	decision, err := approver.ApproveTool(req)
+	if err != nil {
+		return fmt.Errorf("approval failed: %w", err)
+	}
+
+	rule := approvalRule{Tool: req.Tool, Pattern: req.Pattern}
+	if req.Pattern == "" && decision.Action != ToolDeny {
+		// nothing to remember, e.g. a command a policy rule asks about every time
+		decision.Action = ToolApprove
+	}
+
+	switch decision.Action {
+	case ToolApprove:
+	case ToolAllowSession:
+		t.mu.Lock()
+		t.sessionApprovals = append(t.sessionApprovals, rule)
+		t.mu.Unlock()
+	case ToolAllowProject:
+		t.mu.Lock()
+		t.projectApprovals = append(t.projectApprovals, rule)
+		t.mu.Unlock()
+		if err := t.rememberProjectApproval(rule); err != nil {
+			t.warn(fmt.Sprintf("Failed to remember approval: %v", err))
+		}
+	default:
+		return &errToolDenied{reason: decision.Reason}
+	}
+
+	return nil
+}
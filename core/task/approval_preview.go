@@ -0,0 +1,192 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+const (
+	// maxPreviewLines bounds the preview shown with an approval request
+	maxPreviewLines = 60
+	// maxDiffCells bounds the size of the table used to diff the changed part of a file
+	maxDiffCells = 1 << 20
+)
+
+// approvalPreview shows what a tool call is about to do; relative paths are read from dir
+func approvalPreview(dir, tool string, args map[string]any) string {
+	var preview string
+
+	switch tool {
+	case "bash", "interrupt_command":
+		command, _ := args["command"].(string)
+		preview = "$ " + command
+		if background, _ := args["run_in_background"].(bool); background {
+			preview += "\n(runs in the background)"
+		}
+
+	case "write_file":
+		path, _ := args["path"].(string)
+		content, _ := args["content"].(string)
+		old, err := os.ReadFile(inDir(dir, path))
+		if err != nil {
+			preview = fmt.Sprintf("new file %s\n%s", path, prefixLines(content, "+ "))
+		} else {
+			preview = fmt.Sprintf("%s\n%s", path, lineDiff(string(old), content))
+		}
+
+	case "lsp_edit":
+		preview = editPreview(dir, args)
+
+	case "str_replace":
+		preview = replacePreview(dir, args)
+
+	default:
+		data, err := json.MarshalIndent(args, "", "  ")
+		if err != nil {
+			return fmt.Sprintf("%v", args)
+		}
+		preview = string(data)
+	}
+
+	return limitPreview(preview)
+}
+
+// editPreview shows the lines an lsp_edit call replaces next to their new text
+func editPreview(dir string, args map[string]any) string {
+	path, _ := args["path"].(string)
+	edits, _ := args["edits"].([]any)
+
+	content, err := os.ReadFile(inDir(dir, path))
+	if err != nil {
+		return fmt.Sprintf("%s: %v", path, err)
+	}
+	lines := strings.Split(string(content), "\n")
+
+	var preview strings.Builder
+	preview.WriteString(path + "\n")
+
+	for _, e := range edits {
+		edit, _ := e.(map[string]any)
+		start, _ := edit["start_line"].(int)
+		end, _ := edit["end_line"].(int)
+		text, _ := edit["new_text"].(string)
+
+		fmt.Fprintf(&preview, "@@ lines %d-%d @@\n", start, end)
+		for i := start; i <= end && i >= 1 && i <= len(lines); i++ {
+			preview.WriteString("- " + lines[i-1] + "\n")
+		}
+		if text != "" {
+			preview.WriteString(prefixLines(text, "+ "))
+		}
+	}
+
+	return preview.String()
+}
+
+// replacePreview diffs the file against the result of a str_replace call, matching old text the way
+// the tool does; a call the tool would refuse shows the reason with the text as is
+func replacePreview(dir string, args map[string]any) string {
+	path, _ := args["path"].(string)
+	oldString, _ := args["old_string"].(string)
+	newString, _ := args["new_string"].(string)
+	replaceAll, _ := args["replace_all"].(bool)
+
+	content, err := os.ReadFile(inDir(dir, path))
+	if err != nil {
+		return fmt.Sprintf("%s: %v", path, err)
+	}
+
+	replaced, err := tools.ReplaceString(path, string(content), oldString, newString, replaceAll)
+	if err != nil {
+		return fmt.Sprintf("%s (%v)\n%s%s", path, err, prefixLines(oldString, "- "), prefixLines(newString, "+ "))
+	}
+
+	return fmt.Sprintf("%s\n%s", path, lineDiff(string(content), replaced))
+}
+
+// lineDiff renders the changed region of a file: the common head and tail are skipped
+// and the rest is diffed line by line
+func lineDiff(oldText, newText string) string {
+	a := strings.Split(oldText, "\n")
+	b := strings.Split(newText, "\n")
+
+	head := 0
+	for head < len(a) && head < len(b) && a[head] == b[head] {
+		head++
+	}
+	tail := 0
+	for tail < len(a)-head && tail < len(b)-head && a[len(a)-1-tail] == b[len(b)-1-tail] {
+		tail++
+	}
+
+	a, b = a[head:len(a)-tail], b[head:len(b)-tail]
+	if len(a) == 0 && len(b) == 0 {
+		return "(no changes)\n"
+	}
+
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "@@ line %d @@\n", head+1)
+
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			diff.WriteString("- " + line + "\n")
+		}
+		for _, line := range b {
+			diff.WriteString("+ " + line + "\n")
+		}
+		return diff.String()
+	}
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			diff.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			diff.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+
+	return diff.String()
+}
+
+func prefixLines(text, prefix string) string {
+	var out strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		out.WriteString(prefix + line + "\n")
+	}
+	return out.String()
+}
+
+func limitPreview(preview string) string {
+	lines := strings.Split(strings.TrimSuffix(preview, "\n"), "\n")
+	if len(lines) <= maxPreviewLines {
+		return strings.Join(lines, "\n")
+	}
+
+	return strings.Join(lines[:maxPreviewLines], "\n") + fmt.Sprintf("\n... %d more lines", len(lines)-maxPreviewLines)
+}
@@ -0,0 +1,254 @@
+package task
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/vadiminshakov/autonomy/core/policy"
+)
+
+// approvalRule is a remembered "always allow" answer. Pattern is matched against every
+// command of a bash call or the path of a file tool; "*" matches any call of the tool.
+type approvalRule struct {
+	Tool    string `json:"tool"`
+	Pattern string `json:"pattern"`
+}
+
+type approvalsConfig struct {
+	Project string         `json:"project"`
+	Allow   []approvalRule `json:"allow"`
+}
+
+// approvalsPath returns where the patterns approved for the project in dir are kept.
+// They live in the home directory, keyed by the project path: a file in the repository could be
+// shipped with the project and approve anything.
+func approvalsPath(dir string) (project, path string, err error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to detect home directory: %w", err)
+	}
+
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = real
+	}
+
+	sum := sha256.Sum256([]byte(dir))
+	return dir, filepath.Join(home, ".autonomy", "approvals", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+func loadProjectApprovals(dir string) ([]approvalRule, error) {
+	project, path, err := approvalsPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg approvalsConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if cfg.Project != project {
+		return nil, fmt.Errorf("%s belongs to %s, not %s", path, cfg.Project, project)
+	}
+
+	return cfg.Allow, nil
+}
+
+func saveProjectApproval(dir string, rule approvalRule) error {
+	project, path, err := approvalsPath(dir)
+	if err != nil {
+		return err
+	}
+
+	rules, err := loadProjectApprovals(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rules {
+		if r == rule {
+			return nil
+		}
+	}
+
+	data, err := json.MarshalIndent(approvalsConfig{Project: project, Allow: append(rules, rule)}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// rememberedApproval reports whether the patterns approved earlier cover the call made in dir
+func (t *Task) rememberedApproval(dir, tool string, args map[string]any) bool {
+	t.mu.RLock()
+	var patterns []string
+	for _, rules := range [][]approvalRule{t.sessionApprovals, t.projectApprovals} {
+		for _, r := range rules {
+			if r.Tool == tool {
+				patterns = append(patterns, r.Pattern)
+			}
+		}
+	}
+	t.mu.RUnlock()
+
+	subjects := approvalSubjects(dir, tool, args)
+	if len(patterns) == 0 || len(subjects) == 0 {
+		return false
+	}
+
+	for _, subject := range subjects {
+		matched := false
+		for _, pattern := range patterns {
+			if matchApprovalPattern(pattern, subject) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// approvalSubjects returns what the patterns of a tool are matched against; file paths are taken relative to dir
+func approvalSubjects(dir, tool string, args map[string]any) []string {
+	if command, ok := commandArg(tool, args); ok {
+		commands, ok := approvableCommands(command)
+		if !ok {
+			return nil
+		}
+
+		subjects := make([]string, len(commands))
+		for i, cmd := range commands {
+			subjects[i] = cmd.String()
+		}
+		return subjects
+	}
+
+	if path := getFilePathFromArgs(args); path != "" && isFileOperation(tool) {
+		return []string{relativePath(dir, path)}
+	}
+
+	return []string{tool}
+}
+
+// approvalPattern generalizes a call into the pattern an "always allow" answer remembers:
+// the program and subcommand of a command, the directory of a file, or the whole tool
+func approvalPattern(dir, tool string, args map[string]any) string {
+	if command, ok := commandArg(tool, args); ok {
+		commands, ok := approvableCommands(command)
+		if !ok || len(commands) == 0 {
+			return ""
+		}
+
+		// "go test -v" becomes "go test *", while "rm -rf build" becomes "rm *"
+		cmd := commands[0]
+		if len(cmd.Args) > 0 && subcommandPattern.MatchString(cmd.Args[0]) {
+			return cmd.Program + " " + cmd.Args[0] + " *"
+		}
+		return cmd.Program + " *"
+	}
+
+	if path := getFilePathFromArgs(args); path != "" && isFileOperation(tool) {
+		path = relativePath(dir, path)
+		if dir := filepath.ToSlash(filepath.Dir(path)); dir != "." {
+			return dir + "/*"
+		}
+		return path
+	}
+
+	return "*"
+}
+
+// subcommandPattern tells subcommands like "test" or "run" from file names and other arguments
+var subcommandPattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+func commandArg(tool string, args map[string]any) (string, bool) {
+	if tool != "bash" && tool != "interrupt_command" {
+		return "", false
+	}
+
+	command, _ := args["command"].(string)
+	return command, true
+}
+
+// approvableCommands parses a command line; commands run through wrappers like sudo are never remembered
+func approvableCommands(command string) ([]policy.Command, bool) {
+	pipelines, err := policy.Parse(command)
+	if err != nil {
+		return nil, false
+	}
+
+	commands := policy.Commands(pipelines)
+	for _, cmd := range commands {
+		if len(cmd.Wrappers) > 0 {
+			return nil, false
+		}
+	}
+
+	return commands, true
+}
+
+// relativePath returns a path relative to dir, or the absolute path outside of it
+func relativePath(dir, path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Clean(path)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// matchApprovalPattern matches a subject against a pattern in which "*" matches any text.
+// A trailing " *" also matches no arguments at all, so "go test *" covers "go test".
+func matchApprovalPattern(pattern, subject string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, " *"); ok && prefix == subject {
+		return true
+	}
+
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == subject
+	}
+
+	if !strings.HasPrefix(subject, parts[0]) {
+		return false
+	}
+	subject = subject[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(subject, part)
+		if idx < 0 {
+			return false
+		}
+		subject = subject[idx+len(part):]
+	}
+
+	return strings.HasSuffix(subject, last)
+}
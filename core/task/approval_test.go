@@ -0,0 +1,262 @@
+package task
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadiminshakov/autonomy/core/config"
+	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/policy"
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+type scriptedApprover struct {
+	decisions []ToolDecision
+	requests  []ToolApproval
+}
+
+func (a *scriptedApprover) ApproveTool(req ToolApproval) (ToolDecision, error) {
+	a.requests = append(a.requests, req)
+	if len(a.decisions) == 0 {
+		return ToolDecision{Action: ToolApprove}, nil
+	}
+	decision := a.decisions[0]
+	a.decisions = a.decisions[1:]
+	return decision, nil
+}
+
+// approvalRegistry has a read-only tool, a file tool and a bash tool that only consults the command policy
+func approvalRegistry() *tools.Registry {
+	registry := tools.NewRegistry()
+	registry.Register(&tools.FuncTool{
+		ToolName:   "read_file",
+		IsReadOnly: true,
+		Fn:         func(context.Context, map[string]any) (string, error) { return "content", nil },
+	})
+	registry.Register(&tools.FuncTool{
+		ToolName: "write_file",
+		Fn:       func(context.Context, map[string]any) (string, error) { return "written", nil },
+	})
+	registry.Register(&tools.FuncTool{
+		ToolName: "bash",
+		Fn: func(ctx context.Context, args map[string]any) (string, error) {
+			if err := policy.Check(ctx, "bash", args["command"].(string)); err != nil {
+				return "", err
+			}
+			return "ran", nil
+		},
+	})
+	return registry
+}
+
+func approvalTask(mode ApprovalMode, approver ToolApprover) *Task {
+	tsk := NewTask(&scriptedClient{})
+	tsk.SetTools(approvalRegistry())
+	tsk.SetToolApprover(approver)
+	tsk.SetApprovalMode(mode)
+	tsk.loadApprovals()
+	return tsk
+}
+
+func run(tsk *Task, name string, args map[string]any) (string, error) {
+	return tsk.execWithHooks(context.Background(), entity.ToolCall{ID: "1", Name: name, Args: args})
+}
+
+func enterTempDir(t *testing.T) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	return dir
+}
+
+func TestApprovalModes(t *testing.T) {
+	enterTempDir(t)
+
+	calls := []struct {
+		name string
+		args map[string]any
+	}{
+		{"read_file", map[string]any{"path": "a.go"}},
+		{"write_file", map[string]any{"path": "a.go", "content": "package a"}},
+		{"bash", map[string]any{"command": "go test ./..."}},
+	}
+
+	asked := map[ApprovalMode]int{ApprovalAlways: 3, ApprovalMutating: 2, ApprovalAuto: 0}
+	for mode, want := range asked {
+		approver := &scriptedApprover{}
+		tsk := approvalTask(mode, approver)
+
+		for _, call := range calls {
+			_, err := run(tsk, call.name, call.args)
+			require.NoError(t, err, mode)
+		}
+		require.Len(t, approver.requests, want, mode)
+	}
+
+	tsk := approvalTask(ApprovalPlan, &scriptedApprover{})
+	result, err := run(tsk, "read_file", calls[0].args)
+	require.NoError(t, err)
+	require.Equal(t, "content", result)
+	_, err = run(tsk, "write_file", calls[1].args)
+	require.ErrorContains(t, err, "not available in plan mode")
+
+	// nobody can be asked without an approver, so calls that need approval are refused
+	tsk = approvalTask(ApprovalMutating, nil)
+	_, err = run(tsk, "read_file", calls[0].args)
+	require.NoError(t, err)
+	_, err = run(tsk, "write_file", calls[1].args)
+	require.ErrorContains(t, err, "nobody to approve it")
+}
+
+func TestApprovalDenied(t *testing.T) {
+	enterTempDir(t)
+
+	approver := &scriptedApprover{decisions: []ToolDecision{{Action: ToolDeny, Reason: "use a temp dir"}}}
+	tsk := approvalTask(ApprovalMutating, approver)
+
+	_, err := run(tsk, "bash", map[string]any{"command": "rm -rf build && make"})
+	require.EqualError(t, err, "denied by the user: use a temp dir")
+	require.Equal(t, "$ rm -rf build && make", approver.requests[0].Preview)
+	require.Equal(t, "rm *", approver.requests[0].Pattern)
+}
+
+func TestRememberedApprovals(t *testing.T) {
+	dir := enterTempDir(t)
+
+	approver := &scriptedApprover{decisions: []ToolDecision{
+		{Action: ToolAllowSession},
+		{Action: ToolAllowProject},
+	}}
+	tsk := approvalTask(ApprovalMutating, approver)
+
+	_, err := run(tsk, "bash", map[string]any{"command": "go test ./..."})
+	require.NoError(t, err)
+	require.Equal(t, "go test *", approver.requests[0].Pattern)
+
+	_, err = run(tsk, "write_file", map[string]any{"path": "internal/api/a.go", "content": "package api"})
+	require.NoError(t, err)
+	require.Equal(t, "internal/api/*", approver.requests[1].Pattern)
+
+	// covered by the remembered patterns
+	for _, command := range []string{"go test", "go test -run TestX ./pkg && go test ./..."} {
+		_, err = run(tsk, "bash", map[string]any{"command": command})
+		require.NoError(t, err, command)
+	}
+	_, err = run(tsk, "write_file", map[string]any{"path": "internal/api/b.go", "content": "package api"})
+	require.NoError(t, err)
+	require.Len(t, approver.requests, 2)
+
+	// every command of a call must be covered, and wrappers are never remembered
+	for _, command := range []string{"go test ./... && rm -rf /tmp/x", "go build ./...", "sudo go test ./..."} {
+		_, err = run(tsk, "bash", map[string]any{"command": command})
+		require.NoError(t, err)
+	}
+	require.Len(t, approver.requests, 5)
+
+	// the project approval outlives the task, the session one does not
+	next := &scriptedApprover{}
+	tsk = approvalTask(ApprovalMutating, next)
+	_, err = run(tsk, "write_file", map[string]any{"path": "internal/api/c.go", "content": "package api"})
+	require.NoError(t, err)
+	_, err = run(tsk, "bash", map[string]any{"command": "go test ./..."})
+	require.NoError(t, err)
+	require.Len(t, next.requests, 1)
+	require.Equal(t, "bash", next.requests[0].Tool)
+
+	project, path, err := approvalsPath(dir)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(path, filepath.Join(os.Getenv("HOME"), ".autonomy", "approvals")), path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"pattern": "internal/api/*"`)
+	require.Contains(t, string(data), `"project": "`+project+`"`)
+
+	// a file shipped with the repository approves nothing
+	require.NoError(t, os.MkdirAll(".autonomy", 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(".autonomy", "approvals.json"),
+		[]byte(`{"allow": [{"tool": "bash", "pattern": "*"}]}`), 0o644))
+	next = &scriptedApprover{}
+	tsk = approvalTask(ApprovalMutating, next)
+	_, err = run(tsk, "bash", map[string]any{"command": "make deploy"})
+	require.NoError(t, err)
+	require.Len(t, next.requests, 1)
+}
+
+func TestPolicyAskUsesApprover(t *testing.T) {
+	dir := enterTempDir(t)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".autonomy"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".autonomy", "policy.json"),
+		[]byte(`{"rules": [{"action": "ask", "program": "npm", "subcommand": "publish", "reason": "publishing"}]}`), 0o644))
+	require.NoError(t, config.Trust(dir))
+
+	approver := &scriptedApprover{decisions: []ToolDecision{{Action: ToolDeny}, {Action: ToolAllowSession}}}
+	tsk := approvalTask(ApprovalAuto, approver)
+	tsk.loadPolicy()
+
+	_, err := run(tsk, "bash", map[string]any{"command": "npm test"})
+	require.NoError(t, err)
+	require.Empty(t, approver.requests)
+
+	_, err = run(tsk, "bash", map[string]any{"command": "npm publish"})
+	require.ErrorContains(t, err, "rejected by the user")
+	require.Equal(t, "publishing", approver.requests[0].Reason)
+	require.Empty(t, approver.requests[0].Pattern)
+
+	// policy questions are never remembered
+	_, err = run(tsk, "bash", map[string]any{"command": "npm publish"})
+	require.NoError(t, err)
+	_, err = run(tsk, "bash", map[string]any{"command": "npm publish"})
+	require.NoError(t, err)
+	require.Len(t, approver.requests, 3)
+
+	// a call the user has just approved is not asked about twice
+	approver = &scriptedApprover{}
+	tsk = approvalTask(ApprovalMutating, approver)
+	tsk.loadPolicy()
+	_, err = run(tsk, "bash", map[string]any{"command": "npm publish"})
+	require.NoError(t, err)
+	require.Len(t, approver.requests, 1)
+}
+
+func TestLineDiff(t *testing.T) {
+	diff := lineDiff("a\nb\nc\nd\n", "a\nB\nc\nd\ne\n")
+	require.Equal(t, "@@ line 2 @@\n- b\n+ B\n  c\n  d\n+ e\n", diff)
+
+	require.Equal(t, "(no changes)\n", lineDiff("same", "same"))
+}
+
+func TestReplacePreview(t *testing.T) {
+	dir := enterTempDir(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("a\nb\nc\nb\n"), 0o644))
+
+	preview := approvalPreview(dir, "str_replace", map[string]any{"path": "a.go", "old_string": "a\nb", "new_string": "A\nB"})
+	require.Equal(t, "a.go\n@@ line 1 @@\n- a\n- b\n+ A\n+ B", preview)
+
+	preview = approvalPreview(dir, "str_replace", map[string]any{"path": "a.go", "old_string": "b", "new_string": "B", "replace_all": true})
+	require.Equal(t, "a.go\n@@ line 2 @@\n- b\n+ B\n  c\n- b\n+ B", preview)
+
+	// the tool would refuse these calls
+	preview = approvalPreview(dir, "str_replace", map[string]any{"path": "a.go", "old_string": "b", "new_string": "B"})
+	require.Contains(t, preview, "old_string matches 2 times")
+
+	preview = approvalPreview(dir, "str_replace", map[string]any{"path": "a.go", "old_string": "x\ny", "new_string": "z"})
+	require.True(t, strings.HasPrefix(preview, "a.go (old_string not found in a.go;"), preview)
+	require.True(t, strings.HasSuffix(preview, "\n- x\n- y\n+ z"), preview)
+
+	// text the tool matches ignoring whitespace is previewed as the tool applies it
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.go"), []byte("if ok {\n\tf()\n}\n"), 0o644))
+	preview = approvalPreview(dir, "str_replace", map[string]any{"path": "b.go", "old_string": "    f()", "new_string": "    g()"})
+	require.Equal(t, "b.go\n@@ line 2 @@\n- \tf()\n+ \tg()", preview)
+}
@@ -0,0 +1,93 @@
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+// checkCompletion runs the checks that must pass before attempt_completion is accepted.
+// A non-empty result explains to the model what blocks completion.
+func (t *Task) checkCompletion(call entity.ToolCall) string {
+	// a call the tool refuses on its own fails without using up a round of the checks
+	tool, ok := t.tools.Get(call.Name)
+	if !ok {
+		return ""
+	}
+	args, err := tools.ValidateArgs(tool.Schema(), call.Args)
+	if err != nil || tools.CheckCompletion(args) != nil {
+		return ""
+	}
+
+	if blocked := t.preCompletionHooks(call); blocked != "" {
+		return blocked
+	}
+
+	if blocked := t.validateChangedFiles(); blocked != "" {
+		return blocked
+	}
+
+	// plan steps are checked by the step verifier, reflection covers the whole task
+	if isPlanStep() {
+		return ""
+	}
+
+	return t.reflect(tools.ParseCompletionReport(call.Args).Summary)
+}
+
+// isPlanStep reports whether a step of a decomposed plan is being executed
+func isPlanStep() bool {
+	stepID, ok := tools.GetTaskState().GetContext("current_step")
+	return ok && stepID != ""
+}
+
+// validateChangedFiles runs the file validators on everything the task created or modified.
+// Completion stays blocked while errors remain, up to MaxValidationRounds.
+func (t *Task) validateChangedFiles() string {
+	if !t.config.EnableFileValidation || len(tools.GetTaskState().ChangedFiles()) == 0 {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(t.withWorkspace(t.ctx), t.config.ValidationTimeout)
+	defer cancel()
+
+	engine := tools.NewFileValidationEngine(tools.ValidationConfig{
+		EnableCompilation: true,
+		EnableLinting:     true,
+		Timeout:           t.config.ValidationTimeout,
+	})
+
+	stopThinking := t.startThinking("validating changed files...")
+	results := engine.ValidateModifiedFiles(ctx)
+	stopThinking()
+
+	t.mu.Lock()
+	t.lastValidation = results
+	t.mu.Unlock()
+
+	if !tools.HasValidationErrors(results) {
+		return ""
+	}
+
+	report := tools.FormatValidationResults(results)
+
+	t.mu.Lock()
+	t.validationRounds++
+	rounds := t.validationRounds
+	t.mu.Unlock()
+
+	if rounds > t.config.MaxValidationRounds {
+		t.warn(fmt.Sprintf("Validation errors remain after %d fix-up rounds, accepting completion",
+			t.config.MaxValidationRounds))
+		return ""
+	}
+
+	t.warn(fmt.Sprintf("Validation found errors in changed files (fix-up round %d/%d)",
+		rounds, t.config.MaxValidationRounds))
+
+	return fmt.Sprintf("completion blocked: validation found errors in changed files. "+
+		"Fix them, then call attempt_completion again (fix-up round %d/%d).\n\n%s",
+		rounds, t.config.MaxValidationRounds, report)
+}
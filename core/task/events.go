@@ -0,0 +1,113 @@
+package task
+
+import (
+	"errors"
+
+	"github.com/vadiminshakov/autonomy/core/decomposition"
+	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/events"
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+// Events returns the bus the task publishes its execution events to
+func (t *Task) Events() *events.Bus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.events
+}
+
+// SetEventBus replaces the event bus, e.g. to drop the terminal renderer
+func (t *Task) SetEventBus(bus *events.Bus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = bus
+}
+
+func (t *Task) emit(e events.Event) {
+	t.Events().Publish(e)
+}
+
+func (t *Task) info(message string) {
+	t.emit(events.Event{Type: events.Notice, Level: events.LevelInfo, Text: message})
+}
+
+func (t *Task) warn(message string) {
+	t.emit(events.Event{Type: events.Notice, Level: events.LevelWarning, Text: message})
+}
+
+// startThinking reports a model call or other long operation; the returned function ends it
+func (t *Task) startThinking(message string) func() {
+	t.emit(events.Event{Type: events.ThinkingStarted, Text: message})
+
+	return func() {
+		t.emit(events.Event{Type: events.ThinkingFinished})
+	}
+}
+
+func (t *Task) emitAssistantText(content string) {
+	if content == "" {
+		return
+	}
+	t.emit(events.Event{Type: events.AssistantDelta, Text: content})
+}
+
+// emitUsage reports the tokens of one model call along with the task totals
+func (t *Task) emitUsage(usage entity.Usage) {
+	if usage.InputTokens == 0 && usage.OutputTokens == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	t.usage.InputTokens += usage.InputTokens
+	t.usage.OutputTokens += usage.OutputTokens
+	total := t.usage
+	t.mu.Unlock()
+
+	t.emit(events.Event{Type: events.Usage, Usage: &events.TokenUsage{
+		InputTokens:       usage.InputTokens,
+		OutputTokens:      usage.OutputTokens,
+		TotalInputTokens:  total.InputTokens,
+		TotalOutputTokens: total.OutputTokens,
+	}})
+}
+
+// emitPlan reports a plan about to be executed; diff is set for replans
+func (t *Task) emitPlan(plan *decomposition.DecompositionResult, diff string) {
+	steps := make([]events.PlanStep, len(plan.Steps))
+	for i, step := range plan.Steps {
+		steps[i] = events.PlanStep{ID: step.ID, Description: step.Description, Status: step.Status}
+	}
+
+	t.emit(events.Event{Type: events.PlanCreated, Plan: &events.Plan{Steps: steps, Diff: diff}})
+}
+
+func (t *Task) emitStep(idx, total int, step decomposition.TaskStep, stepErr error) {
+	e := &events.Step{
+		Index:       idx,
+		Total:       total,
+		ID:          step.ID,
+		Description: step.Description,
+		Status:      step.Status,
+	}
+	if stepErr != nil {
+		e.Error = stepErr.Error()
+	}
+
+	t.emit(events.Event{Type: events.StepStatus, Step: e})
+}
+
+// emitResult reports how ProcessTask ended
+func (t *Task) emitResult(err error) {
+	switch {
+	case err == nil:
+		summary := ""
+		if report, ok := tools.GetCompletionReport(); ok {
+			summary = report.Summary
+		}
+		t.emit(events.Event{Type: events.TaskCompleted, Text: summary})
+	case errors.Is(err, ErrCanceled):
+		t.warn("Task canceled")
+	default:
+		t.emit(events.Event{Type: events.Error, Error: err.Error()})
+	}
+}
@@ -0,0 +1,153 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/hooks"
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+const taskEndHookTimeout = 2 * time.Minute
+
+// loadHooks reads the hooks of the current project; they are reloaded on every run
+func (t *Task) loadHooks() {
+	wd, err := t.workDir()
+	if err != nil {
+		return
+	}
+
+	m, err := hooks.Load(wd)
+	switch {
+	case errors.Is(err, hooks.ErrUntrusted):
+		t.warn(fmt.Sprintf("Project hooks disabled: %v; run `autonomy trust` in the project to enable them", err))
+	case err != nil:
+		t.warn(fmt.Sprintf("Hooks disabled: %v", err))
+		m = nil
+	}
+
+	t.mu.Lock()
+	t.hooks = m
+	t.mu.Unlock()
+}
+
+func (t *Task) runHooks(ctx context.Context, in hooks.Input) hooks.Outcome {
+	t.mu.RLock()
+	m := t.hooks
+	in.Task = t.originalTask
+	t.mu.RUnlock()
+
+	if m.Empty(in.Event) {
+		return hooks.Outcome{}
+	}
+
+	outcome := m.Run(t.withPolicy(ctx), in)
+	for _, err := range outcome.Errors {
+		t.warn(err.Error())
+	}
+
+	return outcome
+}
+
+// execWithHooks runs a tool between its pre_tool and post_tool hooks, which may block it,
+// rewrite its arguments or result, or attach notes for the agent
+func (t *Task) execWithHooks(ctx context.Context, call entity.ToolCall) (string, error) {
+	pre := t.runHooks(ctx, hooks.Input{Event: hooks.PreTool, Tool: call.Name, Args: call.Args})
+	if pre.Blocked {
+		return "", fmt.Errorf("blocked by hook: %s", pre.Reason)
+	}
+	if pre.Args != nil {
+		call.Args = pre.Args
+	}
+
+	// the user approves the arguments the hooks left
+	toolCtx, err := t.approveTool(ctx, call)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := t.exec(toolCtx, call)
+
+	in := hooks.Input{Event: hooks.PostTool, Tool: call.Name, Args: call.Args, Result: result}
+	if err != nil {
+		in.Error = err.Error()
+	}
+
+	post := t.runHooks(ctx, in)
+	if post.Result != nil {
+		result = *post.Result
+	}
+	if post.Blocked {
+		err = fmt.Errorf("blocked by hook: %s", post.Reason)
+	}
+
+	notes := pre.Messages
+	notes = append(notes, post.Messages...)
+	if len(notes) == 0 {
+		return result, err
+	}
+	if err != nil {
+		return result, fmt.Errorf("%w\n%s", err, formatHookNotes(notes))
+	}
+
+	return result + "\n\n" + formatHookNotes(notes), nil
+}
+
+// preCompletionHooks lets hooks refuse attempt_completion; a non-empty result is the reason for the agent
+func (t *Task) preCompletionHooks(call entity.ToolCall) string {
+	outcome := t.runHooks(t.ctx, hooks.Input{
+		Event:   hooks.PreCompletion,
+		Tool:    call.Name,
+		Args:    call.Args,
+		Summary: tools.ParseCompletionReport(call.Args).Summary,
+	})
+
+	for _, msg := range outcome.Messages {
+		t.info(msg)
+	}
+
+	if !outcome.Blocked {
+		return ""
+	}
+
+	return fmt.Sprintf("completion blocked by hook: %s\n\nAddress it, then call attempt_completion again.", outcome.Reason)
+}
+
+// taskEndHooks reports how the run ended; they run even when the task was canceled
+func (t *Task) taskEndHooks(runErr error) {
+	in := hooks.Input{Event: hooks.TaskEnd, Status: "completed"}
+	switch {
+	case errors.Is(runErr, ErrCanceled):
+		in.Status = "canceled"
+	case runErr != nil:
+		in.Status = "failed"
+		in.Error = runErr.Error()
+	}
+	if report, ok := tools.GetCompletionReport(); ok {
+		in.Summary = report.Summary
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), taskEndHookTimeout)
+	defer cancel()
+
+	outcome := t.runHooks(ctx, in)
+	for _, msg := range outcome.Messages {
+		t.info(msg)
+	}
+}
+
+func formatHookNotes(notes []string) string {
+	var b strings.Builder
+	for i, note := range notes {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("Hook: ")
+		b.WriteString(note)
+	}
+	return b.String()
+}
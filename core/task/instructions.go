@@ -0,0 +1,98 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/instructions"
+)
+
+// systemPromptFor appends the instructions that apply to dir to the base system prompt
+func systemPromptFor(dir string) string {
+	if dir == "" {
+		return systemPrompt
+	}
+
+	text := instructions.Format(instructions.Load(dir))
+	if text == "" {
+		return systemPrompt
+	}
+
+	return systemPrompt + "\n\n" + text
+}
+
+// loadInstructions refreshes the system prompt for the working directory of this run
+// and forgets which nested directories were already announced
+func (t *Task) loadInstructions() {
+	wd, err := t.workDir()
+	if err != nil {
+		return
+	}
+
+	root := instructions.FindRoot(wd)
+	loaded := map[string]bool{root: true}
+	for dir := wd; dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		loaded[dir] = true
+	}
+
+	t.mu.Lock()
+	t.promptData.SystemPrompt = systemPromptFor(wd)
+	t.instructionsRoot = root
+	t.instructionDirs = loaded
+	t.mu.Unlock()
+}
+
+// nestedInstructions returns the instructions of directories the tool call enters for the first time,
+// formatted to be appended to the tool result
+func (t *Task) nestedInstructions(call entity.ToolCall) string {
+	path := getFilePathFromArgs(call.Args)
+	if path == "" {
+		return ""
+	}
+
+	dir := filepath.Clean(path)
+	if !filepath.IsAbs(dir) {
+		wd, err := t.workDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(wd, dir)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	t.mu.Lock()
+	root := t.instructionsRoot
+	if root == "" || t.instructionDirs[dir] {
+		t.mu.Unlock()
+		return ""
+	}
+
+	var sources []instructions.Source
+	for _, s := range instructions.Between(root, dir) {
+		if !t.instructionDirs[s.Dir] {
+			sources = append(sources, s)
+		}
+	}
+	for current := dir; current != root && strings.HasPrefix(current, root); current = filepath.Dir(current) {
+		t.instructionDirs[current] = true
+	}
+	t.mu.Unlock()
+
+	if len(sources) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\nThe following directory instructions apply to files under these directories " +
+		"and take precedence over earlier instructions:")
+	for _, s := range sources {
+		b.WriteString(fmt.Sprintf("\n\n--- %s instructions (%s) ---\n%s", s.Scope, s.Path, s.Content))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,40 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadiminshakov/autonomy/core/entity"
+)
+
+func TestNestedInstructions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "AUTONOMY.md"), []byte("use testify"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "api", "v1"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "api", "AUTONOMY.md"), []byte("keep handlers thin"), 0o644))
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(root))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	tsk := NewTask(&scriptedClient{})
+	tsk.loadInstructions()
+	require.Contains(t, tsk.promptData.SystemPrompt, "use testify")
+	require.NotContains(t, tsk.promptData.SystemPrompt, "keep handlers thin")
+
+	read := func(path string) string {
+		return tsk.nestedInstructions(entity.ToolCall{Name: "read_file", Args: map[string]any{"path": path}})
+	}
+
+	require.Empty(t, read("main.go"))
+	require.Contains(t, read("api/v1/handler.go"), "keep handlers thin")
+	// each directory is announced once
+	require.Empty(t, read("api/routes.go"))
+}
@@ -0,0 +1,331 @@
+package task
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vadiminshakov/autonomy/core/decomposition"
+	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+// ErrStalled is returned when the agent keeps looping after hints and replanning
+var ErrStalled = errors.New("task stalled")
+
+// errLoopReplan asks the caller to replan the remaining work
+var errLoopReplan = errors.New("agent is stuck in a loop")
+
+// loopAction is how the agent loop responds to a detected loop
+type loopAction int
+
+const (
+	loopContinue loopAction = iota
+	loopHint
+	loopReplan
+	loopStop
+)
+
+// callRecord is the fingerprint of one executed tool call
+type callRecord struct {
+	signature string
+	result    string
+	errorHash string
+	display   string
+}
+
+// loopDetector watches recent tool calls for repeated calls, oscillating edits and unchanged errors
+type loopDetector struct {
+	mu        sync.Mutex
+	window    int
+	threshold int
+	dir       string
+
+	recent   []callRecord
+	log      []callRecord
+	files    map[string][]string
+	pending  string
+	findings []string
+}
+
+func newLoopDetector(window, threshold int) *loopDetector {
+	return &loopDetector{
+		window:    window,
+		threshold: threshold,
+		files:     make(map[string][]string),
+	}
+}
+
+// setDir sets the directory relative paths of tool calls are resolved from
+func (d *loopDetector) setDir(dir string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dir = dir
+}
+
+// Record fingerprints a finished tool call and remembers the first loop it reveals
+func (d *loopDetector) Record(call entity.ToolCall, result string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	rec := callRecord{
+		signature: callSignature(call),
+		result:    hashString(result),
+		display:   describeCall(call, err),
+	}
+	if err != nil {
+		rec.errorHash = hashString(err.Error() + "\n" + result)
+	}
+
+	d.recent = appendBounded(d.recent, rec, d.window)
+	d.log = appendBounded(d.log, rec, d.window)
+
+	if d.pending != "" {
+		return
+	}
+
+	if finding := d.detectRepeatedCall(rec); finding != "" {
+		d.pending = finding
+		return
+	}
+	if finding := d.detectRepeatedError(rec); finding != "" {
+		d.pending = finding
+		return
+	}
+	if err == nil {
+		d.pending = d.detectOscillation(call)
+	}
+}
+
+// Check consumes the pending finding and escalates: hint first, then replan, then stop
+func (d *loopDetector) Check() (loopAction, string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.pending == "" {
+		return loopContinue, ""
+	}
+
+	finding := d.pending
+	d.pending = ""
+	d.recent = nil
+	d.files = make(map[string][]string)
+	d.findings = append(d.findings, finding)
+
+	switch len(d.findings) {
+	case 1:
+		return loopHint, finding
+	case 2:
+		return loopReplan, finding
+	default:
+		return loopStop, finding
+	}
+}
+
+// Diagnostic summarizes what the agent was stuck on
+func (d *loopDetector) Diagnostic() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var b strings.Builder
+
+	b.WriteString("Loops detected:\n")
+	for i, finding := range d.findings {
+		b.WriteString(fmt.Sprintf("%d. %s\n", i+1, finding))
+	}
+
+	if len(d.log) > 0 {
+		b.WriteString("Last tool calls:\n")
+		for _, rec := range d.log {
+			b.WriteString("- " + rec.display + "\n")
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// detectRepeatedCall finds the same call returning the same result over and over
+func (d *loopDetector) detectRepeatedCall(rec callRecord) string {
+	count := 0
+	for _, r := range d.recent {
+		if r.signature == rec.signature && r.result == rec.result && r.errorHash == rec.errorHash {
+			count++
+		}
+	}
+
+	if count < d.threshold {
+		return ""
+	}
+
+	return fmt.Sprintf("the same call was repeated %d times with the same result: %s", count, rec.display)
+}
+
+// detectRepeatedError finds failing calls that keep producing the same error output
+func (d *loopDetector) detectRepeatedError(rec callRecord) string {
+	if rec.errorHash == "" {
+		return ""
+	}
+
+	count := 0
+	for _, r := range d.recent {
+		if r.errorHash == rec.errorHash {
+			count++
+		}
+	}
+
+	if count < d.threshold {
+		return ""
+	}
+
+	return fmt.Sprintf("%d calls failed with identical error output: %s", count, rec.display)
+}
+
+// detectOscillation finds edits that bring a file back to a state it already had
+func (d *loopDetector) detectOscillation(call entity.ToolCall) string {
+	if !isFileOperation(call.Name) || call.Name == "read_file" {
+		return ""
+	}
+
+	path := getFilePathFromArgs(call.Args)
+	if path == "" {
+		return ""
+	}
+
+	content, err := os.ReadFile(inDir(d.dir, path))
+	if err != nil {
+		return ""
+	}
+
+	hash := hashString(string(content))
+	states := d.files[path]
+	d.files[path] = appendBounded(states, hash, d.window)
+
+	// the previous state is excluded: rewriting identical content is caught as a repeated call
+	for i := 0; i < len(states)-1; i++ {
+		if states[i] == hash {
+			return fmt.Sprintf("edits to %s oscillate: the file was reverted to a previous version", path)
+		}
+	}
+
+	return ""
+}
+
+// callSignature identifies a call by tool name and arguments; json sorts map keys
+func callSignature(call entity.ToolCall) string {
+	args, err := json.Marshal(call.Args)
+	if err != nil {
+		return call.Name
+	}
+
+	return call.Name + " " + string(args)
+}
+
+// describeCall renders a call for findings and diagnostics
+func describeCall(call entity.ToolCall, err error) string {
+	desc := getToolDisplayName(call.Name, call.Args)
+	if len(desc) > 120 {
+		desc = desc[:120] + "..."
+	}
+
+	if err != nil {
+		msg := err.Error()
+		if len(msg) > 120 {
+			msg = msg[:120] + "..."
+		}
+		desc += fmt.Sprintf(" (error: %s)", msg)
+	}
+
+	return desc
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
+
+func appendBounded[T any](values []T, value T, limit int) []T {
+	values = append(values, value)
+	if len(values) > limit {
+		values = values[len(values)-limit:]
+	}
+	return values
+}
+
+// loopHintMessage tells the model which loop it is in and how to get out
+func loopHintMessage(finding string) string {
+	return fmt.Sprintf("You appear to be stuck in a loop: %s.\n\n"+
+		"Repeating the same action will not give a different result. Stop and reconsider:\n"+
+		"- re-read the error output and identify its actual cause\n"+
+		"- use the information you already have instead of fetching it again\n"+
+		"- try a different approach or tool\n"+
+		"If the goal cannot be achieved, explain why and call attempt_completion.", finding)
+}
+
+// checkLoops escalates a detected loop: a corrective hint, then errLoopReplan, then ErrStalled
+func (t *Task) checkLoops() error {
+	if !t.config.EnableLoopDetection {
+		return nil
+	}
+
+	action, finding := t.loops.Check()
+
+	switch action {
+	case loopHint:
+		t.warn("Loop detected: " + finding)
+		t.addUserMessage(loopHintMessage(finding))
+	case loopReplan:
+		t.warn("Loop persists after a hint: " + finding)
+		return fmt.Errorf("%w: %s", errLoopReplan, finding)
+	case loopStop:
+		return t.stalled()
+	}
+
+	return nil
+}
+
+// stalled builds the final error with a diagnostic summary of the loops
+func (t *Task) stalled() error {
+	return fmt.Errorf("%w, giving up\n%s", ErrStalled, t.loops.Diagnostic())
+}
+
+// replanStalled decomposes the original task again, asking for an approach that avoids the loop
+func (t *Task) replanStalled(loopErr error) error {
+	t.mu.RLock()
+	originalTask := t.originalTask
+	t.mu.RUnlock()
+
+	if originalTask == "" {
+		return t.stalled()
+	}
+
+	t.info("Replanning the task...")
+
+	ctx, cancel := context.WithTimeout(t.ctx, 10*time.Minute)
+	defer cancel()
+
+	stopThinking := t.startThinking("")
+	decomposer := decomposition.NewTaskDecomposerWithClient(t.client)
+	plan, err := decomposer.DecomposeTask(ctx, fmt.Sprintf(
+		"%s\n\nA previous attempt got stuck (%v). Plan an approach that avoids it.", originalTask, loopErr))
+	stopThinking()
+
+	if err != nil {
+		return fmt.Errorf("%w (replanning failed: %v)", t.stalled(), err)
+	}
+	plan.OriginalTask = originalTask
+
+	state := tools.GetTaskState()
+	state.SetContext("has_decomposed_task", true)
+	state.SetContext("decomposed_task", plan)
+
+	t.addUserMessage(fmt.Sprintf("You were stuck: %v.\n\nThe task has been replanned and will continue step by step.", loopErr))
+
+	return t.executeDecomposedTasks()
+}
@@ -0,0 +1,96 @@
+package task
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadiminshakov/autonomy/core/entity"
+)
+
+func readCall(path string) entity.ToolCall {
+	return entity.ToolCall{Name: "read_file", Args: map[string]any{"path": path}}
+}
+
+func TestLoopDetectorRepeatedCalls(t *testing.T) {
+	d := newLoopDetector(10, 3)
+
+	d.Record(readCall("a.go"), "content", nil)
+	d.Record(readCall("a.go"), "content", nil)
+	action, _ := d.Check()
+	require.Equal(t, loopContinue, action)
+
+	d.Record(readCall("a.go"), "content", nil)
+	action, finding := d.Check()
+	require.Equal(t, loopHint, action)
+	require.Contains(t, finding, "repeated 3 times")
+
+	// the finding is consumed and the window starts over
+	action, _ = d.Check()
+	require.Equal(t, loopContinue, action)
+}
+
+func TestLoopDetectorIgnoresChangedResults(t *testing.T) {
+	d := newLoopDetector(10, 3)
+
+	d.Record(readCall("a.go"), "v1", nil)
+	d.Record(readCall("a.go"), "v2", nil)
+	d.Record(readCall("a.go"), "v3", nil)
+
+	action, _ := d.Check()
+	require.Equal(t, loopContinue, action)
+}
+
+func TestLoopDetectorRepeatedErrors(t *testing.T) {
+	d := newLoopDetector(10, 3)
+	err := errors.New("exit status 1")
+
+	for _, cmd := range []string{"go build", "go build ./...", "go build ."} {
+		d.Record(entity.ToolCall{Name: "bash", Args: map[string]any{"command": cmd}}, "undefined: foo", err)
+	}
+
+	action, finding := d.Check()
+	require.Equal(t, loopHint, action)
+	require.Contains(t, finding, "identical error output")
+}
+
+func TestLoopDetectorOscillatingEdits(t *testing.T) {
+	d := newLoopDetector(10, 3)
+	path := filepath.Join(t.TempDir(), "main.go")
+
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		d.Record(entity.ToolCall{Name: "write_file", Args: map[string]any{"path": path, "content": content}}, "ok", nil)
+	}
+
+	write("a")
+	write("b")
+	action, _ := d.Check()
+	require.Equal(t, loopContinue, action)
+
+	write("a")
+	action, finding := d.Check()
+	require.Equal(t, loopHint, action)
+	require.Contains(t, finding, "oscillate")
+}
+
+func TestLoopDetectorEscalation(t *testing.T) {
+	d := newLoopDetector(10, 2)
+
+	expected := []loopAction{loopHint, loopReplan, loopStop}
+	for _, want := range expected {
+		d.Record(readCall("a.go"), "same", nil)
+		d.Record(readCall("a.go"), "same", nil)
+
+		action, _ := d.Check()
+		require.Equal(t, want, action)
+	}
+
+	diagnostic := d.Diagnostic()
+	require.Contains(t, diagnostic, "Loops detected:")
+	require.Contains(t, diagnostic, "3. ")
+	require.Contains(t, diagnostic, "read_file: a.go")
+}
@@ -0,0 +1,37 @@
+package task
+
+import (
+	"fmt"
+
+	"github.com/vadiminshakov/autonomy/core/memory"
+)
+
+// maxPromptMemories caps how many memories are added to the prompt at task start
+const maxPromptMemories = 8
+
+// loadMemories adds the memories relevant to the task to the system prompt
+func (t *Task) loadMemories() {
+	wd, err := t.workDir()
+	if err != nil {
+		return
+	}
+
+	t.mu.RLock()
+	query := t.originalTask
+	t.mu.RUnlock()
+
+	entries, err := memory.Open(wd).Recall(query, maxPromptMemories)
+	if err != nil {
+		t.warn(fmt.Sprintf("Memories unavailable: %v", err))
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	t.promptData.SystemPrompt += "\n\nMEMORIES FROM PREVIOUS TASKS:\n" +
+		"These may be outdated; verify before relying on them and use forget to drop wrong ones.\n" +
+		memory.Format(entries)
+	t.mu.Unlock()
+}
@@ -0,0 +1,43 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vadiminshakov/autonomy/core/policy"
+)
+
+// loadPolicy reads the command policy of the current project; it is reloaded on every run
+func (t *Task) loadPolicy() {
+	wd, err := t.workDir()
+	if err != nil {
+		return
+	}
+
+	p, err := policy.Load(wd)
+	switch {
+	case errors.Is(err, policy.ErrUntrusted):
+		t.warn(fmt.Sprintf("Project command policy ignored: %v; run `autonomy trust` in the project to apply it", err))
+	case err != nil:
+		// a broken policy file must not silently allow everything it was meant to restrict
+		t.warn(fmt.Sprintf("Command policy invalid, commands need approval: %v", err))
+		p = policy.AskAll()
+	}
+
+	t.mu.Lock()
+	t.policy = p
+	t.mu.Unlock()
+}
+
+// withPolicy attaches the command policy to the context of tools and hooks
+func (t *Task) withPolicy(ctx context.Context) context.Context {
+	t.mu.RLock()
+	p := t.policy
+	t.mu.RUnlock()
+
+	if p == nil {
+		return ctx
+	}
+	return policy.WithPolicy(ctx, p)
+}
@@ -2,6 +2,8 @@
 package task
 
 import (
+	"os"
+
 	"github.com/vadiminshakov/autonomy/core/entity"
 	"github.com/vadiminshakov/autonomy/core/tools"
 )
@@ -123,11 +125,11 @@ A step is complete when:
 - Implementation follows best practices
 - Tests pass (if applicable)
 
-Signal completion explicitly with phrases like:
-- "Step completed successfully"
-- "Task objective achieved" 
-- "Implementation finished"
-- "Step is complete"
+Signal completion ONLY by calling attempt_completion with:
+- summary: what was accomplished
+- files_changed: paths of files created or modified (empty for analysis-only steps)
+- verification: evidence that the objective is met (commands run and their output, tests passed, files checked)
+Text replies such as "done" or "step completed" do NOT complete a step.
 
 CRITICAL COMPLETION RULES:
 - Use attempt_completion when sufficient information is gathered
@@ -144,10 +146,11 @@ TOOL USAGE EFFICIENCY:
 • Don't repeat operations if you already have the results
 
 FILE EDITING POLICY (MANDATORY):
-• Use lsp_edit for ANY modifications to existing files (insert/replace/delete). Batch multiple edits in one call when possible
+• Use str_replace for targeted changes to existing files: copy old_string exactly from read_file output, with enough context to be unique
+• Use lsp_edit for line-based edits of existing files (insert/replace/delete). Batch multiple edits in one call when possible
 • Use write_file ONLY to create NEW files or when explicitly instructed to FULLY REPLACE an entire file
-• Before choosing between lsp_edit vs write_file, verify file existence with read_file or find_files
-• If unsure whether a file exists, default to lsp_edit for safe, minimal changes
+• Before choosing between str_replace/lsp_edit vs write_file, verify file existence with read_file or find_files
+• If unsure whether a file exists, default to str_replace for safe, minimal changes
 • Never use write_file for partial edits; it overwrites the whole file
 
 EFFICIENCY OPTIMIZATION:
@@ -179,22 +182,23 @@ COMMUNICATION RULES:
 const forceToolsMessage = `You MUST use a tool. Your previous response had no tool calls.
 
 Based on the user's request, execute one of these tools:
-- For file operations: read_file, lsp_edit (for edits), write_file (new files or explicit full overwrite only)
+- For file operations: read_file, str_replace or lsp_edit (for edits), write_file (new files or explicit full overwrite only)
 - For searching: search_dir, find_files
 - For analysis: get_project_structure
 - For execution: bash (any shell commands)
 - For completion: attempt_completion
 
-Remember FILE EDITING POLICY: prefer lsp_edit for changes; write_file only for new files or full overwrite when explicitly asked.
+Remember FILE EDITING POLICY: prefer str_replace or lsp_edit for changes; write_file only for new files or explicit full overwrite.
 
 Choose the most appropriate tool for the task and execute it NOW.`
 
 func NewPromptData() *entity.PromptData {
 	// Get complete tool definitions with schemas from the common function
 	defs := tools.GetToolDescriptions()
+	wd, _ := os.Getwd()
 
 	return &entity.PromptData{
-		SystemPrompt: systemPrompt,
+		SystemPrompt: systemPromptFor(wd),
 		Messages:     []entity.Message{},
 		Tools:        defs,
 	}
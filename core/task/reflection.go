@@ -0,0 +1,132 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+const reflectionPrompt = `You are a senior engineer reviewing whether an AI coding agent really finished the user's request.
+You receive the original request, the agent's completion summary, the diff of changed files and validation results.
+
+Check that:
+- every part of the request is addressed, not just the easy parts
+- the changes actually implement what the summary claims
+- nothing is left half-done (TODOs, stubs, missing wiring, unhandled errors)
+- validation results show no remaining errors
+
+Do not ask for extras the user did not request.
+
+Respond with JSON only:
+{"complete": true|false, "gaps": ["concrete, actionable gap", ...]}`
+
+const (
+	maxReflectionDiffChars = 12000
+	maxNewFileLines        = 200
+)
+
+// reflect critiques a completion attempt against the original request.
+// A non-empty result lists the gaps that block completion.
+func (t *Task) reflect(summary string) string {
+	t.mu.RLock()
+	originalTask := t.originalTask
+	validation := t.lastValidation
+	t.mu.RUnlock()
+
+	if !t.config.EnableReflection || originalTask == "" {
+		return ""
+	}
+
+	t.mu.Lock()
+	t.reflectionRounds++
+	rounds := t.reflectionRounds
+	t.mu.Unlock()
+
+	if rounds > t.config.MaxReflectionRounds {
+		return ""
+	}
+
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf("Original request:\n%s\n\n", originalTask))
+
+	if summary != "" {
+		msg.WriteString(fmt.Sprintf("Agent's completion summary:\n%s\n\n", summary))
+	}
+
+	changedFiles := tools.GetTaskState().ChangedFiles()
+	if len(changedFiles) == 0 {
+		msg.WriteString("Changed files: none\n\n")
+	} else {
+		dir, _ := t.workDir()
+		msg.WriteString(fmt.Sprintf("Diff of changed files:\n%s\n\n", collectDiff(t.ctx, dir, changedFiles)))
+	}
+
+	if len(validation) > 0 {
+		msg.WriteString(tools.FormatValidationResults(validation))
+	} else {
+		msg.WriteString("Validation results: none")
+	}
+
+	v, err := t.askVerdict(reflectionPrompt, msg.String())
+	if err != nil {
+		t.warn(fmt.Sprintf("Reflection skipped: %v", err))
+		return ""
+	}
+
+	if v.Complete {
+		return ""
+	}
+
+	t.warn(fmt.Sprintf("Reflection found gaps (round %d/%d)", rounds, t.config.MaxReflectionRounds))
+
+	return fmt.Sprintf("completion blocked: a review of your work against the original request found gaps:\n%s\n\n"+
+		"Address them, then call attempt_completion again.", formatGaps(v.Gaps))
+}
+
+// collectDiff returns a git diff for tracked files and the head of untracked ones, bounded in size.
+// Relative paths are taken from dir.
+func collectDiff(ctx context.Context, dir string, files []string) string {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var diff strings.Builder
+
+	for _, file := range files {
+		if diff.Len() >= maxReflectionDiffChars {
+			diff.WriteString("\n... [diff truncated]")
+			break
+		}
+
+		cmd := exec.CommandContext(ctx, "git", "diff", "--no-color", "HEAD", "--", file)
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err == nil && len(out) > 0 {
+			diff.Write(out)
+			continue
+		}
+
+		content, err := os.ReadFile(inDir(dir, file))
+		if err != nil {
+			diff.WriteString(fmt.Sprintf("--- %s: unreadable (%v)\n", file, err))
+			continue
+		}
+
+		lines := strings.Split(string(content), "\n")
+		if len(lines) > maxNewFileLines {
+			lines = append(lines[:maxNewFileLines], "... [truncated]")
+		}
+		diff.WriteString(fmt.Sprintf("+++ new file %s\n%s\n", file, strings.Join(lines, "\n")))
+	}
+
+	result := diff.String()
+	if len(result) > maxReflectionDiffChars {
+		result = result[:maxReflectionDiffChars] + "\n... [diff truncated]"
+	}
+
+	return result
+}
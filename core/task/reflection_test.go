@@ -0,0 +1,62 @@
+package task
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadiminshakov/autonomy/core/decomposition"
+	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+func TestCollectDiffUntrackedFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "new.go")
+	require.NoError(t, os.WriteFile(file, []byte("package main\n"), 0o644))
+
+	diff := collectDiff(context.Background(), "", []string{file})
+	require.Contains(t, diff, "+++ new file "+file)
+	require.Contains(t, diff, "package main")
+
+	// relative paths are read from the project of the task
+	diff = collectDiff(context.Background(), filepath.Dir(file), []string{"new.go"})
+	require.Contains(t, diff, "+++ new file new.go\npackage main")
+
+	missing := collectDiff(context.Background(), "", []string{filepath.Join(t.TempDir(), "missing.go")})
+	require.Contains(t, missing, "unreadable")
+}
+
+func TestCollectDiffTruncatesLargeFiles(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "big.txt")
+	require.NoError(t, os.WriteFile(file, []byte(strings.Repeat("line\n", maxNewFileLines*2)), 0o644))
+
+	diff := collectDiff(context.Background(), "", []string{file})
+	require.Contains(t, diff, "... [truncated]")
+	require.LessOrEqual(t, strings.Count(diff, "line\n"), maxNewFileLines)
+}
+
+func TestPlanSummary(t *testing.T) {
+	summary := planSummary([]decomposition.TaskStep{{Description: "add parser"}, {Description: "write tests"}})
+	require.Equal(t, "Completed plan steps:\n1. add parser\n2. write tests\n", summary)
+}
+
+func TestRefusedCompletionSkipsChecks(t *testing.T) {
+	state := tools.GetTaskState()
+	state.Reset()
+	t.Cleanup(state.Reset)
+
+	cfg := DefaultConfig()
+	cfg.EnableReflection = true
+
+	tsk := NewTaskWithConfig(&scriptedClient{}, cfg)
+	tsk.SetOriginalTask("fix the parser")
+
+	// attempt_completion fails on its own after a failed tool, so no reflection round is used up
+	state.RecordToolUse("bash", false, "exit status 1")
+	require.Empty(t, tsk.checkCompletion(entity.ToolCall{ID: "1", Name: "attempt_completion", Args: map[string]any{"summary": "done"}}))
+	require.Zero(t, tsk.reflectionRounds)
+}
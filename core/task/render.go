@@ -0,0 +1,145 @@
+package task
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/vadiminshakov/autonomy/core/events"
+	"github.com/vadiminshakov/autonomy/ui"
+)
+
+// terminalRenderer prints execution events for a human at the terminal.
+// Task banners are left to the REPL and headless loops.
+type terminalRenderer struct {
+	out io.Writer
+	// spinners are only drawn on stdout, where the user watches the task
+	spinners bool
+
+	mu       sync.Mutex
+	spinner  *ui.Spinner
+	streamed map[string]bool
+}
+
+// NewTerminalRenderer returns the sink that renders events as colored terminal output
+func NewTerminalRenderer() events.Sink {
+	return &terminalRenderer{out: os.Stdout, spinners: true, streamed: make(map[string]bool)}
+}
+
+// NewTerminalRendererTo renders events like NewTerminalRenderer, but writes them to out and draws no spinners
+func NewTerminalRendererTo(out io.Writer) events.Sink {
+	return &terminalRenderer{out: out, streamed: make(map[string]bool)}
+}
+
+func (r *terminalRenderer) Handle(e events.Event) {
+	switch e.Type {
+	case events.ThinkingStarted:
+		r.startSpinner(e.Text)
+
+	case events.ThinkingFinished:
+		r.stopSpinner()
+
+	case events.AssistantDelta:
+		if reasoning := extractReasoning(e.Text); reasoning != "" {
+			fmt.Fprint(r.out, formatReasoning(reasoning))
+		}
+
+	case events.ToolOutput:
+		r.mu.Lock()
+		r.streamed[e.Tool.ID] = true
+		r.mu.Unlock()
+		fmt.Fprint(r.out, e.Text)
+
+	case events.ToolCallFinished:
+		r.mu.Lock()
+		streamed := r.streamed[e.Tool.ID]
+		delete(r.streamed, e.Tool.ID)
+		r.mu.Unlock()
+		r.renderToolResult(e.Tool, streamed)
+
+	case events.PlanCreated:
+		if e.Plan.Diff != "" {
+			fmt.Fprintln(r.out, ui.Info("Revised plan:"))
+			fmt.Fprint(r.out, e.Plan.Diff)
+		}
+
+	case events.Notice:
+		if e.Level == events.LevelWarning {
+			fmt.Fprintln(r.out, ui.Warning(e.Text))
+		} else {
+			fmt.Fprintln(r.out, ui.Info(e.Text))
+		}
+	}
+}
+
+func (r *terminalRenderer) startSpinner(message string) {
+	if !r.spinners {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.spinner != nil {
+		r.spinner.Stop()
+	}
+
+	if message == "" {
+		r.spinner = ui.ShowThinking()
+	} else {
+		r.spinner = ui.ShowProcessing(message)
+	}
+}
+
+func (r *terminalRenderer) stopSpinner() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.spinner != nil {
+		r.spinner.Stop()
+		r.spinner = nil
+	}
+}
+
+// renderToolResult prints a finished tool call; output already streamed to the terminal is not repeated
+func (r *terminalRenderer) renderToolResult(call *events.ToolCall, streamed bool) {
+	if call.Error != "" {
+		fmt.Fprintln(r.out, ui.Error(fmt.Sprintf("Error running %s: %s", call.Name, call.Error)))
+		return
+	}
+
+	result := call.Result
+
+	if isSilentTool(call.Name) {
+		summary := silentToolSummary(call.Name, call.Args, result)
+		fmt.Fprintln(r.out, ui.Success("✓ "+call.Name)+summary)
+		return
+	}
+
+	fmt.Fprintln(r.out, ui.Success("✓ "+getToolDisplayName(call.Name, call.Args)))
+
+	if result == "" {
+		return
+	}
+
+	switch {
+	case call.Name == "attempt_completion":
+		fmt.Fprintln(r.out, ui.Info(result))
+	case isFileOperation(call.Name):
+		// show file path instead of content for file operations
+		if filepath := getFilePathFromArgs(call.Args); filepath != "" {
+			fmt.Fprintln(r.out, ui.Info(fmt.Sprintf("File: %s", filepath)))
+		}
+	case call.Name == "bash":
+		// show command and limited output for bash
+		if cmd := getBashCommand(call.Args); cmd != "" {
+			fmt.Fprintln(r.out, ui.Info(fmt.Sprintf("Command: %s", cmd)))
+		}
+		if !streamed {
+			fmt.Fprintln(r.out, limitToolOutputForTool(call.Name, result))
+		}
+	default:
+		fmt.Fprintln(r.out, limitToolOutputForTool(call.Name, result))
+	}
+}
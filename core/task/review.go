@@ -0,0 +1,121 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vadiminshakov/autonomy/core/decomposition"
+)
+
+// PlanAction is the reviewer's verdict on a proposed plan
+type PlanAction int
+
+const (
+	// PlanAccept runs the plan as proposed
+	PlanAccept PlanAction = iota
+	// PlanReject discards the plan; non-empty feedback triggers a new decomposition
+	PlanReject
+	// PlanEdit replaces the plan with a user-edited version
+	PlanEdit
+)
+
+// PlanDecision is the outcome of a plan review
+type PlanDecision struct {
+	Action   PlanAction
+	Feedback string
+	Plan     *decomposition.DecompositionResult
+}
+
+// PlanReviewer approves, rejects or edits a plan before it is executed
+type PlanReviewer interface {
+	ReviewPlan(plan *decomposition.DecompositionResult) (PlanDecision, error)
+}
+
+// planGenerator produces a new plan after the reviewer rejected one with feedback
+type planGenerator func(rejected *decomposition.DecompositionResult, feedback string) (*decomposition.DecompositionResult, error)
+
+// ErrPlanRejected is returned when the user rejects a plan without asking for a new one
+var ErrPlanRejected = errors.New("plan rejected by user")
+
+// SetPlanReviewer installs the approval gate used after decomposition.
+// Without a reviewer plans are executed immediately.
+func (t *Task) SetPlanReviewer(reviewer PlanReviewer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.planReviewer = reviewer
+}
+
+// reviewPlan runs the approval gate until the reviewer accepts a plan.
+// regenerate produces a new plan from the reviewer's feedback.
+func (t *Task) reviewPlan(
+	plan *decomposition.DecompositionResult,
+	regenerate planGenerator,
+) (*decomposition.DecompositionResult, error) {
+	t.mu.RLock()
+	reviewer := t.planReviewer
+	t.mu.RUnlock()
+
+	if reviewer == nil {
+		return plan, nil
+	}
+
+	// only rejections that ask for a new plan count as revisions, the user's own edits do not
+	revisions := 0
+	for {
+		if err := t.checkCancellation(); err != nil {
+			return nil, err
+		}
+
+		decision, err := reviewer.ReviewPlan(plan)
+		if err != nil {
+			return nil, fmt.Errorf("plan review failed: %w", err)
+		}
+
+		switch decision.Action {
+		case PlanAccept:
+			return plan, nil
+
+		case PlanEdit:
+			if decision.Plan == nil || len(decision.Plan.Steps) == 0 {
+				return nil, fmt.Errorf("edited plan contains no steps")
+			}
+			plan = decision.Plan
+
+		case PlanReject:
+			if decision.Feedback == "" {
+				return nil, ErrPlanRejected
+			}
+
+			if revisions >= t.config.MaxPlanRevisions {
+				return nil, fmt.Errorf("plan rejected %d times, giving up", revisions+1)
+			}
+			revisions++
+
+			revised, err := regenerate(plan, decision.Feedback)
+			if err != nil {
+				return nil, fmt.Errorf("failed to revise plan: %v", err)
+			}
+			plan = revised
+
+		default:
+			return nil, fmt.Errorf("unknown plan review action %d", decision.Action)
+		}
+	}
+}
+
+// redecompose builds a new plan for the original task that takes the reviewer's feedback into account
+func (t *Task) redecompose(
+	rejected *decomposition.DecompositionResult,
+	feedback string,
+) (*decomposition.DecompositionResult, error) {
+	ctx, cancel := context.WithTimeout(t.ctx, 10*time.Minute)
+	defer cancel()
+
+	defer t.startThinking("")()
+
+	decomposer := decomposition.NewTaskDecomposerWithClient(t.client)
+
+	return decomposer.DecomposeTaskWithFeedback(ctx, rejected.OriginalTask, rejected, feedback)
+}
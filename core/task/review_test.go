@@ -0,0 +1,98 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadiminshakov/autonomy/core/decomposition"
+)
+
+type scriptedReviewer struct {
+	decisions []PlanDecision
+	reviewed  []*decomposition.DecompositionResult
+}
+
+func (r *scriptedReviewer) ReviewPlan(plan *decomposition.DecompositionResult) (PlanDecision, error) {
+	r.reviewed = append(r.reviewed, plan)
+	decision := r.decisions[0]
+	r.decisions = r.decisions[1:]
+	return decision, nil
+}
+
+func testPlan(descriptions ...string) *decomposition.DecompositionResult {
+	plan := &decomposition.DecompositionResult{OriginalTask: "task"}
+	for _, d := range descriptions {
+		plan.Steps = append(plan.Steps, decomposition.TaskStep{Description: d})
+	}
+	return plan
+}
+
+func TestReviewPlanWithoutReviewer(t *testing.T) {
+	tsk := NewTask(nil)
+	plan := testPlan("a")
+
+	reviewed, err := tsk.reviewPlan(plan, nil)
+	require.NoError(t, err)
+	require.Same(t, plan, reviewed)
+}
+
+func TestReviewPlanRejectEditAccept(t *testing.T) {
+	edited := testPlan("edited")
+	reviewer := &scriptedReviewer{decisions: []PlanDecision{
+		{Action: PlanReject, Feedback: "split the work"},
+		{Action: PlanEdit, Plan: edited},
+		{Action: PlanAccept},
+	}}
+
+	tsk := NewTask(nil)
+	tsk.SetPlanReviewer(reviewer)
+
+	var feedback string
+	regenerate := func(_ *decomposition.DecompositionResult, fb string) (*decomposition.DecompositionResult, error) {
+		feedback = fb
+		return testPlan("first", "second"), nil
+	}
+
+	result, err := tsk.reviewPlan(testPlan("original"), regenerate)
+	require.NoError(t, err)
+	require.Same(t, edited, result)
+	require.Equal(t, "split the work", feedback)
+	require.Len(t, reviewer.reviewed, 3)
+	require.Len(t, reviewer.reviewed[1].Steps, 2)
+}
+
+func TestReviewPlanRejectWithoutFeedback(t *testing.T) {
+	tsk := NewTask(nil)
+	tsk.SetPlanReviewer(&scriptedReviewer{decisions: []PlanDecision{{Action: PlanReject}}})
+
+	_, err := tsk.reviewPlan(testPlan("a"), nil)
+	require.ErrorIs(t, err, ErrPlanRejected)
+}
+
+func TestReviewPlanCountsOnlyRejections(t *testing.T) {
+	reviewer := &scriptedReviewer{decisions: []PlanDecision{
+		{Action: PlanEdit, Plan: testPlan("edited")},
+		{Action: PlanEdit, Plan: testPlan("edited again")},
+		{Action: PlanReject, Feedback: "smaller steps"},
+		{Action: PlanEdit, Plan: testPlan("edited once more")},
+		{Action: PlanReject, Feedback: "still too big"},
+	}}
+
+	config := DefaultConfig()
+	config.MaxPlanRevisions = 1
+	tsk := NewTaskWithConfig(nil, config)
+	tsk.SetPlanReviewer(reviewer)
+
+	regenerated := 0
+	regenerate := func(*decomposition.DecompositionResult, string) (*decomposition.DecompositionResult, error) {
+		regenerated++
+		return testPlan("revised"), nil
+	}
+
+	// edits are free, the second rejection exceeds the single allowed revision
+	_, err := tsk.reviewPlan(testPlan("original"), regenerate)
+	require.EqualError(t, err, "plan rejected 2 times, giving up")
+	require.Equal(t, 1, regenerated)
+	require.Len(t, reviewer.reviewed, 5)
+}
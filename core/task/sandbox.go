@@ -0,0 +1,44 @@
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vadiminshakov/autonomy/core/config"
+	"github.com/vadiminshakov/autonomy/core/sandbox"
+)
+
+// loadSandbox sets up the sandbox of shell commands when the config enables it
+func (t *Task) loadSandbox() {
+	var sb *sandbox.Sandbox
+
+	cfg, err := config.LoadConfigFile()
+	if err == nil && cfg.Sandbox.Enabled {
+		wd, err := t.workDir()
+		if err != nil {
+			return
+		}
+
+		sb = sandbox.New(cfg.Sandbox, wd)
+		if err := sb.Check(); err != nil {
+			// commands fail rather than run unconfined
+			t.warn(fmt.Sprintf("Sandbox unavailable, commands will not run: %v", err))
+		}
+	}
+
+	t.mu.Lock()
+	t.sandbox = sb
+	t.mu.Unlock()
+}
+
+// withSandbox attaches the sandbox to the context of tools
+func (t *Task) withSandbox(ctx context.Context) context.Context {
+	t.mu.RLock()
+	sb := t.sandbox
+	t.mu.RUnlock()
+
+	if sb == nil {
+		return ctx
+	}
+	return sandbox.WithSandbox(ctx, sb)
+}
@@ -0,0 +1,215 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadiminshakov/autonomy/core/decomposition"
+	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/events"
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+// scriptedClient returns canned responses in order
+type scriptedClient struct {
+	responses []*entity.AIResponse
+	prompts   []entity.PromptData
+}
+
+func (c *scriptedClient) GenerateCode(ctx context.Context, prompt entity.PromptData) (*entity.AIResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.prompts = append(c.prompts, prompt)
+
+	response := c.responses[0]
+	c.responses = c.responses[1:]
+	return response, nil
+}
+
+func TestCancelStopsRunningTool(t *testing.T) {
+	client := &scriptedClient{responses: []*entity.AIResponse{{
+		ToolCalls: []entity.ToolCall{
+			{ID: "1", Name: "bash", Args: map[string]any{"command": "sleep 30"}},
+			{ID: "2", Name: "bash", Args: map[string]any{"command": "echo never"}},
+		},
+	}}}
+
+	tsk := NewTask(client)
+	tsk.SetApprovalMode(ApprovalAuto)
+	tsk.AddUserMessage("run something slow")
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		tsk.Cancel()
+	}()
+
+	start := time.Now()
+	err := tsk.ProcessTask()
+	require.ErrorIs(t, err, ErrCanceled)
+	require.Less(t, time.Since(start), 10*time.Second)
+
+	// every tool call is answered, so the conversation can continue
+	answered := map[string]bool{}
+	for _, msg := range tsk.promptData.Messages {
+		if msg.ToolCallID != "" {
+			answered[msg.ToolCallID] = true
+		}
+	}
+	require.True(t, answered["1"])
+	require.True(t, answered["2"])
+
+	tsk.AddUserMessage("try a different approach")
+	last := tsk.promptData.Messages[len(tsk.promptData.Messages)-1]
+	require.Contains(t, last.Content, "interrupted")
+	require.Contains(t, last.Content, "try a different approach")
+}
+
+func TestQueuedMessagesAreDeliveredBeforeNextCall(t *testing.T) {
+	client := &scriptedClient{}
+	tsk := NewTask(client)
+	tsk.AddUserMessage("write a parser")
+
+	tsk.QueueMessage("use the standard library only")
+	require.Equal(t, []string{"use the standard library only"}, tsk.QueuedMessages())
+
+	client.responses = []*entity.AIResponse{{Content: "ok"}}
+	_, err := tsk.callAi()
+	require.NoError(t, err)
+	require.Empty(t, tsk.QueuedMessages())
+
+	messages := client.prompts[0].Messages
+	require.Equal(t, "use the standard library only", messages[len(messages)-1].Content)
+	require.Equal(t, "user", messages[len(messages)-1].Role)
+}
+
+func TestProcessTaskEvents(t *testing.T) {
+	tools.GetTaskState().Reset()
+
+	client := &scriptedClient{responses: []*entity.AIResponse{{
+		Content: "Let me finish the task",
+		ToolCalls: []entity.ToolCall{
+			{ID: "1", Name: "attempt_completion", Args: map[string]any{"summary": "done"}},
+		},
+		Usage: entity.Usage{InputTokens: 10, OutputTokens: 5},
+	}}}
+
+	recorder := events.NewRecorder()
+	tsk := NewTask(client)
+	tsk.SetEventBus(events.NewBus(recorder))
+	tsk.AddUserMessage("finish")
+
+	require.NoError(t, tsk.ProcessTask())
+
+	require.Equal(t, []events.Type{
+		events.TaskStarted,
+		events.ThinkingStarted,
+		events.ThinkingFinished,
+		events.Usage,
+		events.AssistantDelta,
+		events.ToolCallStarted,
+		events.ToolCallFinished,
+		events.TaskCompleted,
+	}, recorder.Types())
+
+	finished := recorder.OfType(events.ToolCallFinished)[0]
+	require.Equal(t, "attempt_completion", finished.Tool.Name)
+	require.Empty(t, finished.Tool.Error)
+
+	usage := recorder.OfType(events.Usage)[0].Usage
+	require.Equal(t, 10, usage.TotalInputTokens)
+	require.Equal(t, "done", recorder.OfType(events.TaskCompleted)[0].Text)
+}
+
+func TestIterationBudget(t *testing.T) {
+	tools.GetTaskState().Reset()
+
+	cfg := DefaultConfig()
+	cfg.MaxIterations = 2
+	cfg.MinAPIInterval = 0
+	cfg.EnableLoopDetection = false
+
+	client := &scriptedClient{}
+	for i := 0; i < cfg.MaxIterations; i++ {
+		client.responses = append(client.responses, &entity.AIResponse{ToolCalls: []entity.ToolCall{
+			{ID: "1", Name: "get_task_state", Args: map[string]any{}},
+		}})
+	}
+
+	tsk := NewTaskWithConfig(client, cfg)
+	tsk.SetEventBus(events.NewBus())
+	tsk.AddUserMessage("never finish")
+
+	require.ErrorIs(t, tsk.ProcessTask(), ErrBudgetExceeded)
+}
+
+func TestShellOutlivesRuns(t *testing.T) {
+	tools.GetTaskState().Reset()
+
+	cfg := DefaultConfig()
+	cfg.EnableReflection = false
+	client := &scriptedClient{responses: []*entity.AIResponse{
+		{ToolCalls: []entity.ToolCall{{ID: "1", Name: "bash", Args: map[string]any{"command": "export GREETING=hello"}}}},
+		{ToolCalls: []entity.ToolCall{{ID: "2", Name: "attempt_completion", Args: map[string]any{"summary": "exported"}}}},
+	}}
+
+	tsk := NewTaskWithConfig(client, cfg)
+	tsk.SetApprovalMode(ApprovalAuto)
+	t.Cleanup(tsk.Close)
+	tsk.AddUserMessage("export a variable")
+	require.NoError(t, tsk.ProcessTask())
+
+	echo := entity.ToolCall{ID: "3", Name: "bash", Args: map[string]any{"command": "echo \"[$GREETING]\""}}
+
+	// the next run of the task continues in the same shell
+	result, err := tsk.exec(context.Background(), echo)
+	require.NoError(t, err)
+	require.Equal(t, "[hello]", result)
+
+	// another task has a shell of its own
+	other := NewTask(&scriptedClient{})
+	t.Cleanup(other.Close)
+	result, err = other.exec(context.Background(), echo)
+	require.NoError(t, err)
+	require.Equal(t, "[]", result)
+
+	// closing the task ends its shell
+	tsk.Close()
+	result, err = tsk.exec(context.Background(), echo)
+	require.NoError(t, err)
+	require.Equal(t, "[]", result)
+}
+
+func TestPlanStepRefusesDecomposition(t *testing.T) {
+	tools.GetTaskState().Reset()
+
+	cfg := DefaultConfig()
+	cfg.MinAPIInterval = 0
+	cfg.EnableStepVerification = false
+	client := &scriptedClient{responses: []*entity.AIResponse{
+		{ToolCalls: []entity.ToolCall{{ID: "1", Name: "decompose_task", Args: map[string]any{"task_description": "split the step"}}}},
+		{ToolCalls: []entity.ToolCall{{ID: "2", Name: "attempt_completion", Args: map[string]any{
+			"summary": "parser added", "verification": "go test passed",
+		}}}},
+	}}
+
+	tsk := NewTaskWithConfig(client, cfg)
+	tsk.SetEventBus(events.NewBus())
+	require.NoError(t, tsk.executeTaskStep(decomposition.TaskStep{ID: "1", Description: "add a parser"}))
+
+	// the step completes and leaves no nested plan behind
+	require.Empty(t, client.responses)
+	require.False(t, hasDecomposedTask())
+
+	var refusal string
+	for _, msg := range tsk.promptData.Messages {
+		if msg.ToolCallID == "1" {
+			refusal = msg.Content
+		}
+	}
+	require.Contains(t, refusal, "a plan is already being executed")
+}
@@ -2,7 +2,10 @@ package task
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -10,32 +13,61 @@ import (
 	"github.com/vadiminshakov/autonomy/core/ai"
 	"github.com/vadiminshakov/autonomy/core/decomposition"
 	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/events"
+	"github.com/vadiminshakov/autonomy/core/hooks"
+	"github.com/vadiminshakov/autonomy/core/policy"
+	"github.com/vadiminshakov/autonomy/core/sandbox"
 	"github.com/vadiminshakov/autonomy/core/tools"
+	"github.com/vadiminshakov/autonomy/core/workspace"
 	"github.com/vadiminshakov/autonomy/ui"
 )
 
 // Config holds task execution configuration
 type Config struct {
-	MaxIterations        int
-	MaxHistorySize       int
-	AICallTimeout        time.Duration
-	ToolTimeout          time.Duration
-	MinAPIInterval       time.Duration
-	MaxNoToolAttempts    int
-	EnableReflection     bool
-	EnableFileValidation bool
+	MaxIterations          int
+	MaxHistorySize         int
+	AICallTimeout          time.Duration
+	ToolTimeout            time.Duration
+	ToolTimeouts           map[string]time.Duration
+	MinAPIInterval         time.Duration
+	MaxNoToolAttempts      int
+	MaxReplans             int
+	MaxPlanRevisions       int
+	MaxStepVerifications   int
+	EnableStepVerification bool
+	EnableReflection       bool
+	MaxReflectionRounds    int
+	EnableFileValidation   bool
+	MaxValidationRounds    int
+	ValidationTimeout      time.Duration
+	EnableLoopDetection    bool
+	LoopDetectionWindow    int
+	LoopRepeatThreshold    int
+	// ApprovalMode overrides the approval mode of the config file
+	ApprovalMode ApprovalMode
 }
 
-func defaultConfig() Config {
+// DefaultConfig returns the execution limits used by NewTask
+func DefaultConfig() Config {
 	return Config{
-		MaxIterations:        100,
-		MaxHistorySize:       100,
-		AICallTimeout:        300 * time.Second,
-		ToolTimeout:          30 * time.Second,
-		MinAPIInterval:       1 * time.Second,
-		MaxNoToolAttempts:    5,
-		EnableReflection:     true,
-		EnableFileValidation: true,
+		MaxIterations:          100,
+		MaxHistorySize:         100,
+		AICallTimeout:          300 * time.Second,
+		ToolTimeout:            30 * time.Second,
+		MinAPIInterval:         1 * time.Second,
+		MaxNoToolAttempts:      5,
+		MaxReplans:             2,
+		MaxPlanRevisions:       3,
+		MaxStepVerifications:   2,
+		EnableStepVerification: true,
+		EnableReflection:       true,
+		MaxReflectionRounds:    2,
+		EnableFileValidation:   true,
+		MaxValidationRounds:    3,
+		ValidationTimeout:      2 * time.Minute,
+		EnableLoopDetection:    true,
+		LoopDetectionWindow:    10,
+		LoopRepeatThreshold:    3,
 	}
 }
 
@@ -44,30 +76,58 @@ type Task struct {
 	client     ai.AIClient
 	promptData *entity.PromptData
 	config     Config
+	tools      *tools.Registry
 
 	mu          sync.RWMutex
 	ctx         context.Context
 	cancel      context.CancelFunc
+	interrupted bool
+	queued      []string
 	noToolCount int
 
 	originalTask string
+	planReviewer PlanReviewer
+
+	toolApprover     ToolApprover
+	approvalMode     ApprovalMode
+	sessionApprovals []approvalRule
+	projectApprovals []approvalRule
+
+	validationRounds int
+	reflectionRounds int
+	lastValidation   map[string][]*tools.ValidationResult
+
+	instructionsRoot string
+	instructionDirs  map[string]bool
+
+	dir string
+
+	loops     *loopDetector
+	processes *tools.Processes
+	hooks     *hooks.Manager
+	policy    *policy.Policy
+	sandbox   *sandbox.Sandbox
+	workspace *workspace.Workspace
+	events    *events.Bus
+	usage     entity.Usage
 }
 
 // NewTask creates a new task with default configuration
 func NewTask(client ai.AIClient) *Task {
-	return NewTaskWithConfig(client, defaultConfig())
+	return NewTaskWithConfig(client, DefaultConfig())
 }
 
 // NewTaskWithConfig creates a new task with custom configuration
 func NewTaskWithConfig(client ai.AIClient, config Config) *Task {
-	ctx, cancel := context.WithCancel(context.Background())
-
 	return &Task{
 		client:     client,
 		promptData: NewPromptData(),
 		config:     config,
-		ctx:        ctx,
-		cancel:     cancel,
+		tools:      tools.Default(),
+		ctx:        context.Background(),
+		loops:      newLoopDetector(config.LoopDetectionWindow, config.LoopRepeatThreshold),
+		processes:  tools.NewProcesses(),
+		events:     events.NewBus(NewTerminalRenderer()),
 	}
 }
 
@@ -78,54 +138,274 @@ func (t *Task) SetOriginalTask(task string) {
 	t.originalTask = task
 }
 
-// Close releases task resources
+// SetTools restricts the task to the tools of the registry
+func (t *Task) SetTools(registry *tools.Registry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tools = registry
+	t.promptData.Tools = registry.Definitions()
+}
+
+// SetDir makes the task work in the project in dir rather than in the working directory of the process
+func (t *Task) SetDir(dir string) {
+	if dir != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+	}
+	t.loops.setDir(dir)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dir = dir
+}
+
+// workDir returns the directory the task works in
+func (t *Task) workDir() (string, error) {
+	t.mu.RLock()
+	dir := t.dir
+	t.mu.RUnlock()
+
+	if dir != "" {
+		return dir, nil
+	}
+	return os.Getwd()
+}
+
+// inDir resolves a relative path against dir; an empty dir leaves it to the working directory of the process
+func inDir(dir, path string) string {
+	if dir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+var (
+	// ErrCanceled is returned by ProcessTask when the run was stopped with Cancel
+	ErrCanceled = errors.New("task canceled")
+	// ErrBudgetExceeded is returned when the task runs out of iterations
+	ErrBudgetExceeded = errors.New("iteration budget exceeded")
+)
+
+// Close cancels the task and stops its shell session and background processes,
+// which otherwise carry over from one run to the next
 func (t *Task) Close() {
-	if t.cancel != nil {
-		t.cancel()
+	t.Cancel()
+	t.processes.Close()
+}
+
+// Cancel stops the running ProcessTask call; tools and AI requests in flight are aborted.
+// The conversation is kept, so the task can be continued with a new user message.
+func (t *Task) Cancel() {
+	t.mu.RLock()
+	cancel := t.cancel
+	t.mu.RUnlock()
+
+	if cancel != nil {
+		cancel()
 	}
 }
 
 // ProcessTask executes the main task loop
 func (t *Task) ProcessTask() error {
-	defer t.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.mu.Lock()
+	t.ctx = ctx
+	t.cancel = cancel
+	originalTask := t.originalTask
+	t.mu.Unlock()
 
+	t.emit(events.Event{Type: events.TaskStarted, Text: originalTask})
+	t.loadHooks()
+	t.loadPolicy()
+	t.loadApprovals()
+	t.loadSandbox()
+	t.loadWorkspace()
+	t.loadToolTimeouts()
+	t.loadInstructions()
+	t.loadMemories()
+
+	err := t.run()
+	if err != nil && ctx.Err() != nil {
+		t.mu.Lock()
+		t.interrupted = true
+		t.mu.Unlock()
+		err = ErrCanceled
+	}
+
+	t.taskEndHooks(err)
+	t.emitResult(err)
+
+	return err
+}
+
+func (t *Task) run() error {
+	var err error
 	if hasDecomposedTask() {
-		return t.executeDecomposedTasks()
+		err = t.executeDecomposedTasks()
+	} else {
+		err = t.executeDirectTask()
 	}
 
-	return t.executeDirectTask()
+	// messages queued during the final turn still need an answer
+	for err == nil && t.hasQueuedMessages() {
+		err = t.executeDirectTask()
+	}
+
+	return err
 }
 
 // executeDecomposedTasks executes decomposed tasks
 func (t *Task) executeDecomposedTasks() error {
-	decomposedTask, err := getDecomposedTask()
+	plan, err := getDecomposedTask()
 	if err != nil {
 		return fmt.Errorf("failed to get decomposed task: %v", err)
 	}
 
 	clearDecomposedTask()
 
-	for i, step := range decomposedTask.Steps {
+	plan, err = t.reviewPlan(plan, t.redecompose)
+	if err != nil {
+		return err
+	}
+
+	t.emitPlan(plan, "")
+
+	var completed []decomposition.TaskStep
+	replans := 0
+
+	for i := 0; i < len(plan.Steps); i++ {
+		step := &plan.Steps[i]
 		step.Status = "in_progress"
+		t.emitStep(i, len(plan.Steps), *step, nil)
+
+		stepErr := t.executeTaskStep(*step)
+		if stepErr == nil {
+			step.Status = "completed"
+			t.emitStep(i, len(plan.Steps), *step, nil)
+			completed = append(completed, *step)
+			continue
+		}
+
+		step.Status = "failed"
+		t.emitStep(i, len(plan.Steps), *step, stepErr)
 
-		if err := t.executeTaskStep(step); err != nil {
-			step.Status = "failed"
-			return fmt.Errorf("step %d failed: %v", i+1, err)
+		if err := t.checkCancellation(); err != nil {
+			return err
+		}
+
+		if errors.Is(stepErr, ErrStalled) {
+			return stepErr
 		}
 
-		step.Status = "completed"
+		if replans >= t.config.MaxReplans {
+			if errors.Is(stepErr, errLoopReplan) {
+				return t.stalled()
+			}
+			return fmt.Errorf("step %d failed: %w", i+1, stepErr)
+		}
+		replans++
+
+		t.warn(fmt.Sprintf("Step %d failed: %v. Replanning (%d/%d)...", i+1, stepErr, replans, t.config.MaxReplans))
+
+		revised, err := t.replan(plan, completed, i, stepErr, "")
+		if err != nil {
+			return fmt.Errorf("step %d failed: %v (replanning failed: %v)", i+1, stepErr, err)
+		}
+
+		t.emitPlan(revised, decomposition.DiffPlans(plan.Steps[i:], revised.Steps))
+
+		failedIdx := i
+		replanWithFeedback := func(_ *decomposition.DecompositionResult, feedback string) (*decomposition.DecompositionResult, error) {
+			return t.replan(plan, completed, failedIdx, stepErr, feedback)
+		}
+
+		revised, err = t.reviewPlan(revised, replanWithFeedback)
+		if err != nil {
+			return err
+		}
+
+		t.emitPlan(revised, "")
+
+		t.addUserMessage(fmt.Sprintf("Step %q failed: %v\n\nThe remaining work has been replanned:\n%s",
+			step.Description, stepErr, revised.GetStepSummary()))
+
+		plan = revised
+		i = -1
+	}
+
+	if blocked := t.reflect(planSummary(completed)); blocked != "" {
+		t.addUserMessage(blocked)
+		return t.executeDirectTask()
 	}
 
 	return nil
 }
 
+// planSummary describes the completed steps of a plan for reflection
+func planSummary(completed []decomposition.TaskStep) string {
+	var summary strings.Builder
+
+	summary.WriteString("Completed plan steps:\n")
+	for i, step := range completed {
+		summary.WriteString(fmt.Sprintf("%d. %s\n", i+1, step.Description))
+	}
+
+	return summary.String()
+}
+
+// replan asks the decomposer for a revised plan covering the work left after a failed step
+func (t *Task) replan(
+	plan *decomposition.DecompositionResult,
+	completed []decomposition.TaskStep,
+	failedIdx int,
+	stepErr error,
+	feedback string,
+) (*decomposition.DecompositionResult, error) {
+	t.mu.RLock()
+	originalTask := t.originalTask
+	t.mu.RUnlock()
+
+	if originalTask == "" {
+		originalTask = plan.OriginalTask
+	}
+
+	req := decomposition.ReplanRequest{
+		OriginalTask:   originalTask,
+		CompletedSteps: completed,
+		FailedStep:     plan.Steps[failedIdx],
+		Failure:        stepErr.Error(),
+		RemainingSteps: plan.Steps[failedIdx+1:],
+		StateSummary:   tools.GetTaskState().Summary(),
+		Feedback:       feedback,
+	}
+
+	ctx, cancel := context.WithTimeout(t.ctx, 10*time.Minute)
+	defer cancel()
+
+	defer t.startThinking("")()
+
+	decomposer := decomposition.NewTaskDecomposerWithClient(t.client)
+
+	return decomposer.ReplanTask(ctx, req)
+}
+
 func (t *Task) executeTaskStep(step decomposition.TaskStep) error {
-	stepMessage := fmt.Sprintf("Execute this step: %s\n\nReason: %s", step.Description, step.Reason)
+	state := tools.GetTaskState()
+	state.SetContext("current_step", step.ID)
+	state.SetContext("completion_report", nil)
+	defer state.SetContext("current_step", "")
+
+	stepMessage := fmt.Sprintf("Execute this step: %s\n\nReason: %s\n\n"+
+		"When the step objective is achieved, call attempt_completion with summary, files_changed and verification evidence.",
+		step.Description, step.Reason)
 	t.addUserMessage(stepMessage)
 
-	maxStepIterations := 100
+	verifications := 0
 
-	for iter := 0; iter < maxStepIterations; iter++ {
+	for iter := 0; iter < t.config.MaxIterations; iter++ {
 		if err := t.checkCancellation(); err != nil {
 			return err
 		}
@@ -136,7 +416,7 @@ func (t *Task) executeTaskStep(step decomposition.TaskStep) error {
 		}
 
 		if len(response.ToolCalls) == 0 {
-			t.displayReasoning(response.Content)
+			t.emitAssistantText(response.Content)
 			t.addAssistantMessage(response.Content)
 			if shouldAbort := t.handleNoTools(); shouldAbort {
 				return fmt.Errorf("step execution timed out - no tools used")
@@ -153,43 +433,39 @@ func (t *Task) executeTaskStep(step decomposition.TaskStep) error {
 			return fmt.Errorf("tool execution failed: %v", err)
 		}
 
-		if completed {
-			return nil
+		if !completed {
+			if err := t.checkLoops(); err != nil {
+				return err
+			}
+			continue
 		}
 
-		if t.stepIsComplete(response.Content) {
+		if !t.config.EnableStepVerification || verifications >= t.config.MaxStepVerifications {
 			return nil
 		}
-	}
+		verifications++
 
-	return fmt.Errorf("step execution timed out after %d iterations", maxStepIterations)
-}
+		report, ok := tools.GetCompletionReport()
+		if !ok {
+			return nil
+		}
 
-func (t *Task) stepIsComplete(content string) bool {
-	content = strings.ToLower(content)
-	completionMarkers := []string{
-		"completed",
-		"done",
-		"success",
-		"task objective achieved",
-		"implementation finished",
-		"step is complete",
-		"step completed",
-		"task completed",
-		"done with this step",
-		"step is finished",
-		"moving to next step",
-		"this step is complete",
-		"objective achieved",
-	}
+		v, err := t.verifyStep(step, report)
+		if err != nil {
+			t.warn(fmt.Sprintf("Step verification skipped: %v", err))
+			return nil
+		}
 
-	for _, marker := range completionMarkers {
-		if strings.Contains(content, marker) {
-			return true
+		if v.Complete {
+			return nil
 		}
+
+		t.warn("Step verification found gaps, continuing the step")
+		t.addUserMessage(fmt.Sprintf("The step is NOT complete yet. A reviewer found these gaps:\n%s\n\n"+
+			"Address them, then call attempt_completion again with updated verification evidence.", formatGaps(v.Gaps)))
 	}
 
-	return false
+	return fmt.Errorf("%w: step execution timed out after %d iterations", ErrBudgetExceeded, t.config.MaxIterations)
 }
 
 func (t *Task) executeDirectTask() error {
@@ -204,7 +480,7 @@ func (t *Task) executeDirectTask() error {
 		}
 
 		if len(response.ToolCalls) == 0 {
-			t.displayReasoning(response.Content)
+			t.emitAssistantText(response.Content)
 			t.addAssistantMessage(response.Content)
 			if shouldAbort := t.handleNoTools(); shouldAbort {
 				return fmt.Errorf("task execution timed out")
@@ -212,7 +488,7 @@ func (t *Task) executeDirectTask() error {
 			continue
 		}
 
-		t.displayReasoning(response.Content)
+		t.emitAssistantText(response.Content)
 		t.promptData.AddAssistantMessageWithTools(response.Content, response.ToolCalls)
 		t.trimHistoryIfNeeded()
 		t.resetNoToolCount()
@@ -225,27 +501,43 @@ func (t *Task) executeDirectTask() error {
 		if completed {
 			return nil
 		}
+
+		if err := t.checkLoops(); err != nil {
+			if errors.Is(err, errLoopReplan) {
+				return t.replanStalled(err)
+			}
+			return err
+		}
+
+		// a plan was created by decompose_task, continue step by step
+		if hasDecomposedTask() {
+			return t.executeDecomposedTasks()
+		}
 	}
 
-	return fmt.Errorf("task execution timed out after %d iterations", t.config.MaxIterations)
+	return fmt.Errorf("%w: task execution timed out after %d iterations", ErrBudgetExceeded, t.config.MaxIterations)
 }
 
 func (t *Task) callAi() (*entity.AIResponse, error) {
 	ctx, cancel := context.WithTimeout(t.ctx, t.config.AICallTimeout)
 	defer cancel()
 
+	t.deliverQueuedMessages()
+
 	t.mu.RLock()
 	promptCopy := t.copyPromptData()
 	t.mu.RUnlock()
 
-	spinner := ui.ShowThinking()
-	defer spinner.Stop()
-
+	stopThinking := t.startThinking("")
 	response, err := t.client.GenerateCode(ctx, promptCopy)
+	stopThinking()
+
 	if err != nil {
 		return nil, err
 	}
 
+	t.emitUsage(response.Usage)
+
 	return response, nil
 }
 
@@ -253,17 +545,40 @@ func (t *Task) executeSequential(calls []entity.ToolCall) (bool, error) {
 	ctx, cancel := context.WithTimeout(t.ctx, 5*time.Minute)
 	defer cancel()
 
-	for _, call := range calls {
+	for i, call := range calls {
 		if err := t.checkContext(ctx); err != nil {
+			t.skipToolCalls(calls[i:], "skipped: "+err.Error())
 			return false, err
 		}
 
-		result, err := t.exec(ctx, call)
-		t.handleToolResult(call, result, err)
+		if call.Name == "attempt_completion" {
+			if blocked := t.checkCompletion(call); blocked != "" {
+				t.handleToolResult(call, blocked, fmt.Errorf("completion blocked"), 0)
+				continue
+			}
+		}
+
+		// a nested plan would be picked up only after the step, which could then never complete
+		if call.Name == "decompose_task" && isPlanStep() {
+			t.handleToolResult(call, "a plan is already being executed: carry out the current step directly "+
+				"and call attempt_completion when it is done", fmt.Errorf("decomposition refused"), 0)
+			continue
+		}
+
+		t.emit(events.Event{Type: events.ToolCallStarted, Tool: &events.ToolCall{ID: call.ID, Name: call.Name, Args: call.Args}})
 
-		// only complete on attempt_completion if we're executing direct task
-		// for decomposed tasks, attempt_completion should not stop execution
+		started := time.Now()
+		result, err := t.execWithHooks(ctx, call)
+		if err == nil {
+			result += t.nestedInstructions(call)
+		}
+		t.handleToolResult(call, result, err, time.Since(started))
+
+		// an accepted attempt_completion ends the direct task, or the step when a plan step runs
+		// (checkCompletion leaves steps to the step verifier). A plan that decompose_task created
+		// earlier in this turn is still pending, so it runs instead of the task ending here.
 		if call.Name == "attempt_completion" && err == nil && !hasDecomposedTask() {
+			t.skipToolCalls(calls[i+1:], "skipped: completion was already reported")
 			return true, nil
 		}
 	}
@@ -271,106 +586,72 @@ func (t *Task) executeSequential(calls []entity.ToolCall) (bool, error) {
 	return false, nil
 }
 
-func (t *Task) exec(ctx context.Context, call entity.ToolCall) (string, error) {
-	availableTools := tools.List()
-	toolExists := false
-	for _, tool := range availableTools {
-		if tool == call.Name {
-			toolExists = true
-			break
-		}
+// skipToolCalls answers tool calls that will not be executed, so the history stays well-formed
+func (t *Task) skipToolCalls(calls []entity.ToolCall, reason string) {
+	for _, call := range calls {
+		t.promptData.AddToolResponse(call.ID, reason)
 	}
+}
 
-	if !toolExists {
+func (t *Task) exec(ctx context.Context, call entity.ToolCall) (string, error) {
+	tool, ok := t.tools.Get(call.Name)
+	if !ok {
 		return "", fmt.Errorf("tool %s is not available", call.Name)
 	}
 
-	timeout := t.getToolTimeout(call.Name)
+	timeout := t.getToolTimeout(tool)
 	toolCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	resultChan := make(chan struct {
-		res string
-		err error
-	}, 1)
+	toolCtx = tools.WithProcesses(toolCtx, t.processes)
+	toolCtx = tools.WithOutput(t.withWorkspace(t.withSandbox(t.withPolicy(toolCtx))), func(chunk string) {
+		t.emit(events.Event{Type: events.ToolOutput, Text: chunk, Tool: &events.ToolCall{ID: call.ID, Name: call.Name}})
+	})
 
-	go func() {
-		res, err := tools.Execute(call.Name, call.Args)
-		resultChan <- struct {
-			res string
-			err error
-		}{res, err}
-	}()
+	// every tool stops when its context is done, so the call is over once Execute returns
+	res, err := t.tools.Execute(toolCtx, call.Name, call.Args)
 
-	select {
-	case result := <-resultChan:
-		return result.res, result.err
-	case <-toolCtx.Done():
-		return "", fmt.Errorf("tool %s timed out after %v", call.Name, timeout)
+	switch {
+	case errors.Is(toolCtx.Err(), context.DeadlineExceeded):
+		return res, fmt.Errorf("tool %s timed out after %v", call.Name, timeout)
+	case errors.Is(toolCtx.Err(), context.Canceled):
+		return res, fmt.Errorf("tool %s canceled", call.Name)
 	}
+
+	return res, err
 }
 
-func (t *Task) getToolTimeout(toolName string) time.Duration {
-	longRunningTools := map[string]time.Duration{
-		"decompose_task":  9 * time.Minute,
-		"go_test":         2 * time.Minute,
-		"search_index":    2 * time.Minute,
-		"analyze_code_go": 1 * time.Minute,
+// getToolTimeout prefers the configured timeout of the tool, then its own default
+func (t *Task) getToolTimeout(tool tools.Tool) time.Duration {
+	if timeout, ok := t.config.ToolTimeouts[tool.Name()]; ok && timeout > 0 {
+		return timeout
 	}
 
-	if timeout, ok := longRunningTools[toolName]; ok {
+	if timeout := tool.Timeout(); timeout > 0 {
 		return timeout
 	}
 
 	return t.config.ToolTimeout
 }
 
-func (t *Task) handleToolResult(call entity.ToolCall, result string, err error) {
-	if err != nil {
-		fmt.Println(ui.Error(fmt.Sprintf("Error running %s: %v", call.Name, err)))
-		t.promptData.AddToolResponse(call.ID, fmt.Sprintf("Error: %v. Result: %s", err, result))
-		return
+func (t *Task) handleToolResult(call entity.ToolCall, result string, err error, duration time.Duration) {
+	if t.config.EnableLoopDetection {
+		t.loops.Record(call, result, err)
 	}
 
-	// save original result for history
-	originalResult := result
+	finished := &events.ToolCall{ID: call.ID, Name: call.Name, Args: call.Args, Result: result, Duration: duration}
+	if err != nil {
+		finished.Error = err.Error()
+	}
+	t.emit(events.Event{Type: events.ToolCallFinished, Tool: finished})
 
-	if isSilentTool(call.Name) {
-		summary := silentToolSummary(call.Name, call.Args, result)
-		fmt.Println(ui.Success("✓ "+call.Name) + summary)
-	} else {
-		toolOutput := getToolDisplayName(call.Name, call.Args)
-		fmt.Println(ui.Success("✓ " + toolOutput))
-
-		if result != "" {
-			if call.Name == "attempt_completion" {
-				fmt.Println(ui.Info(result))
-			} else if isFileOperation(call.Name) {
-				// show file path instead of content for file operations
-				filepath := getFilePathFromArgs(call.Args)
-				if filepath != "" {
-					fmt.Println(ui.Info(fmt.Sprintf("File: %s", filepath)))
-				}
-			} else if call.Name == "bash" {
-				// show command and limited output for bash
-				if cmd := getBashCommand(call.Args); cmd != "" {
-					fmt.Println(ui.Info(fmt.Sprintf("Command: %s", cmd)))
-				}
-				if result != "" {
-					displayResult := limitToolOutputForTool(call.Name, result)
-					_ = NormalizeOutput(displayResult)
-					fmt.Println(displayResult)
-				}
-			} else {
-				displayResult := limitToolOutputForTool(call.Name, result)
-				_ = NormalizeOutput(displayResult)
-				fmt.Println(displayResult)
-			}
-		}
+	if err != nil {
+		t.promptData.AddToolResponse(call.ID, fmt.Sprintf("Error: %v. Result: %s", err, result))
+		return
 	}
 
 	// add original (untruncated) result to history
-	t.promptData.AddToolResponse(call.ID, originalResult)
+	t.promptData.AddToolResponse(call.ID, result)
 }
 
 func getToolDisplayName(toolName string, args map[string]any) string {
@@ -379,7 +660,7 @@ func getToolDisplayName(toolName string, args map[string]any) string {
 		if cmd := getBashCommand(args); cmd != "" {
 			return fmt.Sprintf("bash: %s", cmd)
 		}
-	case "read_file", "write_file", "lsp_edit":
+	case "read_file", "write_file", "lsp_edit", "str_replace":
 		if filepath := getFilePathFromArgs(args); filepath != "" {
 			return fmt.Sprintf("%s: %s", toolName, filepath)
 		}
@@ -432,7 +713,7 @@ func limitToolOutputForTool(toolName, result string) string {
 
 func isFileOperation(toolName string) bool {
 	switch toolName {
-	case "read_file", "write_file", "lsp_edit":
+	case "read_file", "write_file", "lsp_edit", "str_replace":
 		return true
 	default:
 		return false
@@ -468,7 +749,7 @@ func NormalizeOutput(s string) string {
 func (t *Task) checkCancellation() error {
 	select {
 	case <-t.ctx.Done():
-		return fmt.Errorf("task canceled: %v", t.ctx.Err())
+		return ErrCanceled
 	default:
 		return nil
 	}
@@ -487,10 +768,59 @@ func (t *Task) AddUserMessage(message string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	if t.interrupted {
+		message = "I interrupted your previous work. " + message
+		t.interrupted = false
+	}
+
 	t.promptData.AddMessage("user", message)
 	t.trimHistoryIfNeeded()
 }
 
+// QueueMessage adds user guidance to a running task. Queued messages are
+// delivered to the model before its next turn.
+func (t *Task) QueueMessage(message string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.queued = append(t.queued, message)
+}
+
+// QueuedMessages returns the messages that are waiting to be delivered
+func (t *Task) QueuedMessages() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return append([]string(nil), t.queued...)
+}
+
+func (t *Task) hasQueuedMessages() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return len(t.queued) > 0
+}
+
+// deliverQueuedMessages moves queued user messages into the conversation
+func (t *Task) deliverQueuedMessages() {
+	t.mu.Lock()
+	queued := t.queued
+	if len(queued) == 0 {
+		t.mu.Unlock()
+		return
+	}
+	t.queued = nil
+	for _, message := range queued {
+		t.promptData.AddMessage("user", message)
+	}
+	t.trimHistoryIfNeeded()
+	t.mu.Unlock()
+
+	for _, message := range queued {
+		t.info("📨 Delivered: " + message)
+	}
+}
+
 func (t *Task) addAssistantMessage(content string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -605,7 +935,7 @@ func (t *Task) fallbackContextCompaction(messagesToTrim int) string {
 			}
 		}
 
-		if strings.Contains(content, "write_file") || strings.Contains(content, "lsp_edit") {
+		if strings.Contains(content, "write_file") || strings.Contains(content, "lsp_edit") || strings.Contains(content, "str_replace") {
 			if idx := strings.Index(content, "path:"); idx != -1 {
 				pathPart := content[idx+5:]
 				if endIdx := strings.IndexAny(pathPart, " \n,}"); endIdx != -1 {
@@ -646,7 +976,7 @@ func (t *Task) handleNoTools() bool {
 	t.mu.Unlock()
 
 	if count >= t.config.MaxNoToolAttempts {
-		fmt.Println(ui.Warning("AI failed to use tools after multiple attempts. Aborting."))
+		t.warn("AI failed to use tools after multiple attempts. Aborting.")
 		return true
 	}
 
@@ -707,14 +1037,6 @@ func clearDecomposedTask() {
 	state.SetContext("decomposed_task", nil)
 }
 
-// displayReasoning extracts and displays reasoning sections from AI responses
-func (t *Task) displayReasoning(content string) {
-	reasoning := extractReasoning(content)
-	if reasoning != "" {
-		fmt.Print(formatReasoning(reasoning))
-	}
-}
-
 // extractReasoning finds tool explanations in the response
 func extractReasoning(resp string) string {
 	lines := strings.Split(resp, "\n")
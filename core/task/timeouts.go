@@ -0,0 +1,37 @@
+package task
+
+import (
+	"time"
+
+	"github.com/vadiminshakov/autonomy/core/config"
+)
+
+// loadToolTimeouts applies the timeouts of the config file; per-tool timeouts of the task config take precedence
+func (t *Task) loadToolTimeouts() {
+	cfg, err := config.LoadConfigFile()
+	if err != nil {
+		return
+	}
+
+	t.applyToolTimeouts(cfg)
+}
+
+func (t *Task) applyToolTimeouts(cfg config.Config) {
+	if cfg.ToolTimeout > 0 {
+		t.config.ToolTimeout = time.Duration(cfg.ToolTimeout) * time.Second
+	}
+
+	if len(cfg.ToolTimeouts) == 0 {
+		return
+	}
+
+	if t.config.ToolTimeouts == nil {
+		t.config.ToolTimeouts = make(map[string]time.Duration)
+	}
+
+	for name, secs := range cfg.ToolTimeouts {
+		if _, ok := t.config.ToolTimeouts[name]; !ok && secs > 0 {
+			t.config.ToolTimeouts[name] = time.Duration(secs) * time.Second
+		}
+	}
+}
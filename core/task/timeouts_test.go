@@ -0,0 +1,65 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadiminshakov/autonomy/core/config"
+	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+func TestToolTimeoutPrecedence(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ToolTimeouts = map[string]time.Duration{"bash": time.Minute}
+
+	tsk := NewTaskWithConfig(&scriptedClient{}, cfg)
+	tsk.applyToolTimeouts(config.Config{
+		ToolTimeout:  45,
+		ToolTimeouts: map[string]int{"bash": 5, "read_file": 3},
+	})
+
+	get := func(name string) time.Duration {
+		tool, ok := tools.Default().Get(name)
+		require.True(t, ok)
+		return tsk.getToolTimeout(tool)
+	}
+
+	require.Equal(t, time.Minute, get("bash"))
+	require.Equal(t, 3*time.Second, get("read_file"))
+	require.Equal(t, 9*time.Minute, get("decompose_task"))
+	require.Equal(t, 45*time.Second, get("find_files"))
+}
+
+func TestToolTimeoutStopsTool(t *testing.T) {
+	stopped := make(chan struct{})
+
+	registry := tools.NewRegistry()
+	registry.Register(&tools.FuncTool{
+		ToolName: "slow",
+		Fn: func(ctx context.Context, _ map[string]any) (string, error) {
+			<-ctx.Done()
+			close(stopped)
+			return "", ctx.Err()
+		},
+	})
+
+	cfg := DefaultConfig()
+	cfg.ToolTimeouts = map[string]time.Duration{"slow": 50 * time.Millisecond}
+
+	tsk := NewTaskWithConfig(&scriptedClient{}, cfg)
+	tsk.SetTools(registry)
+
+	_, err := tsk.exec(context.Background(), entity.ToolCall{ID: "1", Name: "slow", Args: map[string]any{}})
+	require.EqualError(t, err, "tool slow timed out after 50ms")
+
+	// exec returns only after the tool itself has stopped
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("tool is still running")
+	}
+}
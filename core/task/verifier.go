@@ -0,0 +1,101 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vadiminshakov/autonomy/core/decomposition"
+	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+const stepVerifierPrompt = `You are a strict reviewer of an AI coding agent's work.
+You receive one step of an execution plan and the completion report the agent submitted for it.
+Decide whether the report demonstrates that the step objective is actually achieved.
+
+Rules:
+- Judge only the given step, not the rest of the plan
+- Claims without evidence do not count as verification
+- Missing files, failing commands or unaddressed parts of the step are gaps
+
+Respond with JSON only:
+{"complete": true|false, "gaps": ["concrete missing item", ...]}`
+
+// verdict is the structured answer of a verification or reflection prompt
+type verdict struct {
+	Complete bool     `json:"complete"`
+	Gaps     []string `json:"gaps,omitempty"`
+}
+
+// verifyStep asks the model to check a completion report against the step description
+func (t *Task) verifyStep(step decomposition.TaskStep, report *tools.CompletionReport) (*verdict, error) {
+	var msg strings.Builder
+
+	msg.WriteString(fmt.Sprintf("Step: %s\n", step.Description))
+	if step.Reason != "" {
+		msg.WriteString(fmt.Sprintf("Why it matters: %s\n", step.Reason))
+	}
+
+	msg.WriteString(fmt.Sprintf("\nCompletion report:\nSummary: %s\n", report.Summary))
+	if len(report.FilesChanged) > 0 {
+		msg.WriteString(fmt.Sprintf("Files changed: %s\n", strings.Join(report.FilesChanged, ", ")))
+	}
+	msg.WriteString(fmt.Sprintf("Verification evidence: %s\n", report.Verification))
+
+	if summary := tools.GetTaskState().Summary(); summary != "" {
+		msg.WriteString(fmt.Sprintf("\nRecorded task state:\n%s\n", summary))
+	}
+
+	return t.askVerdict(stepVerifierPrompt, msg.String())
+}
+
+// askVerdict runs a tool-less prompt that must answer with a verdict JSON object
+func (t *Task) askVerdict(systemPrompt, message string) (*verdict, error) {
+	ctx, cancel := context.WithTimeout(t.ctx, t.config.AICallTimeout)
+	defer cancel()
+
+	defer t.startThinking("")()
+
+	response, err := t.client.GenerateCode(ctx, entity.PromptData{
+		SystemPrompt: systemPrompt,
+		Messages:     []entity.Message{{Role: "user", Content: message}},
+		Tools:        []entity.ToolDefinition{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseVerdict(response.Content)
+}
+
+// parseVerdict extracts the verdict object from a model response, tolerating code fences and prose
+func parseVerdict(content string) (*verdict, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		return nil, fmt.Errorf("no JSON object in verifier response: %s", content)
+	}
+
+	var v verdict
+	if err := json.Unmarshal([]byte(content[start:end+1]), &v); err != nil {
+		return nil, fmt.Errorf("invalid verifier response: %v", err)
+	}
+
+	return &v, nil
+}
+
+// formatGaps renders verdict gaps as a bullet list for the model
+func formatGaps(gaps []string) string {
+	if len(gaps) == 0 {
+		return "- the reviewer did not accept the result but gave no details; re-check the objective"
+	}
+
+	var b strings.Builder
+	for _, gap := range gaps {
+		b.WriteString("- " + gap + "\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
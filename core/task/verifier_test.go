@@ -0,0 +1,26 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVerdict(t *testing.T) {
+	v, err := parseVerdict("```json\n{\"complete\": false, \"gaps\": [\"tests not run\"]}\n```")
+	require.NoError(t, err)
+	require.False(t, v.Complete)
+	require.Equal(t, []string{"tests not run"}, v.Gaps)
+
+	v, err = parseVerdict(`Looks good. {"complete": true}`)
+	require.NoError(t, err)
+	require.True(t, v.Complete)
+
+	_, err = parseVerdict("the step is done")
+	require.Error(t, err)
+}
+
+func TestFormatGaps(t *testing.T) {
+	require.Equal(t, "- a\n- b", formatGaps([]string{"a", "b"}))
+	require.Contains(t, formatGaps(nil), "no details")
+}
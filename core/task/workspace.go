@@ -0,0 +1,47 @@
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vadiminshakov/autonomy/core/config"
+	"github.com/vadiminshakov/autonomy/core/workspace"
+)
+
+// loadWorkspace confines the file tools to the repository of the working directory
+func (t *Task) loadWorkspace() {
+	wd, err := t.workDir()
+	if err != nil {
+		return
+	}
+
+	var cfg workspace.Config
+	if c, err := config.LoadConfigFile(); err == nil {
+		cfg = c.Workspace
+	}
+
+	w, err := workspace.New(cfg, wd)
+	if err != nil {
+		// the project alone stays available when the extra directories or globs are broken
+		t.warn(fmt.Sprintf("Workspace config invalid, only the project is accessible: %v", err))
+		if w, err = workspace.New(workspace.Config{}, wd); err != nil {
+			return
+		}
+	}
+
+	t.mu.Lock()
+	t.workspace = w
+	t.mu.Unlock()
+}
+
+// withWorkspace attaches the workspace to the context of tools
+func (t *Task) withWorkspace(ctx context.Context) context.Context {
+	t.mu.RLock()
+	w := t.workspace
+	t.mu.RUnlock()
+
+	if w == nil {
+		return ctx
+	}
+	return workspace.WithWorkspace(ctx, w)
+}
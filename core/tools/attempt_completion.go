@@ -1,24 +1,67 @@
 package tools
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 )
 
 func init() {
-	Register("attempt_completion", AttemptCompletion)
+	RegisterTool(&FuncTool{
+		ToolName: "attempt_completion",
+		ToolDescription: "Mark task or current plan step as finished. Use ONLY when it is fully completed. " +
+			"Plan steps require summary and verification evidence",
+		InputSchema: objectSchema(map[string]any{
+			"summary": map[string]string{
+				"type":        "string",
+				"description": "What was accomplished",
+			},
+			"files_changed": map[string]any{
+				"type":        "array",
+				"items":       map[string]string{"type": "string"},
+				"description": "Paths of files created or modified",
+			},
+			"verification": map[string]string{
+				"type":        "string",
+				"description": "Evidence that the objective is met: commands run and their results, tests passed, files checked",
+			},
+			"result": map[string]string{
+				"type":        "string",
+				"description": "Deprecated alias for summary",
+			},
+		}),
+		IsReadOnly: true,
+		Fn:         AttemptCompletion,
+	})
+}
+
+// CompletionReport is the structured result attached to attempt_completion
+type CompletionReport struct {
+	Summary      string   `json:"summary"`
+	FilesChanged []string `json:"files_changed,omitempty"`
+	Verification string   `json:"verification,omitempty"`
 }
 
 // AttemptCompletion marks the task as completed and returns a final message.
-func AttemptCompletion(args map[string]interface{}) (string, error) {
-	result, _ := args["result"].(string)
+func AttemptCompletion(_ context.Context, args map[string]interface{}) (string, error) {
+	report := ParseCompletionReport(args)
 	state := getTaskState()
 
-	// only check if the last tool was successful - ignore historical errors
-	if !state.LastToolSucceeded() {
-		return "", errors.New("cannot complete task: last operation failed")
+	if err := checkCompletion(state, report); err != nil {
+		return "", err
+	}
+
+	// steps of an executing plan must report what was done and how it was verified
+	if stepID, ok := state.GetContext("current_step"); ok && stepID != "" {
+		state.SetContext("completion_report", report)
+		state.SetContext("step_completed", "true")
+
+		return fmt.Sprintf("Step completed:\n%s\n✅", report.Summary), nil
 	}
 
+	state.SetContext("completion_report", report)
+
 	// check if we're in a decomposed task execution
 	hasTask, exists := state.GetContext("has_decomposed_task")
 	isDecomposedTask := exists && hasTask == true
@@ -26,8 +69,8 @@ func AttemptCompletion(args map[string]interface{}) (string, error) {
 	// for decomposed tasks, only set step completion flag, don't terminate
 	if isDecomposedTask {
 		state.SetContext("step_completed", "true")
-		if result != "" {
-			return fmt.Sprintf("Step completed:\n%s\n✅", result), nil
+		if report.Summary != "" {
+			return fmt.Sprintf("Step completed:\n%s\n✅", report.Summary), nil
 		} else {
 			return "Step completed!\n✅", nil
 		}
@@ -36,9 +79,78 @@ func AttemptCompletion(args map[string]interface{}) (string, error) {
 	// for direct tasks, mark as fully completed
 	state.SetContext("task_completed", "true")
 
-	if result != "" {
-		return fmt.Sprintf("Task completed:\n\n%s\n\n✅", result), nil
+	if report.Summary != "" {
+		return fmt.Sprintf("Task completed:\n\n%s\n\n✅", report.Summary), nil
 	} else {
 		return "Task completed!\n\n✅", nil
 	}
 }
+
+// CheckCompletion reports why attempt_completion would refuse args, without completing anything
+func CheckCompletion(args map[string]interface{}) error {
+	return checkCompletion(getTaskState(), ParseCompletionReport(args))
+}
+
+func checkCompletion(state *TaskState, report *CompletionReport) error {
+	// only check if the last tool was successful - ignore historical errors
+	if !state.LastToolSucceeded() {
+		return errors.New("cannot complete task: last operation failed")
+	}
+
+	// steps of an executing plan must report what was done and how it was verified
+	if stepID, ok := state.GetContext("current_step"); ok && stepID != "" {
+		if report.Summary == "" {
+			return errors.New("cannot complete step: 'summary' is required - describe what was accomplished")
+		}
+		if report.Verification == "" {
+			return errors.New("cannot complete step: 'verification' is required - " +
+				"provide evidence that the step objective is met (command output, test results, file contents checked)")
+		}
+	}
+
+	return nil
+}
+
+// GetCompletionReport returns the report attached to the last successful attempt_completion
+func GetCompletionReport() (*CompletionReport, bool) {
+	val, ok := getTaskState().GetContext("completion_report")
+	if !ok {
+		return nil, false
+	}
+
+	report, ok := val.(*CompletionReport)
+	return report, ok && report != nil
+}
+
+// ParseCompletionReport extracts the structured completion fields; "result" is accepted as a summary alias
+func ParseCompletionReport(args map[string]interface{}) *CompletionReport {
+	report := &CompletionReport{}
+
+	if summary, ok := args["summary"].(string); ok {
+		report.Summary = strings.TrimSpace(summary)
+	}
+	if result, ok := args["result"].(string); ok && report.Summary == "" {
+		report.Summary = strings.TrimSpace(result)
+	}
+
+	if verification, ok := args["verification"].(string); ok {
+		report.Verification = strings.TrimSpace(verification)
+	}
+
+	switch files := args["files_changed"].(type) {
+	case []interface{}:
+		for _, f := range files {
+			if s, ok := f.(string); ok && strings.TrimSpace(s) != "" {
+				report.FilesChanged = append(report.FilesChanged, strings.TrimSpace(s))
+			}
+		}
+	case string:
+		for _, f := range strings.Split(files, ",") {
+			if strings.TrimSpace(f) != "" {
+				report.FilesChanged = append(report.FilesChanged, strings.TrimSpace(f))
+			}
+		}
+	}
+
+	return report
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"strings"
 	"testing"
 )
@@ -14,7 +15,7 @@ func TestAttemptCompletion_DirectTask(t *testing.T) {
 		"result": "Task finished successfully",
 	}
 
-	result, err := AttemptCompletion(args)
+	result, err := AttemptCompletion(context.Background(), args)
 	if err != nil {
 		t.Fatalf("AttemptCompletion failed: %v", err)
 	}
@@ -45,7 +46,7 @@ func TestAttemptCompletion_DecomposedTask(t *testing.T) {
 		"result": "Step 1 completed",
 	}
 
-	result, err := AttemptCompletion(args)
+	result, err := AttemptCompletion(context.Background(), args)
 	if err != nil {
 		t.Fatalf("AttemptCompletion failed: %v", err)
 	}
@@ -82,7 +83,7 @@ func TestAttemptCompletion_FailedLastTool(t *testing.T) {
 		"result": "Trying to complete",
 	}
 
-	_, err := AttemptCompletion(args)
+	_, err := AttemptCompletion(context.Background(), args)
 	if err == nil {
 		t.Errorf("Expected error when last tool failed, but got none")
 	}
@@ -98,7 +99,7 @@ func TestAttemptCompletion_NoResult(t *testing.T) {
 
 	args := map[string]interface{}{}
 
-	result, err := AttemptCompletion(args)
+	result, err := AttemptCompletion(context.Background(), args)
 	if err != nil {
 		t.Fatalf("AttemptCompletion failed: %v", err)
 	}
@@ -118,7 +119,7 @@ func TestAttemptCompletion_DecomposedTaskNoResult(t *testing.T) {
 
 	args := map[string]interface{}{}
 
-	result, err := AttemptCompletion(args)
+	result, err := AttemptCompletion(context.Background(), args)
 	if err != nil {
 		t.Fatalf("AttemptCompletion failed: %v", err)
 	}
@@ -128,3 +129,47 @@ func TestAttemptCompletion_DecomposedTaskNoResult(t *testing.T) {
 		t.Errorf("Expected '%s', got: '%s'", expected, result)
 	}
 }
+
+func TestAttemptCompletion_PlanStepRequiresReport(t *testing.T) {
+	state := getTaskState()
+	state.Reset()
+	state.SetContext("current_step", "step_1")
+
+	_, err := AttemptCompletion(context.Background(), map[string]interface{}{"result": "done"})
+	if err == nil || !strings.Contains(err.Error(), "'verification' is required") {
+		t.Fatalf("Expected missing verification error, got: %v", err)
+	}
+
+	_, err = AttemptCompletion(context.Background(), map[string]interface{}{"verification": "go test passed"})
+	if err == nil || !strings.Contains(err.Error(), "'summary' is required") {
+		t.Fatalf("Expected missing summary error, got: %v", err)
+	}
+
+	result, err := AttemptCompletion(context.Background(), map[string]interface{}{
+		"summary":       "Added handler",
+		"files_changed": []interface{}{"api/handler.go", " "},
+		"verification":  "go test ./api passed",
+	})
+	if err != nil {
+		t.Fatalf("AttemptCompletion failed: %v", err)
+	}
+
+	if !strings.Contains(result, "Step completed:\nAdded handler") {
+		t.Errorf("Expected step completion message, got: %s", result)
+	}
+
+	report, ok := GetCompletionReport()
+	if !ok {
+		t.Fatal("Expected completion report to be stored")
+	}
+	if len(report.FilesChanged) != 1 || report.FilesChanged[0] != "api/handler.go" {
+		t.Errorf("Unexpected files_changed: %v", report.FilesChanged)
+	}
+	if report.Verification != "go test ./api passed" {
+		t.Errorf("Unexpected verification: %s", report.Verification)
+	}
+
+	if taskCompleted, exists := state.GetContext("task_completed"); exists && taskCompleted == "true" {
+		t.Errorf("Expected task_completed to not be set for plan step")
+	}
+}
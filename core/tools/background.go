@@ -0,0 +1,451 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/vadiminshakov/autonomy/core/sandbox"
+)
+
+const (
+	// maxBackgroundOutput is how much output of a background process is kept
+	maxBackgroundOutput = 1 << 20
+	// maxReadOutput is how much new output one read returns
+	maxReadOutput = 32 * 1024
+
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitTimeout     = 10 * time.Minute
+)
+
+func init() {
+	idProperty := map[string]string{
+		"type":        "string",
+		"description": "Id of the background process returned by bash",
+	}
+
+	RegisterTool(&FuncTool{
+		ToolName:        "bash_output",
+		ToolDescription: "Read new output of a background process since the last read, and whether it is still running",
+		InputSchema:     objectSchema(map[string]any{"id": idProperty}, "id"),
+		IsReadOnly:      true,
+		Fn:              bashOutput,
+	})
+	RegisterTool(&FuncTool{
+		ToolName: "bash_wait",
+		ToolDescription: "Wait until new output of a background process matches a regular expression, " +
+			"or until it exits when no pattern is given. Use to wait for a server to start or tests to finish",
+		InputSchema: objectSchema(map[string]any{
+			"id": idProperty,
+			"pattern": map[string]string{
+				"type":        "string",
+				"description": "Regular expression to wait for, e.g. 'listening on|ready'",
+			},
+			"timeout": map[string]string{
+				"type":        "integer",
+				"description": "Seconds to wait, 30 by default",
+			},
+		}, "id"),
+		IsReadOnly: true,
+		// waits are bounded by their own timeout argument
+		DefaultTimeout: maxWaitTimeout + time.Minute,
+		Fn:             bashWait,
+	})
+	RegisterTool(&FuncTool{
+		ToolName:        "bash_input",
+		ToolDescription: "Send a line to the stdin of a background process",
+		InputSchema: objectSchema(map[string]any{
+			"id":    idProperty,
+			"input": map[string]string{"type": "string"},
+		}, "id", "input"),
+		Fn: bashInput,
+	})
+	RegisterTool(&FuncTool{
+		ToolName:        "bash_stop",
+		ToolDescription: "Stop a background process and its children, returning the remaining output",
+		InputSchema:     objectSchema(map[string]any{"id": idProperty}, "id"),
+		Fn:              bashStop,
+	})
+}
+
+// backgroundArg returns the background process the "id" argument names
+func backgroundArg(ctx context.Context, args map[string]interface{}) (*backgroundProcess, error) {
+	id, ok := args["id"].(string)
+	if !ok || id == "" {
+		return nil, fmt.Errorf("parameter 'id' must be a non-empty string")
+	}
+	return processesFrom(ctx).getBackground(id)
+}
+
+func bashOutput(ctx context.Context, args map[string]interface{}) (string, error) {
+	p, err := backgroundArg(ctx, args)
+	if err != nil {
+		return "", err
+	}
+
+	return formatBackground(p, p.readNew()), nil
+}
+
+func bashWait(ctx context.Context, args map[string]interface{}) (string, error) {
+	p, err := backgroundArg(ctx, args)
+	if err != nil {
+		return "", err
+	}
+
+	var pattern *regexp.Regexp
+	if expr, _ := args["pattern"].(string); expr != "" {
+		if pattern, err = regexp.Compile(expr); err != nil {
+			return "", fmt.Errorf("invalid pattern: %v", err)
+		}
+	}
+
+	timeout := defaultWaitTimeout
+	if secs, ok := toInt(args["timeout"]); ok && secs > 0 {
+		timeout = time.Duration(secs) * time.Second
+	}
+	if timeout > maxWaitTimeout {
+		timeout = maxWaitTimeout
+	}
+
+	out, matched, err := p.wait(ctx, pattern, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	note := "pattern matched"
+	switch {
+	case pattern == nil && matched:
+		note = "process exited"
+	case !matched && p.exited():
+		note = "process exited before the pattern appeared"
+	case !matched:
+		note = fmt.Sprintf("timed out after %v", timeout)
+	}
+
+	return note + "\n" + formatBackground(p, out), nil
+}
+
+func bashInput(ctx context.Context, args map[string]interface{}) (string, error) {
+	p, err := backgroundArg(ctx, args)
+	if err != nil {
+		return "", err
+	}
+
+	input, ok := args["input"].(string)
+	if !ok {
+		return "", fmt.Errorf("parameter 'input' must be a string")
+	}
+
+	if err := p.send(ctx, input); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("sent input to %s", p.id), nil
+}
+
+func bashStop(ctx context.Context, args map[string]interface{}) (string, error) {
+	p, err := backgroundArg(ctx, args)
+	if err != nil {
+		return "", err
+	}
+
+	p.stop(ctx)
+
+	return formatBackground(p, p.readNew()), nil
+}
+
+func formatBackground(p *backgroundProcess, output string) string {
+	if strings.TrimSpace(output) == "" {
+		output = "(no new output)"
+	}
+	return fmt.Sprintf("%s [%s]: %s\n%s", p.id, p.status(), p.command, output)
+}
+
+// backgroundProcess is a command started with run_in_background
+type backgroundProcess struct {
+	id      string
+	command string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	cancel  context.CancelFunc
+
+	mu      sync.Mutex
+	output  []byte
+	dropped int // bytes discarded from the front of output
+	read    int // offset of the output already returned to the agent
+	changed chan struct{}
+	done    chan struct{}
+	exitErr error
+	stopped bool
+}
+
+// startBackground runs a command that outlives the tool call; it is stopped with
+// bash_stop or when the task is closed. Without a shell session it starts in dir.
+func (ps *Processes) startBackground(command string, sb *sandbox.Sandbox, dir string) (*backgroundProcess, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := shellCommand(ctx, command)
+	// start where the shell session is, so "cd dir" followed by a background command works
+	cmd.Dir = ps.shellDir()
+	if cmd.Dir == "" {
+		cmd.Dir = dir
+	}
+
+	p := &backgroundProcess{
+		command: command,
+		cmd:     cmd,
+		cancel:  cancel,
+		changed: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	cmd.Stdout = p
+	cmd.Stderr = p
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to open stdin: %v", err)
+	}
+	p.stdin = stdin
+
+	if err := sb.Wrap(cmd); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to start command: %v", err)
+	}
+
+	ps.mu.Lock()
+	ps.next++
+	p.id = fmt.Sprintf("bg-%d", ps.next)
+	ps.background[p.id] = p
+	ps.mu.Unlock()
+
+	go func() {
+		err := cmd.Wait()
+
+		p.mu.Lock()
+		p.exitErr = err
+		close(p.done)
+		p.notifyLocked()
+		p.mu.Unlock()
+	}()
+
+	return p, nil
+}
+
+func (ps *Processes) getBackground(id string) (*backgroundProcess, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	p, ok := ps.background[id]
+	if !ok {
+		ids := make([]string, 0, len(ps.background))
+		for known := range ps.background {
+			ids = append(ids, known)
+		}
+		sort.Strings(ids)
+
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("background process %s not found, no processes are running", id)
+		}
+		return nil, fmt.Errorf("background process %s not found, known processes: %s", id, strings.Join(ids, ", "))
+	}
+
+	return p, nil
+}
+
+func (ps *Processes) stopBackground() {
+	ps.mu.Lock()
+	procs := make([]*backgroundProcess, 0, len(ps.background))
+	for _, p := range ps.background {
+		procs = append(procs, p)
+	}
+	ps.background = make(map[string]*backgroundProcess)
+	ps.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, p := range procs {
+		wg.Add(1)
+		go func(p *backgroundProcess) {
+			defer wg.Done()
+			p.stop(context.Background())
+		}(p)
+	}
+	wg.Wait()
+}
+
+func (p *backgroundProcess) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.output = append(p.output, b...)
+	if extra := len(p.output) - maxBackgroundOutput; extra > 0 {
+		p.output = append([]byte(nil), p.output[extra:]...)
+		p.dropped += extra
+	}
+	p.notifyLocked()
+
+	return len(b), nil
+}
+
+// notifyLocked wakes up the waiters for new output or exit
+func (p *backgroundProcess) notifyLocked() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// unreadLocked returns the output the agent has not seen yet
+func (p *backgroundProcess) unreadLocked() string {
+	start := p.read - p.dropped
+	if start < 0 {
+		start = 0
+	}
+	return string(p.output[start:])
+}
+
+// readNew returns the output the agent has not seen yet and marks it as read
+func (p *backgroundProcess) readNew() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.consumeLocked()
+}
+
+func (p *backgroundProcess) consumeLocked() string {
+	out := p.unreadLocked()
+	p.read = p.dropped + len(p.output)
+
+	if len(out) > maxReadOutput {
+		skipped := len(out) - maxReadOutput
+		out = fmt.Sprintf("[... %d bytes skipped ...]\n%s", skipped, out[skipped:])
+	}
+
+	return out
+}
+
+func (p *backgroundProcess) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// status describes whether the process is still running and how it ended
+func (p *backgroundProcess) status() string {
+	if !p.exited() {
+		return "running"
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var exitErr *exec.ExitError
+	switch {
+	case p.stopped:
+		return "stopped"
+	case p.exitErr == nil:
+		return "exited with code 0"
+	case errors.As(p.exitErr, &exitErr):
+		return fmt.Sprintf("exited with code %d", exitErr.ExitCode())
+	default:
+		return fmt.Sprintf("failed: %v", p.exitErr)
+	}
+}
+
+// wait blocks until the unread output matches pattern, the process exits or the timeout passes.
+// A nil pattern waits for exit only.
+func (p *backgroundProcess) wait(ctx context.Context, pattern *regexp.Regexp, timeout time.Duration) (string, bool, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		p.mu.Lock()
+		if pattern != nil && pattern.MatchString(p.unreadLocked()) {
+			out := p.consumeLocked()
+			p.mu.Unlock()
+			return out, true, nil
+		}
+		if p.exited() {
+			out := p.consumeLocked()
+			p.mu.Unlock()
+			return out, pattern == nil, nil
+		}
+		changed := p.changed
+		p.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return p.readNew(), false, nil
+		case <-ctx.Done():
+			return "", false, ctx.Err()
+		}
+	}
+}
+
+// send writes a line to stdin; a process that does not read it fills the pipe, so the write
+// gives up when ctx is done
+func (p *backgroundProcess) send(ctx context.Context, input string) error {
+	if p.exited() {
+		return fmt.Errorf("background process %s is not running (%s)", p.id, p.status())
+	}
+
+	if !strings.HasSuffix(input, "\n") {
+		input += "\n"
+	}
+
+	if pipe, ok := p.stdin.(interface{ SetWriteDeadline(time.Time) error }); ok {
+		// an earlier canceled write may have left a deadline behind
+		_ = pipe.SetWriteDeadline(time.Time{})
+		stop := context.AfterFunc(ctx, func() { _ = pipe.SetWriteDeadline(time.Now()) })
+		defer stop()
+	}
+
+	if _, err := io.WriteString(p.stdin, input); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("failed to write to %s: %w", p.id, ctxErr)
+		}
+		return fmt.Errorf("failed to write to %s: %v", p.id, err)
+	}
+
+	return nil
+}
+
+// stop terminates the process group, killing it if it does not exit within two seconds or ctx is done
+func (p *backgroundProcess) stop(ctx context.Context) {
+	if p.exited() {
+		return
+	}
+
+	p.mu.Lock()
+	p.stopped = true
+	p.mu.Unlock()
+
+	_ = p.stdin.Close()
+	_ = signalGroup(p.cmd, syscall.SIGTERM)
+
+	select {
+	case <-p.done:
+	case <-time.After(2 * time.Second):
+		p.cancel()
+		<-p.done
+	case <-ctx.Done():
+		p.cancel()
+		<-p.done
+	}
+
+	p.cancel()
+}
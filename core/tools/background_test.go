@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func startBackgroundTool(t *testing.T, command string) string {
+	t.Helper()
+
+	result, err := Execute("bash", map[string]interface{}{"command": command, "run_in_background": true})
+	require.NoError(t, err)
+	require.Contains(t, result, "started background process bg-")
+
+	id := strings.Fields(strings.TrimPrefix(result, "started background process "))[0]
+	t.Cleanup(StopBackgroundProcesses)
+
+	return id
+}
+
+func TestBackgroundProcess(t *testing.T) {
+	id := startBackgroundTool(t, `echo ready; read line; echo "got $line"; sleep 30`)
+
+	result, err := Execute("bash_wait", map[string]interface{}{"id": id, "pattern": "ready", "timeout": "5"})
+	require.NoError(t, err)
+	require.Contains(t, result, "pattern matched")
+	require.Contains(t, result, "[running]")
+
+	_, err = Execute("bash_input", map[string]interface{}{"id": id, "input": "hello"})
+	require.NoError(t, err)
+
+	result, err = Execute("bash_wait", map[string]interface{}{"id": id, "pattern": "got hello", "timeout": 5})
+	require.NoError(t, err)
+	require.Contains(t, result, "got hello")
+	require.NotContains(t, result, "ready\n", "output that was already read is not returned again")
+
+	result, err = Execute("bash_output", map[string]interface{}{"id": id})
+	require.NoError(t, err)
+	require.Contains(t, result, "(no new output)")
+
+	start := time.Now()
+	result, err = Execute("bash_stop", map[string]interface{}{"id": id})
+	require.NoError(t, err)
+	require.Contains(t, result, "[stopped]")
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestBackgroundWaitForExit(t *testing.T) {
+	id := startBackgroundTool(t, "echo done; exit 3")
+
+	result, err := Execute("bash_wait", map[string]interface{}{"id": id, "timeout": 5})
+	require.NoError(t, err)
+	require.Contains(t, result, "process exited")
+	require.Contains(t, result, "exited with code 3")
+	require.Contains(t, result, "done")
+}
+
+func TestBackgroundToolsCheckArgs(t *testing.T) {
+	// called directly, without the schema validation of Execute
+	for name, fn := range map[string]ContextToolFunc{
+		"bash_output": bashOutput,
+		"bash_input":  bashInput,
+		"bash_stop":   bashStop,
+		"bash_wait":   bashWait,
+	} {
+		_, err := fn(context.Background(), map[string]interface{}{"id": nil})
+		require.ErrorContains(t, err, "parameter 'id' must be a non-empty string", name)
+	}
+
+	id := startBackgroundTool(t, "sleep 30")
+	_, err := bashInput(context.Background(), map[string]interface{}{"id": id, "input": 42})
+	require.ErrorContains(t, err, "parameter 'input' must be a string")
+}
+
+func TestBackgroundInputStopsWithContext(t *testing.T) {
+	// sleep never reads its stdin, so the pipe fills up
+	id := startBackgroundTool(t, "sleep 30")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_, err := bashInput(ctx, map[string]interface{}{"id": id, "input": strings.Repeat("x", 1<<20)})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the process stays usable
+	result, err := Execute("bash_output", map[string]interface{}{"id": id})
+	require.NoError(t, err)
+	require.Contains(t, result, "[running]")
+}
+
+func TestStopBackgroundProcesses(t *testing.T) {
+	id := startBackgroundTool(t, "sleep 30")
+
+	p, err := sharedProcesses.getBackground(id)
+	require.NoError(t, err)
+
+	StopBackgroundProcesses()
+
+	require.True(t, p.exited())
+	_, err = Execute("bash_output", map[string]interface{}{"id": id})
+	require.ErrorContains(t, err, "not found")
+}
+
+func TestBashStreamsOutput(t *testing.T) {
+	var mu sync.Mutex
+	var chunks []string
+
+	ctx := WithOutput(context.Background(), func(chunk string) {
+		mu.Lock()
+		defer mu.Unlock()
+		chunks = append(chunks, chunk)
+	})
+
+	result, err := bashCommand(ctx, map[string]interface{}{"command": "echo one; sleep 0.1; echo two"})
+	require.NoError(t, err)
+	require.Equal(t, "one\ntwo", result)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, "one\ntwo\n", strings.Join(chunks, ""))
+	require.GreaterOrEqual(t, len(chunks), 2)
+}
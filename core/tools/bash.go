@@ -1,28 +1,77 @@
 package tools
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
 	"strings"
+	"time"
+
+	"github.com/vadiminshakov/autonomy/core/policy"
+	"github.com/vadiminshakov/autonomy/core/sandbox"
 )
 
+// commandWaitDelay bounds how long a killed command may hold its output pipes open
+const commandWaitDelay = 2 * time.Second
+
 func init() {
-	Register("bash", bashCommand)
+	RegisterTool(&FuncTool{
+		ToolName: "bash",
+		ToolDescription: "Execute any bash command. Replaces git, file operations, and directory commands. " +
+			"Commands run in a persistent shell: cd, exported variables and functions carry over between calls. " +
+			"Set run_in_background for dev servers, watchers and long test suites, then use " +
+			"bash_output, bash_wait, bash_input and bash_stop with the returned id",
+		InputSchema: objectSchema(map[string]any{
+			"command": map[string]string{"type": "string"},
+			"run_in_background": map[string]string{
+				"type":        "boolean",
+				"description": "Start the command and return its id immediately instead of waiting for it to exit",
+			},
+		}, "command"),
+		Fn: bashCommand,
+	})
 }
 
-func bashCommand(args map[string]interface{}) (string, error) {
+func bashCommand(ctx context.Context, args map[string]interface{}) (string, error) {
 	command, ok := args["command"].(string)
 	if !ok {
 		return "", fmt.Errorf("command parameter is required")
 	}
 
-	cmd := exec.Command("bash", "-c", command)
-	output, err := cmd.CombinedOutput()
+	if err := policy.Check(ctx, "bash", command); err != nil {
+		return "", err
+	}
+
+	if inBackground, _ := args["run_in_background"].(bool); inBackground {
+		return bashBackground(ctx, command)
+	}
+
+	output, code, err := runInShell(ctx, command)
 
-	result := strings.TrimSpace(string(output))
+	result := strings.TrimSpace(output)
+	if ctx.Err() != nil {
+		return result, fmt.Errorf("command canceled: %v", ctx.Err())
+	}
 	if err != nil {
-		return result, fmt.Errorf("command failed: %v", err)
+		return result, err
+	}
+
+	result += shellDirNote(ctx, processesFrom(ctx).shellDir())
+	if code != 0 {
+		return result, fmt.Errorf("command failed: exit status %d", code)
 	}
 
 	return result, nil
 }
+
+func bashBackground(ctx context.Context, command string) (string, error) {
+	p, err := processesFrom(ctx).startBackground(command, sandbox.FromContext(ctx), workDir(ctx))
+	if err != nil {
+		return "", err
+	}
+
+	getTaskState().RecordCommandExecuted(command + " [BACKGROUND " + p.id + "]")
+
+	return fmt.Sprintf("started background process %s (pid %d). "+
+		"use bash_output, bash_wait, bash_input and bash_stop with id %s; it is stopped when the task ends",
+		p.id, p.cmd.Process.Pid, p.id), nil
+}
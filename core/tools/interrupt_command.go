@@ -3,11 +3,13 @@ package tools
 import (
 	"context"
 	"fmt"
-	"os/exec"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
+
+	"github.com/vadiminshakov/autonomy/core/policy"
+	"github.com/vadiminshakov/autonomy/core/sandbox"
 )
 
 type safeWriter struct {
@@ -21,27 +23,42 @@ func (sw *safeWriter) Write(p []byte) (n int, err error) {
 	return sw.builder.Write(p)
 }
 
+// interruptAfter is how long interrupt_command lets a command run
+const interruptAfter = 10 * time.Second
+
 func init() {
-	Register("interrupt_command", InterruptCommand)
+	RegisterTool(&FuncTool{
+		ToolName: "interrupt_command",
+		ToolDescription: "Execute command with interrupt capability - automatically stops long-running commands " +
+			"after 10s and analyzes their output",
+		InputSchema: objectSchema(map[string]any{
+			"command": map[string]string{
+				"type":        "string",
+				"description": "Command to execute with interrupt capability",
+			},
+		}, "command"),
+		Fn: InterruptCommandContext,
+	})
 }
 
 func InterruptCommand(args map[string]interface{}) (string, error) {
+	return InterruptCommandContext(context.Background(), args)
+}
+
+// InterruptCommandContext runs a command for up to 10 seconds; canceling ctx kills it immediately
+func InterruptCommandContext(ctx context.Context, args map[string]interface{}) (string, error) {
 	cmdStr, ok := args["command"].(string)
 	if !ok || strings.TrimSpace(cmdStr) == "" {
 		return "", fmt.Errorf("parameter 'command' must be a non-empty string")
 	}
 
-	dangerous := []string{"rm -rf", "format", "del /", "shutdown", "reboot", "mkfs"}
-	for _, danger := range dangerous {
-		if strings.Contains(strings.ToLower(cmdStr), danger) {
-			return "", fmt.Errorf("execution of command '%s' is blocked for security reasons", cmdStr)
-		}
+	if err := policy.Check(ctx, "interrupt_command", cmdStr); err != nil {
+		return "", err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "bash", "-c", cmdStr)
+	// the command is killed when the task is canceled; the interrupt after 10 seconds is separate
+	cmd := shellCommand(ctx, cmdStr)
+	cmd.Dir = workDir(ctx)
 
 	var output strings.Builder
 	var outputMu sync.RWMutex
@@ -49,6 +66,10 @@ func InterruptCommand(args map[string]interface{}) (string, error) {
 	cmd.Stdout = &safeWriter{builder: &output, mu: &outputMu}
 	cmd.Stderr = &safeWriter{builder: &output, mu: &outputMu}
 
+	if err := sandbox.FromContext(ctx).Wrap(cmd); err != nil {
+		return "", err
+	}
+
 	if err := cmd.Start(); err != nil {
 		return "", fmt.Errorf("failed to start command: %v", err)
 	}
@@ -58,8 +79,15 @@ func InterruptCommand(args map[string]interface{}) (string, error) {
 		done <- cmd.Wait()
 	}()
 
+	interrupt := time.NewTimer(interruptAfter)
+	defer interrupt.Stop()
+
 	select {
 	case err := <-done:
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("command canceled: %v", ctx.Err())
+		}
+
 		state := getTaskState()
 		state.RecordCommandExecuted(cmdStr)
 		outputMu.RLock()
@@ -67,11 +95,14 @@ func InterruptCommand(args map[string]interface{}) (string, error) {
 		outputMu.RUnlock()
 		return result, err
 
-	case <-ctx.Done():
-		if cmd.Process != nil {
-			_ = cmd.Process.Signal(syscall.SIGTERM)
-			time.Sleep(2 * time.Second)
-			_ = cmd.Process.Kill()
+	case <-interrupt.C:
+		// give the processes a chance to flush their output before killing them
+		_ = signalGroup(cmd, syscall.SIGTERM)
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			_ = signalGroup(cmd, syscall.SIGKILL)
+			<-done
 		}
 
 		outputMu.RLock()
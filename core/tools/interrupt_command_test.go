@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"strings"
 	"testing"
 )
@@ -102,7 +103,7 @@ func TestAnalyzeCommandOutputEmpty(t *testing.T) {
 
 func TestInterruptCommandRecordsState(t *testing.T) {
 	// reset task state
-	if _, err := resetTaskState(map[string]interface{}{}); err != nil {
+	if _, err := resetTaskState(context.Background(), map[string]interface{}{}); err != nil {
 		t.Fatalf("failed to reset task state: %v", err)
 	}
 
@@ -1,18 +1,40 @@
 package tools
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
+
+	"github.com/vadiminshakov/autonomy/core/workspace"
 )
 
 func init() {
-	Register("lsp_edit", lspEdit)
+	RegisterTool(&FuncTool{
+		ToolName: "lsp_edit",
+		ToolDescription: "Modify EXISTING files with precise line-based edits (insert/replace/delete). " +
+			"Supports multiple edits in a single call. This is the DEFAULT tool for any modifications",
+		InputSchema: objectSchema(map[string]any{
+			"path": map[string]string{"type": "string"},
+			"edits": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"start_line":  map[string]string{"type": "integer"},
+						"end_line":    map[string]string{"type": "integer"},
+						"new_text":    map[string]string{"type": "string"},
+						"description": map[string]string{"type": "string"},
+					},
+					"required": []string{"start_line", "end_line", "new_text"},
+				},
+			},
+		}, "path", "edits"),
+		Fn: lspEdit,
+	})
 }
 
 // EditRequest represents a single edit operation
@@ -24,7 +46,7 @@ type EditRequest struct {
 }
 
 //nolint:gocyclo
-func lspEdit(args map[string]interface{}) (string, error) {
+func lspEdit(ctx context.Context, args map[string]interface{}) (string, error) {
 	pathVal, ok := args["path"].(string)
 	if !ok || strings.TrimSpace(pathVal) == "" {
 		return "", fmt.Errorf("parameter 'path' must be a non-empty string")
@@ -32,13 +54,18 @@ func lspEdit(args map[string]interface{}) (string, error) {
 
 	pathVal = strings.TrimSpace(pathVal)
 
+	path, err := workspace.FromContext(ctx).Resolve(pathVal)
+	if err != nil {
+		return "", err
+	}
+
 	// check that the file exists
-	if _, err := os.Stat(pathVal); os.IsNotExist(err) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return "", fmt.Errorf("file does not exist: %s", pathVal)
 	}
 
 	// read file content
-	content, err := os.ReadFile(pathVal)
+	content, err := os.ReadFile(path)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %v", err)
 	}
@@ -49,14 +76,9 @@ func lspEdit(args map[string]interface{}) (string, error) {
 
 	// try to get edits as JSON string or array
 	if editsVal, ok := args["edits"]; ok {
+		// JSON-encoded edits are decoded by schema validation
 		switch v := editsVal.(type) {
-		case string:
-			// if JSON string is passed
-			if err := json.Unmarshal([]byte(v), &edits); err != nil {
-				return "", fmt.Errorf("invalid edits JSON: %v", err)
-			}
 		case []interface{}:
-			// if array of objects is passed
 			for _, editVal := range v {
 				if editMap, ok := editVal.(map[string]interface{}); ok {
 					edit, err := parseEditRequest(editMap)
@@ -69,7 +91,7 @@ func lspEdit(args map[string]interface{}) (string, error) {
 				}
 			}
 		default:
-			return "", fmt.Errorf("edits must be a JSON string or array of objects")
+			return "", fmt.Errorf("edits must be an array of objects")
 		}
 	} else {
 		// fallback: create one edit from old parameters for compatibility
@@ -77,24 +99,12 @@ func lspEdit(args map[string]interface{}) (string, error) {
 		endLine := len(lines)
 		newText := ""
 
-		if sl, ok := args["start_line"]; ok {
-			if slInt, ok := sl.(float64); ok {
-				startLine = int(slInt)
-			} else if slStr, ok := sl.(string); ok {
-				if parsed, err := strconv.Atoi(slStr); err == nil {
-					startLine = parsed
-				}
-			}
+		if sl, ok := toInt(args["start_line"]); ok {
+			startLine = sl
 		}
 
-		if el, ok := args["end_line"]; ok {
-			if elInt, ok := el.(float64); ok {
-				endLine = int(elInt)
-			} else if elStr, ok := el.(string); ok {
-				if parsed, err := strconv.Atoi(elStr); err == nil {
-					endLine = parsed
-				}
-			}
+		if el, ok := toInt(args["end_line"]); ok {
+			endLine = el
 		}
 
 		if nt, ok := args["new_text"].(string); ok {
@@ -128,7 +138,7 @@ func lspEdit(args map[string]interface{}) (string, error) {
 	}
 
 	// create backup
-	backupPath := pathVal + ".backup." + fmt.Sprintf("%d", time.Now().Unix())
+	backupPath := path + ".backup." + fmt.Sprintf("%d", time.Now().Unix())
 	if err := os.WriteFile(backupPath, content, 0600); err != nil {
 		return "", fmt.Errorf("failed to create backup: %v", err)
 	}
@@ -151,19 +161,19 @@ func lspEdit(args map[string]interface{}) (string, error) {
 
 	// write the result
 	newContent := strings.Join(modifiedLines, "\n")
-	if err := os.WriteFile(pathVal, []byte(newContent), 0600); err != nil {
+	if err := os.WriteFile(path, []byte(newContent), 0600); err != nil {
 		// restore from backup on error
-		if rerr := os.WriteFile(pathVal, content, 0600); rerr != nil {
+		if rerr := os.WriteFile(path, content, 0600); rerr != nil {
 			return "", fmt.Errorf("failed to write modified file: %v; also failed to restore backup: %v", err, rerr)
 		}
 		return "", fmt.Errorf("failed to write modified file: %v", err)
 	}
 
 	// validate syntax if this is a Go file
-	if strings.HasSuffix(pathVal, ".go") {
-		if err := validateGoSyntax(pathVal); err != nil {
+	if strings.HasSuffix(path, ".go") {
+		if err := validateGoSyntax(path); err != nil {
 			// restore from backup on syntax error
-			if rerr := os.WriteFile(pathVal, content, 0600); rerr != nil {
+			if rerr := os.WriteFile(path, content, 0600); rerr != nil {
 				return "", fmt.Errorf("syntax validation failed: %v; also failed to restore backup: %v", err, rerr)
 			}
 			return "", fmt.Errorf("syntax validation failed: %v", err)
@@ -181,43 +191,17 @@ func lspEdit(args map[string]interface{}) (string, error) {
 func parseEditRequest(editMap map[string]interface{}) (EditRequest, error) {
 	var edit EditRequest
 
-	if sl, ok := editMap["start_line"]; ok {
-		switch v := sl.(type) {
-		case float64:
-			edit.StartLine = int(v)
-		case int:
-			edit.StartLine = v
-		case string:
-			if parsed, err := strconv.Atoi(v); err == nil {
-				edit.StartLine = parsed
-			} else {
-				return edit, fmt.Errorf("invalid start_line: %v", v)
-			}
-		default:
-			return edit, fmt.Errorf("start_line must be a number")
-		}
-	} else {
-		return edit, fmt.Errorf("start_line is required")
+	sl, ok := toInt(editMap["start_line"])
+	if !ok {
+		return edit, fmt.Errorf("start_line must be an integer")
 	}
+	edit.StartLine = sl
 
-	if el, ok := editMap["end_line"]; ok {
-		switch v := el.(type) {
-		case float64:
-			edit.EndLine = int(v)
-		case int:
-			edit.EndLine = v
-		case string:
-			if parsed, err := strconv.Atoi(v); err == nil {
-				edit.EndLine = parsed
-			} else {
-				return edit, fmt.Errorf("invalid end_line: %v", v)
-			}
-		default:
-			return edit, fmt.Errorf("end_line must be a number")
-		}
-	} else {
-		return edit, fmt.Errorf("end_line is required")
+	el, ok := toInt(editMap["end_line"])
+	if !ok {
+		return edit, fmt.Errorf("end_line must be an integer")
 	}
+	edit.EndLine = el
 
 	if nt, ok := editMap["new_text"].(string); ok {
 		edit.NewText = nt
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -37,7 +38,7 @@ func main() {
 		},
 	}
 
-	result, err := lspEdit(args)
+	result, err := lspEdit(context.Background(), args)
 	if err != nil {
 		t.Fatalf("lspEdit failed: %v", err)
 	}
@@ -100,7 +101,7 @@ func main() {
 		},
 	}
 
-	result, err := lspEdit(args)
+	result, err := lspEdit(context.Background(), args)
 	if err != nil {
 		t.Fatalf("lspEdit failed: %v", err)
 	}
@@ -161,7 +162,7 @@ func main() {
 		},
 	}
 
-	result, err := lspEdit(args)
+	result, err := lspEdit(context.Background(), args)
 	if err != nil {
 		t.Fatalf("lspEdit failed: %v", err)
 	}
@@ -210,7 +211,7 @@ func main() {
 		},
 	}
 
-	_, err := lspEdit(args)
+	_, err := lspEdit(context.Background(), args)
 	if err == nil {
 		t.Error("Expected error for invalid start_line, but got none")
 	}
@@ -227,7 +228,7 @@ func main() {
 		},
 	}
 
-	_, err = lspEdit(args)
+	_, err = lspEdit(context.Background(), args)
 	if err == nil {
 		t.Error("Expected error for end_line < start_line, but got none")
 	}
@@ -245,7 +246,7 @@ func TestLspEdit_FileNotFound(t *testing.T) {
 		},
 	}
 
-	_, err := lspEdit(args)
+	_, err := lspEdit(context.Background(), args)
 	if err == nil {
 		t.Error("Expected error for non-existent file, but got none")
 	}
@@ -266,7 +267,7 @@ func TestLspEdit_MissingPath(t *testing.T) {
 		},
 	}
 
-	_, err := lspEdit(args)
+	_, err := lspEdit(context.Background(), args)
 	if err == nil {
 		t.Error("Expected error for missing path, but got none")
 	}
@@ -285,7 +286,7 @@ func TestLspEdit_EmptyEdits(t *testing.T) {
 		"edits": []interface{}{},
 	}
 
-	_, err := lspEdit(args)
+	_, err := lspEdit(context.Background(), args)
 	if err == nil {
 		t.Error("Expected error for empty edits, but got none")
 	}
@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vadiminshakov/autonomy/core/memory"
+)
+
+func init() {
+	// remember and forget write to the memory files, so only recall counts as read-only
+	RegisterTool(&FuncTool{
+		ToolName: "remember",
+		ToolDescription: "Save a durable fact for future tasks: build and test commands, project quirks, " +
+			"architecture decisions, user preferences. Keep it short and self-contained",
+		InputSchema: objectSchema(map[string]any{
+			"content": map[string]string{
+				"type":        "string",
+				"description": "The fact to remember",
+			},
+			"tags": map[string]any{
+				"type":        "array",
+				"items":       map[string]string{"type": "string"},
+				"description": "Keywords that help find the memory later",
+			},
+			"scope": map[string]any{
+				"type":        "string",
+				"enum":        []string{"project", "user"},
+				"description": "project (default) for facts about this repository, user for personal preferences",
+			},
+		}, "content"),
+		Fn: Remember,
+	})
+	RegisterTool(&FuncTool{
+		ToolName:        "recall",
+		ToolDescription: "Search saved memories by keywords. Use before rediscovering build commands or conventions",
+		InputSchema: objectSchema(map[string]any{
+			"query": map[string]string{
+				"type":        "string",
+				"description": "Keywords to search for; empty returns the newest memories",
+			},
+			"limit": map[string]string{"type": "integer"},
+		}),
+		IsReadOnly: true,
+		Fn:         Recall,
+	})
+	RegisterTool(&FuncTool{
+		ToolName:        "forget",
+		ToolDescription: "Delete a saved memory that is wrong or outdated, by id",
+		InputSchema: objectSchema(map[string]any{
+			"id": map[string]string{"type": "string"},
+		}, "id"),
+		Fn: Forget,
+	})
+}
+
+const defaultRecallLimit = 10
+
+func openMemory(ctx context.Context) (*memory.Store, error) {
+	wd := workDir(ctx)
+	if wd == "" {
+		return nil, errors.New("failed to detect working directory")
+	}
+	return memory.Open(wd), nil
+}
+
+// Remember stores a fact for future tasks
+func Remember(ctx context.Context, args map[string]interface{}) (string, error) {
+	content, ok := args["content"].(string)
+	if !ok || content == "" {
+		return "", fmt.Errorf("parameter 'content' must be a non-empty string")
+	}
+
+	scope := memory.ScopeProject
+	if s, ok := args["scope"].(string); ok && s != "" {
+		scope = memory.Scope(s)
+	}
+
+	var tags []string
+	if rawTags, ok := args["tags"].([]interface{}); ok {
+		for _, tag := range rawTags {
+			if s, ok := tag.(string); ok {
+				tags = append(tags, s)
+			}
+		}
+	}
+
+	store, err := openMemory(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	entry, err := store.Remember(scope, content, tags)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Remembered %s (%s)", entry.ID, entry.Scope), nil
+}
+
+// Recall searches memories by keywords
+func Recall(ctx context.Context, args map[string]interface{}) (string, error) {
+	query, _ := args["query"].(string)
+
+	limit := defaultRecallLimit
+	if l, ok := toInt(args["limit"]); ok && l > 0 {
+		limit = l
+	}
+
+	store, err := openMemory(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := store.Recall(query, limit)
+	if err != nil {
+		return "", err
+	}
+
+	if len(entries) == 0 {
+		return "No memories found", nil
+	}
+
+	return memory.Format(entries), nil
+}
+
+// Forget deletes a memory that is wrong or outdated
+func Forget(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, ok := args["id"].(string)
+	if !ok || id == "" {
+		return "", fmt.Errorf("parameter 'id' must be a non-empty string")
+	}
+
+	store, err := openMemory(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if err := store.Forget(id); err != nil {
+		if errors.Is(err, memory.ErrNotFound) {
+			return "", fmt.Errorf("memory %s not found, use recall to look up ids", id)
+		}
+		return "", err
+	}
+
+	return fmt.Sprintf("Forgot %s", id), nil
+}
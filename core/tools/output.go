@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"sync"
+)
+
+// OutputFunc receives the output of a running tool as it arrives
+type OutputFunc func(chunk string)
+
+type outputKey struct{}
+
+// WithOutput returns a context that streams the output of tools run with it to fn
+func WithOutput(ctx context.Context, fn OutputFunc) context.Context {
+	return context.WithValue(ctx, outputKey{}, fn)
+}
+
+func outputFrom(ctx context.Context) OutputFunc {
+	fn, _ := ctx.Value(outputKey{}).(OutputFunc)
+	return fn
+}
+
+// streamWriter collects command output and forwards each write to the output stream
+type streamWriter struct {
+	mu     sync.Mutex
+	buf    []byte
+	stream OutputFunc
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.buf = append(w.buf, p...)
+	w.mu.Unlock()
+
+	if w.stream != nil {
+		w.stream(string(p))
+	}
+
+	return len(p), nil
+}
+
+func (w *streamWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return string(w.buf)
+}
@@ -0,0 +1,47 @@
+//go:build !windows
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestBashKillsProcessGroup(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	_, err := bashCommand(ctx, map[string]interface{}{
+		"command": "sleep 30 & echo $! > " + pidFile + "; wait",
+	})
+	if err == nil {
+		t.Fatal("Expected error for canceled command")
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("Failed to read pid: %v", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatalf("Invalid pid: %v", err)
+	}
+
+	// the background child is killed with its group; give the kernel a moment to reap it
+	deadline := time.Now().Add(2 * time.Second)
+	for syscall.Kill(pid, 0) == nil {
+		if time.Now().After(deadline) {
+			_ = syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatal("Background process survived cancellation")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package tools
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand runs a command through bash; Windows has no process groups to signal,
+// so only bash itself is killed and WaitDelay releases the output of orphaned children
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	cmd.WaitDelay = commandWaitDelay
+	return cmd
+}
+
+func setProcessGroup(_ *exec.Cmd) {}
+
+func signalGroup(cmd *exec.Cmd, _ syscall.Signal) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
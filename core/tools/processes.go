@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"context"
+	"sync"
+)
+
+// Processes are the shell session and the background processes of one task. They live as long as
+// the task, so the working directory, variables and servers carry over between its runs.
+type Processes struct {
+	shellMu sync.Mutex // held while a command runs, so commands never interleave
+	shell   *shellSession
+
+	mu         sync.Mutex
+	next       int
+	background map[string]*backgroundProcess
+}
+
+// NewProcesses returns an empty set of processes; close it when the task ends
+func NewProcesses() *Processes {
+	return &Processes{background: make(map[string]*backgroundProcess)}
+}
+
+// sharedProcesses serve calls made without processes in their context, like those of tests
+var sharedProcesses = NewProcesses()
+
+type processesKey struct{}
+
+// WithProcesses attaches the processes of a task to the context of its tool calls
+func WithProcesses(ctx context.Context, p *Processes) context.Context {
+	return context.WithValue(ctx, processesKey{}, p)
+}
+
+func processesFrom(ctx context.Context) *Processes {
+	if p, ok := ctx.Value(processesKey{}).(*Processes); ok && p != nil {
+		return p
+	}
+	return sharedProcesses
+}
+
+// Close stops the background processes and the shell session
+func (p *Processes) Close() {
+	p.stopBackground()
+	p.closeShell()
+}
+
+// CloseShell stops the shell session of calls made without task processes
+func CloseShell() {
+	sharedProcesses.closeShell()
+}
+
+// StopBackgroundProcesses stops the background processes of calls made without task processes
+func StopBackgroundProcesses() {
+	sharedProcesses.stopBackground()
+}
@@ -1,24 +1,41 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/vadiminshakov/autonomy/core/workspace"
 )
 
 func init() {
-	Register("get_project_structure", GetProjectStructure)
+	RegisterTool(&FuncTool{
+		ToolName:        "get_project_structure",
+		ToolDescription: "View project directory tree in a textual form. Use to understand project layout",
+		InputSchema: objectSchema(map[string]any{
+			"path": map[string]string{"type": "string"},
+		}),
+		IsReadOnly: true,
+		Fn:         GetProjectStructure,
+	})
 }
 
 // GetProjectStructure returns a tree-like structure of the project starting from the working directory or the provided path
-func GetProjectStructure(args map[string]interface{}) (string, error) {
+func GetProjectStructure(ctx context.Context, args map[string]interface{}) (string, error) {
 	root := "."
 	if val, ok := args["path"].(string); ok && val != "" {
 		root = val
 	}
 
+	ws := workspace.FromContext(ctx)
+	absRoot, err := ws.Resolve(root)
+	if err != nil {
+		return "", err
+	}
+
 	ignorePatterns := []string{
 		".git",
 		".DS_Store",
@@ -33,14 +50,9 @@ func GetProjectStructure(args map[string]interface{}) (string, error) {
 
 	sb := &strings.Builder{}
 
-	absRoot, err := filepath.Abs(root)
-	if err != nil {
-		absRoot = root
-	}
-
 	sb.WriteString(fmt.Sprintf("%s/\n", filepath.Base(absRoot)))
 
-	err = buildTree(root, "", sb, ignorePatterns)
+	err = buildTree(ctx, ws, absRoot, "", sb, ignorePatterns)
 	if err != nil {
 		return "", fmt.Errorf("failed to build project structure: %v", err)
 	}
@@ -48,8 +60,12 @@ func GetProjectStructure(args map[string]interface{}) (string, error) {
 	return sb.String(), nil
 }
 
-// buildTree constructs the file tree recursively
-func buildTree(dir, prefix string, sb *strings.Builder, ignorePatterns []string) error {
+// buildTree constructs the file tree recursively until ctx ends
+func buildTree(ctx context.Context, ws *workspace.Workspace, dir, prefix string, sb *strings.Builder, ignorePatterns []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return err
@@ -57,7 +73,7 @@ func buildTree(dir, prefix string, sb *strings.Builder, ignorePatterns []string)
 
 	var filteredEntries []os.DirEntry
 	for _, entry := range entries {
-		if shouldIgnore(entry.Name(), ignorePatterns) {
+		if shouldIgnore(entry.Name(), ignorePatterns) || ws.Denied(filepath.Join(dir, entry.Name())) {
 			continue
 		}
 		filteredEntries = append(filteredEntries, entry)
@@ -89,7 +105,10 @@ func buildTree(dir, prefix string, sb *strings.Builder, ignorePatterns []string)
 
 			// recursively process subdirectory
 			subDir := filepath.Join(dir, entry.Name())
-			err := buildTree(subDir, nextPrefix, sb, ignorePatterns)
+			err := buildTree(ctx, ws, subDir, nextPrefix, sb, ignorePatterns)
+			if ctx.Err() != nil {
+				return err
+			}
 			if err != nil {
 				// continue even if subdirectory processing fails
 				sb.WriteString(fmt.Sprintf("%s    [error reading directory: %v]\n", nextPrefix, err))
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -37,7 +38,7 @@ func TestGetProjectStructure(t *testing.T) {
 		"path": tempDir,
 	}
 
-	result, err := GetProjectStructure(args)
+	result, err := GetProjectStructure(context.Background(), args)
 	require.NoError(t, err, "GetProjectStructure failed")
 
 	// verify result contains expected files
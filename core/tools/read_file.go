@@ -1,33 +1,42 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
+
+	"github.com/vadiminshakov/autonomy/core/workspace"
 )
 
 func init() {
-	Register("read_file", ReadFile)
+	RegisterTool(&FuncTool{
+		ToolName:        "read_file",
+		ToolDescription: "Read file contents",
+		InputSchema: objectSchema(map[string]any{
+			"path": map[string]string{"type": "string"},
+		}, "path"),
+		IsReadOnly: true,
+		Fn:         ReadFile,
+	})
 }
 
 const (
 	maxFileSize = 1024 * 1024
 )
 
-func ReadFile(args map[string]interface{}) (string, error) {
+func ReadFile(ctx context.Context, args map[string]interface{}) (string, error) {
 	pathVal, ok := args["path"].(string)
 	if !ok {
 		return "", fmt.Errorf("parameter 'path' must be a non-empty string")
 	}
 
-	if isSensitiveFile(pathVal) {
-		return "", fmt.Errorf("reading file '%s' blocked for security reasons", pathVal)
+	path, err := workspace.FromContext(ctx).Resolve(pathVal)
+	if err != nil {
+		return "", err
 	}
 
-	cleanPath := filepath.Clean(pathVal)
-
-	info, err := os.Stat(cleanPath)
+	info, err := os.Stat(path)
 	if err != nil {
 		return "", fmt.Errorf("failed to get info for file %s: %v", pathVal, err)
 	}
@@ -40,37 +49,13 @@ func ReadFile(args map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("path %s points to a directory, not a file", pathVal)
 	}
 
-	data, err := os.ReadFile(cleanPath)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file %s: %v", pathVal, err)
 	}
 
 	state := getTaskState()
-	state.RecordFileRead(cleanPath)
+	state.RecordFileRead(filepath.Clean(pathVal))
 
 	return string(data), nil
 }
-
-func isSensitiveFile(path string) bool {
-	path = strings.ToLower(filepath.Clean(path))
-
-	sensitiveFiles := []string{
-		"/etc/passwd", "/etc/shadow", "/etc/sudoers",
-		"/home/.ssh/id_rsa", "/root/.ssh/id_rsa",
-		"/.env", "/.env.local", "/.env.production",
-		"/id_rsa", "/id_dsa", "/id_ecdsa", "/id_ed25519",
-		"/private.key", "/server.key", "/cert.key",
-	}
-
-	for _, sensitive := range sensitiveFiles {
-		if strings.Contains(path, sensitive) {
-			return true
-		}
-	}
-
-	if strings.Contains(path, "/.ssh/") || strings.Contains(path, "/.gnupg/") {
-		return true
-	}
-
-	return false
-}
@@ -1,27 +1,105 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"sort"
+	"sync"
+
+	"github.com/vadiminshakov/autonomy/core/entity"
 )
 
 type ToolFunc func(args map[string]any) (string, error)
 
-var registry = make(map[string]ToolFunc)
+// ContextToolFunc is a tool that stops its work when the context is canceled
+type ContextToolFunc func(ctx context.Context, args map[string]any) (string, error)
 
-// ClearRegistry clears the registry (mainly for testing)
-func ClearRegistry() {
-	registry = make(map[string]ToolFunc)
+// Registry is a set of tools. Tasks and tests can use their own registries;
+// built-in tools register themselves in the default one.
+type Registry struct {
+	mu    sync.RWMutex
+	tools map[string]Tool
 }
-func Register(name string, fn ToolFunc) {
-	registry[name] = fn
+
+func NewRegistry() *Registry {
+	return &Registry{tools: make(map[string]Tool)}
 }
 
-func Execute(name string, args map[string]any) (string, error) {
-	fn, ok := registry[name]
+var defaultRegistry = NewRegistry()
+
+// Default returns the registry with the built-in tools
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register adds a tool, replacing one with the same name
+func (r *Registry) Register(tool Tool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools[tool.Name()] = tool
+}
+
+// Get returns a tool by name
+func (r *Registry) Get(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// List returns the tool names in alphabetical order
+func (r *Registry) List() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Subset returns a registry with the named tools only; unknown names are ignored
+func (r *Registry) Subset(names ...string) *Registry {
+	subset := NewRegistry()
+	for _, name := range names {
+		if tool, ok := r.Get(name); ok {
+			subset.Register(tool)
+		}
+	}
+	return subset
+}
+
+// Definitions describes the tools for the model
+func (r *Registry) Definitions() []entity.ToolDefinition {
+	names := r.List()
+	defs := make([]entity.ToolDefinition, 0, len(names))
+
+	for _, name := range names {
+		tool, ok := r.Get(name)
+		if !ok {
+			continue
+		}
+
+		defs = append(defs, entity.ToolDefinition{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			InputSchema: tool.Schema(),
+		})
+	}
+
+	return defs
+}
+
+// Execute runs a tool; context-aware tools stop when ctx is canceled
+func (r *Registry) Execute(ctx context.Context, name string, args map[string]any) (string, error) {
+	tool, ok := r.Get(name)
 	if !ok {
 		// suggest similar tool names if available
-		suggestions := getSimilarToolNames(name)
+		suggestions := r.similarNames(name)
 		if len(suggestions) > 0 {
 			return "", fmt.Errorf("tool %s not found. did you mean: %v", name, suggestions)
 		}
@@ -33,12 +111,13 @@ func Execute(name string, args map[string]any) (string, error) {
 		logToolCall(name, args)
 	}
 
-	// validate common required parameters before execution
-	if err := validateToolArgs(name, args); err != nil {
-		return "", err
+	// validate and coerce parameters before execution, so tools get the declared types
+	args, err := ValidateArgs(tool.Schema(), args)
+	if err != nil {
+		return "", fmt.Errorf("invalid arguments for tool %s: %v", name, err)
 	}
 
-	result, err := fn(args)
+	result, err := tool.Execute(ctx, args)
 
 	// record tool usage in task state (except for task state tools themselves to avoid recursion)
 	if name != "get_task_state" &&
@@ -50,41 +129,56 @@ func Execute(name string, args map[string]any) (string, error) {
 	return result, err
 }
 
-// validateToolArgs performs basic validation of tool arguments
-func validateToolArgs(toolName string, args map[string]any) error {
-	// Basic validation for common tools
-	switch toolName {
-	case "read_file", "write_file", "lsp_edit", "delete_file", "copy_file", "move_file":
-		if _, ok := args["path"]; !ok {
-			return fmt.Errorf("tool %s requires 'path' parameter. example: {\"path\": \"file.txt\"}", toolName)
-		}
-	case "bash", "interrupt_command":
-		if _, ok := args["command"]; !ok {
-			return fmt.Errorf("tool %s requires 'command' parameter. example: {\"command\": \"go run main.go\"}", toolName)
-		}
-	case "search_dir", "search_index":
-		if _, ok := args["query"]; !ok {
-			return fmt.Errorf("tool %s requires 'query' parameter. example: {\"query\": \"func main\"}", toolName)
-		}
-	case "find_files":
-		if _, ok := args["pattern"]; !ok {
-			return fmt.Errorf("tool %s requires 'pattern' parameter. example: {\"pattern\": \"*.go\"}", toolName)
-		}
-	case "make_dir", "remove_dir":
-		if _, ok := args["path"]; !ok {
-			return fmt.Errorf("tool %s requires 'path' parameter. example: {\"path\": \"src/components\"}", toolName)
+// ClearRegistry clears the default registry (mainly for testing)
+func ClearRegistry() {
+	defaultRegistry = NewRegistry()
+}
+
+// RegisterTool adds a tool to the default registry
+func RegisterTool(tool Tool) {
+	defaultRegistry.Register(tool)
+}
+
+// Register adds a function without metadata to the default registry. The function cannot be
+// stopped once it runs, so tools that may take long should use RegisterContext.
+func Register(name string, fn ToolFunc) {
+	RegisterContext(name, func(ctx context.Context, args map[string]any) (string, error) {
+		if err := ctx.Err(); err != nil {
+			return "", err
 		}
-	}
-	return nil
+		return fn(args)
+	})
 }
 
-// getSimilarToolNames returns tool names similar to the given name
-func getSimilarToolNames(name string) []string {
+// RegisterContext registers a tool that receives the task context
+func RegisterContext(name string, fn ContextToolFunc) {
+	RegisterTool(&FuncTool{ToolName: name, ToolDescription: "Internal tool " + name, Fn: fn})
+}
+
+func Execute(name string, args map[string]any) (string, error) {
+	return ExecuteContext(context.Background(), name, args)
+}
+
+// ExecuteContext runs a tool of the default registry
+func ExecuteContext(ctx context.Context, name string, args map[string]any) (string, error) {
+	return defaultRegistry.Execute(ctx, name, args)
+}
+
+func List() []string {
+	return defaultRegistry.List()
+}
+
+// GetToolDescriptions returns the definitions of the default tools
+func GetToolDescriptions() []entity.ToolDefinition {
+	return defaultRegistry.Definitions()
+}
+
+// similarNames returns tool names similar to the given name
+func (r *Registry) similarNames(name string) []string {
 	var suggestions []string
-	allTools := List()
 
 	// Simple similarity check - could be improved with edit distance
-	for _, tool := range allTools {
+	for _, tool := range r.List() {
 		// Check if tool contains the name or vice versa
 		if len(name) > 3 && (contains(tool, name) || contains(name, tool)) {
 			suggestions = append(suggestions, tool)
@@ -124,11 +218,3 @@ func logToolCall(_ string, args map[string]any) {
 		}
 	}
 }
-
-func List() []string {
-	keys := make([]string, 0, len(registry))
-	for k := range registry {
-		keys = append(keys, k)
-	}
-	return keys
-}
@@ -1,7 +1,10 @@
 package tools
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestRegister(t *testing.T) {
@@ -50,26 +53,101 @@ func TestExecuteNonExistent(t *testing.T) {
 }
 
 func TestList(t *testing.T) {
-	// Clear registry for this test
-	ClearRegistry()
+	registry := NewRegistry()
 
-	testFunc := func(args map[string]interface{}) (string, error) {
+	testFunc := func(_ context.Context, args map[string]interface{}) (string, error) {
 		return "", nil
 	}
 
-	Register("tool1", testFunc)
-	Register("tool2", testFunc)
+	registry.Register(&FuncTool{ToolName: "tool2", Fn: testFunc})
+	registry.Register(&FuncTool{ToolName: "tool1", Fn: testFunc})
 
-	tools := List()
+	tools := registry.List()
 	if len(tools) != 2 {
 		t.Errorf("Expected 2 tools, got %d", len(tools))
 	}
 
-	if !containsString(tools, "tool1") {
-		t.Error("tool1 not found in list")
+	if tools[0] != "tool1" || tools[1] != "tool2" {
+		t.Errorf("Expected sorted tool names, got %v", tools)
+	}
+}
+
+func TestRegistrySubset(t *testing.T) {
+	subset := Default().Subset("read_file", "find_files", "unknown_tool")
+
+	tools := subset.List()
+	if len(tools) != 2 || !containsString(tools, "read_file") || !containsString(tools, "find_files") {
+		t.Errorf("Expected read_file and find_files, got %v", tools)
 	}
-	if !containsString(tools, "tool2") {
-		t.Error("tool2 not found in list")
+
+	if _, err := subset.Execute(context.Background(), "bash", map[string]interface{}{"command": "true"}); err == nil {
+		t.Error("Expected error for tool outside of the subset")
+	}
+}
+
+func TestRegistryDefinitions(t *testing.T) {
+	defs := Default().Subset("read_file", "bash").Definitions()
+	if len(defs) != 2 {
+		t.Fatalf("Expected 2 definitions, got %d", len(defs))
+	}
+
+	if defs[0].Name != "bash" || defs[1].Name != "read_file" {
+		t.Errorf("Unexpected definitions order: %s, %s", defs[0].Name, defs[1].Name)
+	}
+	if defs[1].Description != "Read file contents" {
+		t.Errorf("Unexpected read_file description: %s", defs[1].Description)
+	}
+	if required := requiredArgs(defs[1].InputSchema); len(required) != 1 || required[0] != "path" {
+		t.Errorf("Expected read_file to require path, got %v", required)
+	}
+}
+
+func TestBuiltinToolMetadata(t *testing.T) {
+	for name, readOnly := range map[string]bool{
+		"read_file":  true,
+		"search_dir": true,
+		"write_file": false,
+		"lsp_edit":   false,
+		"bash":       false,
+		"recall":     true,
+		// memories end up in the prompt of later tasks
+		"remember": false,
+		"forget":   false,
+	} {
+		tool, ok := Default().Get(name)
+		if !ok {
+			t.Errorf("Tool %s is not registered", name)
+			continue
+		}
+		if tool.ReadOnly() != readOnly {
+			t.Errorf("Expected %s read-only=%v", name, readOnly)
+		}
+	}
+
+	tool, _ := Default().Get("decompose_task")
+	if tool.Timeout() != 9*time.Minute {
+		t.Errorf("Expected decompose_task timeout 9m, got %v", tool.Timeout())
+	}
+}
+
+func TestExecuteRequiresArgs(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&FuncTool{
+		ToolName:    "needs_path",
+		InputSchema: objectSchema(map[string]any{"path": map[string]string{"type": "string"}}, "path"),
+		Fn: func(_ context.Context, _ map[string]any) (string, error) {
+			return "ok", nil
+		},
+	})
+
+	_, err := registry.Execute(context.Background(), "needs_path", map[string]interface{}{})
+	if err == nil || err.Error() != "invalid arguments for tool needs_path: 'path': missing required field" {
+		t.Errorf("Expected missing parameter error, got %v", err)
+	}
+
+	result, err := registry.Execute(context.Background(), "needs_path", map[string]interface{}{"path": "a"})
+	if err != nil || result != "ok" {
+		t.Errorf("Expected ok, got %q, %v", result, err)
 	}
 }
 
@@ -82,3 +160,32 @@ func containsString(slice []string, item string) bool {
 	}
 	return false
 }
+
+func TestExecuteContextPassesContext(t *testing.T) {
+	RegisterContext("test_context_tool", func(ctx context.Context, args map[string]interface{}) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ExecuteContext(ctx, "test_context_tool", map[string]interface{}{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestBashCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := ExecuteContext(ctx, "bash", map[string]interface{}{"command": "sleep 30"})
+	if err == nil {
+		t.Error("Expected error for canceled command")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Command was not killed on cancellation")
+	}
+}
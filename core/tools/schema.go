@@ -0,0 +1,275 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidateArgs checks arguments against a JSON schema and returns a copy with coerced values:
+// numeric strings become integers or numbers, "true"/"false" become booleans and
+// JSON-encoded strings become arrays or objects. Integers are returned as int.
+// All problems are reported together, each prefixed with the path of the field.
+func ValidateArgs(schema map[string]any, args map[string]any) (map[string]any, error) {
+	if args == nil {
+		args = map[string]any{}
+	}
+
+	var problems []string
+	coerced := validateValue(schemaOf(schema), args, "", &problems)
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	result, _ := coerced.(map[string]any)
+	return result, nil
+}
+
+func validateValue(schema map[string]any, value any, path string, problems *[]string) any {
+	kind, _ := schema["type"].(string)
+
+	var result any
+	var ok bool
+
+	switch kind {
+	case "object":
+		result, ok = validateObject(schema, value, path, problems)
+	case "array":
+		result, ok = validateArray(schema, value, path, problems)
+	case "string":
+		result, ok = toString(value)
+	case "integer":
+		result, ok = toInt(value)
+	case "number":
+		result, ok = toFloat(value)
+	case "boolean":
+		result, ok = toBool(value)
+	default:
+		// untyped schema accepts anything
+		result, ok = value, true
+	}
+
+	if !ok {
+		*problems = append(*problems, fmt.Sprintf("%s: expected %s, got %s", fieldName(path), kind, describe(value)))
+		return value
+	}
+
+	if allowed := enumOf(schema); len(allowed) > 0 && !inEnum(result, allowed) {
+		*problems = append(*problems, fmt.Sprintf("%s: must be one of %s, got %s",
+			fieldName(path), formatEnum(allowed), describe(value)))
+	}
+
+	return result
+}
+
+func validateObject(schema map[string]any, value any, path string, problems *[]string) (any, bool) {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		if s, isString := value.(string); isString {
+			ok = json.Unmarshal([]byte(s), &obj) == nil && obj != nil
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+
+	result := make(map[string]any, len(obj))
+	for k, v := range obj {
+		result[k] = v
+	}
+
+	required := make(map[string]bool)
+	for _, name := range requiredArgs(schema) {
+		required[name] = true
+		if _, present := obj[name]; !present {
+			*problems = append(*problems, fmt.Sprintf("%s: missing required field", fieldName(fieldPath(path, name))))
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		// null stands for an omitted optional field, a required one is checked against its type
+		v, present := obj[name]
+		if !present || v == nil && !required[name] {
+			continue
+		}
+		result[name] = validateValue(schemaOf(properties[name]), v, fieldPath(path, name), problems)
+	}
+
+	return result, true
+}
+
+func validateArray(schema map[string]any, value any, path string, problems *[]string) (any, bool) {
+	items, ok := value.([]any)
+	if !ok {
+		if s, isString := value.(string); isString {
+			ok = json.Unmarshal([]byte(s), &items) == nil && items != nil
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+
+	itemSchema := schemaOf(schema["items"])
+	result := make([]any, len(items))
+	for i, item := range items {
+		result[i] = validateValue(itemSchema, item, fmt.Sprintf("%s[%d]", path, i), problems)
+	}
+
+	return result, true
+}
+
+// schemaOf normalizes schema literals, which tools declare as map[string]string or map[string]any
+func schemaOf(v any) map[string]any {
+	switch s := v.(type) {
+	case map[string]any:
+		return s
+	case map[string]string:
+		result := make(map[string]any, len(s))
+		for k, val := range s {
+			result[k] = val
+		}
+		return result
+	default:
+		return map[string]any{}
+	}
+}
+
+func enumOf(schema map[string]any) []any {
+	switch values := schema["enum"].(type) {
+	case []any:
+		return values
+	case []string:
+		result := make([]any, len(values))
+		for i, v := range values {
+			result[i] = v
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+func inEnum(value any, allowed []any) bool {
+	for _, a := range allowed {
+		if a == value {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(allowed []any) string {
+	parts := make([]string, len(allowed))
+	for i, a := range allowed {
+		parts[i] = fmt.Sprintf("%q", fmt.Sprint(a))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func toString(v any) (any, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(s), true
+	case bool:
+		return strconv.FormatBool(s), true
+	default:
+		return nil, false
+	}
+}
+
+// toInt converts JSON numbers and numeric strings to int; fractional values are rejected
+func toInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		if n != math.Trunc(n) || math.IsInf(n, 0) {
+			return 0, false
+		}
+		return int(n), true
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func toFloat(v any) (any, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	default:
+		return nil, false
+	}
+}
+
+func toBool(v any) (any, bool) {
+	switch b := v.(type) {
+	case bool:
+		return b, true
+	case string:
+		parsed, err := strconv.ParseBool(strings.TrimSpace(b))
+		return parsed, err == nil
+	default:
+		return nil, false
+	}
+}
+
+func describe(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		if len(val) > 40 {
+			val = val[:40] + "..."
+		}
+		return fmt.Sprintf("string %q", val)
+	case float64, int:
+		return fmt.Sprintf("number %v", val)
+	case bool:
+		return fmt.Sprintf("boolean %v", val)
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func fieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func fieldName(path string) string {
+	if path == "" {
+		return "arguments"
+	}
+	return "'" + path + "'"
+}
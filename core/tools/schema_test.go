@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+var editSchema = objectSchema(map[string]any{
+	"path": map[string]string{"type": "string"},
+	"edits": map[string]any{
+		"type": "array",
+		"items": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"start_line": map[string]string{"type": "integer"},
+				"end_line":   map[string]string{"type": "integer"},
+				"new_text":   map[string]string{"type": "string"},
+			},
+			"required": []string{"start_line", "end_line", "new_text"},
+		},
+	},
+	"force": map[string]string{"type": "boolean"},
+	"mode":  map[string]any{"type": "string", "enum": []string{"insert", "replace"}},
+}, "path", "edits")
+
+func TestValidateArgsCoerces(t *testing.T) {
+	args, err := ValidateArgs(editSchema, map[string]any{
+		"path":  "main.go",
+		"edits": `[{"start_line": "3", "end_line": 4.0, "new_text": "x"}]`,
+		"force": "true",
+		"extra": "kept",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	edits, ok := args["edits"].([]any)
+	if !ok || len(edits) != 1 {
+		t.Fatalf("Expected decoded edits array, got %#v", args["edits"])
+	}
+	edit := edits[0].(map[string]any)
+	if edit["start_line"] != 3 || edit["end_line"] != 4 {
+		t.Errorf("Expected integer lines, got %#v", edit)
+	}
+	if args["force"] != true {
+		t.Errorf("Expected boolean force, got %#v", args["force"])
+	}
+	if args["extra"] != "kept" {
+		t.Error("Unknown fields should be passed through")
+	}
+}
+
+func TestValidateArgsReportsFields(t *testing.T) {
+	_, err := ValidateArgs(editSchema, map[string]any{
+		"edits": []any{
+			map[string]any{"start_line": 1.5, "end_line": 2, "new_text": "x"},
+			map[string]any{"start_line": 1, "new_text": "x"},
+		},
+		"force": "maybe",
+		"mode":  "append",
+	})
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+
+	for _, want := range []string{
+		"'path': missing required field",
+		"'edits[0].start_line': expected integer, got number 1.5",
+		"'edits[1].end_line': missing required field",
+		`'force': expected boolean, got string "maybe"`,
+		`'mode': must be one of "insert", "replace", got string "append"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in error: %v", want, err)
+		}
+	}
+}
+
+func TestValidateArgsRejectsWrongContainer(t *testing.T) {
+	_, err := ValidateArgs(editSchema, map[string]any{"path": "a", "edits": "not json"})
+	if err == nil || !strings.Contains(err.Error(), `'edits': expected array, got string "not json"`) {
+		t.Errorf("Expected array type error, got %v", err)
+	}
+}
+
+func TestValidateArgsRejectsNullRequiredField(t *testing.T) {
+	_, err := ValidateArgs(editSchema, map[string]any{"path": nil, "edits": []any{}, "mode": nil})
+	if err == nil || !strings.Contains(err.Error(), "'path': expected string, got null") {
+		t.Errorf("Expected null type error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "'mode'") {
+		t.Errorf("Expected null optional field to be accepted: %v", err)
+	}
+
+	if _, err := Execute("bash_output", map[string]any{"id": nil}); err == nil {
+		t.Error("Expected bash_output to reject a null id")
+	}
+}
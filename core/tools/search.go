@@ -1,26 +1,52 @@
 package tools
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/vadiminshakov/autonomy/core/workspace"
 )
 
 func init() {
-	Register("search_dir", SearchDir)
-	Register("find_files", FindFiles)
+	RegisterTool(&FuncTool{
+		ToolName:        "search_dir",
+		ToolDescription: "Search text pattern recursively in directory",
+		InputSchema: objectSchema(map[string]any{
+			"path":  map[string]string{"type": "string"},
+			"query": map[string]string{"type": "string"},
+		}, "query"),
+		IsReadOnly: true,
+		Fn:         SearchDir,
+	})
+	RegisterTool(&FuncTool{
+		ToolName:        "find_files",
+		ToolDescription: "Find files by glob pattern. Use before read_file to verify file exists",
+		InputSchema: objectSchema(map[string]any{
+			"pattern": map[string]string{"type": "string"},
+		}, "pattern"),
+		IsReadOnly: true,
+		Fn:         FindFiles,
+	})
 }
 
 // SearchDir searches for a text query inside files under a directory.
-func SearchDir(args map[string]interface{}) (string, error) {
+func SearchDir(ctx context.Context, args map[string]interface{}) (string, error) {
 	rootDir, ok := args["path"].(string)
 	if !ok || rootDir == "" {
 		rootDir = "." // default to current directory
 	}
 
+	ws := workspace.FromContext(ctx)
+	root, err := ws.Resolve(rootDir)
+	if err != nil {
+		return "", err
+	}
+
 	query, ok := args["query"].(string)
 	if !ok || query == "" {
 		return "", fmt.Errorf("parameter 'query' is required for search_dir")
@@ -33,7 +59,7 @@ func SearchDir(args map[string]interface{}) (string, error) {
 		caseInsensitive = (val == "true" || val == "1")
 	}
 
-	results, err := searchInDir(rootDir, query, caseInsensitive)
+	results, err := searchInDir(ctx, ws, root, query, caseInsensitive)
 	if err != nil {
 		return "", fmt.Errorf("search error: %v", err)
 	}
@@ -47,6 +73,10 @@ func SearchDir(args map[string]interface{}) (string, error) {
 
 	totalMatches := 0
 	for filePath, matches := range results {
+		// paths are shown the way the directory was given
+		if rel, err := filepath.Rel(root, filePath); err == nil {
+			filePath = filepath.Join(rootDir, rel)
+		}
 		output.WriteString(fmt.Sprintf("\n📄 %s (%d matches):\n", filePath, len(matches)))
 		for _, match := range matches {
 			output.WriteString(fmt.Sprintf("  %d: %s\n", match.LineNumber, match.Text))
@@ -69,8 +99,12 @@ type searchMatch struct {
 // in all files under the given directory (including subdirectories).
 // If caseInsensitive is true, search ignores letter case.
 //
+// Files the workspace denies are skipped, and the walk stops when ctx ends.
+//
 // Returns a map: file path => list of matched lines (line numbers and text)
-func searchInDir(rootDir string, query string, caseInsensitive bool) (map[string][]searchMatch, error) {
+func searchInDir(
+	ctx context.Context, ws *workspace.Workspace, rootDir string, query string, caseInsensitive bool,
+) (map[string][]searchMatch, error) {
 	if rootDir == "" || query == "" {
 		return nil, errors.New("rootDir and query must be non-empty")
 	}
@@ -80,6 +114,16 @@ func searchInDir(rootDir string, query string, caseInsensitive bool) (map[string
 		if err != nil {
 			return err
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if ws.Denied(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
 		if d.IsDir() {
 			return nil
@@ -136,12 +180,18 @@ func searchFileForQuery(path, query string, caseInsensitive bool) ([]searchMatch
 }
 
 // FindFiles searches for files by name/pattern
-func FindFiles(args map[string]interface{}) (string, error) {
+func FindFiles(ctx context.Context, args map[string]interface{}) (string, error) {
 	rootDir, ok := args["path"].(string)
 	if !ok || rootDir == "" {
 		rootDir = "." // default to current directory
 	}
 
+	ws := workspace.FromContext(ctx)
+	root, err := ws.Resolve(rootDir)
+	if err != nil {
+		return "", err
+	}
+
 	pattern, ok := args["pattern"].(string)
 	if !ok || pattern == "" {
 		return "", fmt.Errorf("parameter 'pattern' is required for find_files")
@@ -154,7 +204,7 @@ func FindFiles(args map[string]interface{}) (string, error) {
 		caseInsensitive = (val == "true" || val == "1")
 	}
 
-	foundFiles, err := findFilesByName(rootDir, pattern, caseInsensitive)
+	foundFiles, err := findFilesByName(ctx, ws, root, pattern, caseInsensitive)
 	if err != nil {
 		return "", fmt.Errorf("file search error: %v", err)
 	}
@@ -175,8 +225,9 @@ func FindFiles(args map[string]interface{}) (string, error) {
 	return output.String(), nil
 }
 
-// findFilesByName searches files by name/pattern inside a directory.
-func findFilesByName(rootDir, pattern string, caseInsensitive bool) ([]string, error) {
+// findFilesByName searches files by name/pattern inside a directory, skipping the ones the workspace denies.
+// The walk stops when ctx ends.
+func findFilesByName(ctx context.Context, ws *workspace.Workspace, rootDir, pattern string, caseInsensitive bool) ([]string, error) {
 	var foundFiles []string
 
 	searchPattern := pattern
@@ -188,6 +239,16 @@ func findFilesByName(rootDir, pattern string, caseInsensitive bool) ([]string, e
 		if err != nil {
 			return nil // ignore access errors to files
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if ws.Denied(path) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
 		if info.IsDir() {
 			return nil
@@ -1,10 +1,15 @@
 package tools
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadiminshakov/autonomy/core/workspace"
 )
 
 func TestSearchDir(t *testing.T) {
@@ -39,7 +44,7 @@ func TestSearchDir(t *testing.T) {
 		"query": "search target",
 	}
 
-	result, err := SearchDir(args)
+	result, err := SearchDir(context.Background(), args)
 	if err != nil {
 		t.Fatalf("search failed: %v", err)
 	}
@@ -79,7 +84,7 @@ func TestSearchDirNoResults(t *testing.T) {
 		"query": "nonexistent",
 	}
 
-	result, err := SearchDir(args)
+	result, err := SearchDir(context.Background(), args)
 	if err != nil {
 		t.Fatalf("search failed: %v", err)
 	}
@@ -110,7 +115,7 @@ func TestSearchDirCaseInsensitive(t *testing.T) {
 		"case_insensitive": true,
 	}
 
-	result, err := SearchDir(args)
+	result, err := SearchDir(context.Background(), args)
 	if err != nil {
 		t.Fatalf("search failed: %v", err)
 	}
@@ -125,7 +130,7 @@ func TestSearchDirMissingQuery(t *testing.T) {
 		"path": ".",
 	}
 
-	_, err := SearchDir(args)
+	_, err := SearchDir(context.Background(), args)
 	if err == nil {
 		t.Error("expected error when 'query' parameter is missing")
 	}
@@ -167,7 +172,7 @@ func TestFindFiles(t *testing.T) {
 		"pattern": ".go",
 	}
 
-	result, err := FindFiles(args)
+	result, err := FindFiles(context.Background(), args)
 	if err != nil {
 		t.Fatalf("file search failed: %v", err)
 	}
@@ -192,7 +197,7 @@ func TestFindFilesMissingPattern(t *testing.T) {
 		"path": ".",
 	}
 
-	_, err := FindFiles(args)
+	_, err := FindFiles(context.Background(), args)
 	if err == nil {
 		t.Error("expected error when 'pattern' parameter is missing")
 	}
@@ -200,3 +205,55 @@ func TestFindFilesMissingPattern(t *testing.T) {
 		t.Errorf("Unexpected error message: %v", err)
 	}
 }
+
+func TestFileToolsStayInWorkspace(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main // token"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".env"), []byte("API_KEY=token"), 0o644))
+
+	w, err := workspace.New(workspace.Config{}, root)
+	require.NoError(t, err)
+	ctx := workspace.WithWorkspace(context.Background(), w)
+
+	result, err := SearchDir(ctx, map[string]interface{}{"path": root, "query": "token"})
+	require.NoError(t, err)
+	require.Contains(t, result, "main.go")
+	require.NotContains(t, result, ".env")
+
+	_, err = ReadFile(ctx, map[string]interface{}{"path": filepath.Join(root, ".env")})
+	require.ErrorIs(t, err, workspace.ErrDenied)
+
+	for _, call := range []struct {
+		fn   func(context.Context, map[string]interface{}) (string, error)
+		args map[string]interface{}
+	}{
+		{ReadFile, map[string]interface{}{"path": filepath.Join(root, "..", "other.txt")}},
+		{WriteFile, map[string]interface{}{"path": filepath.Join(t.TempDir(), "x.txt"), "content": "x"}},
+		{SearchDir, map[string]interface{}{"path": "/", "query": "token"}},
+		{GetProjectStructure, map[string]interface{}{"path": filepath.Dir(root)}},
+	} {
+		_, err := call.fn(ctx, call.args)
+		require.ErrorContains(t, err, "outside the workspace", call.args)
+	}
+}
+
+func TestFileToolsStopWithContext(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sub", "main.go"), []byte("package main // token"), 0o644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, call := range []struct {
+		fn   func(context.Context, map[string]interface{}) (string, error)
+		args map[string]interface{}
+	}{
+		{SearchDir, map[string]interface{}{"path": root, "query": "token"}},
+		{FindFiles, map[string]interface{}{"path": root, "pattern": "main"}},
+		{GetProjectStructure, map[string]interface{}{"path": root}},
+	} {
+		_, err := call.fn(ctx, call.args)
+		require.ErrorContains(t, err, "context canceled", call.args)
+	}
+}
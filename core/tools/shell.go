@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/vadiminshakov/autonomy/core/sandbox"
+	"github.com/vadiminshakov/autonomy/core/workspace"
+)
+
+func init() {
+	RegisterTool(&FuncTool{
+		ToolName: "reset_shell",
+		ToolDescription: "Restart the shell used by bash: working directory, variables and functions " +
+			"return to their initial state. Use when the shell is stuck or its environment is broken",
+		Fn: resetShell,
+	})
+}
+
+// shellSession is a long-lived bash that keeps the working directory, variables and
+// functions between bash calls. Each command is followed by a sentinel line carrying
+// its exit code and the new working directory.
+type shellSession struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	lines    chan string
+	done     chan struct{}
+	sentinel string
+	dir      string
+	sandbox  *sandbox.Sandbox
+}
+
+// workDir returns the directory commands start in: the working directory of the task's workspace,
+// or of the process when the context has no workspace
+func workDir(ctx context.Context) string {
+	if dir := workspace.FromContext(ctx).Dir(); dir != "" {
+		return dir
+	}
+	wd, _ := os.Getwd()
+	return wd
+}
+
+func startShell(sb *sandbox.Sandbox, dir string) (*shellSession, error) {
+	cmd := exec.Command("bash", "--noprofile", "--norc")
+	cmd.Dir = dir
+	setProcessGroup(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open shell stdin: %v", err)
+	}
+
+	// commands write stdout and stderr into one pipe, so their output keeps its order
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open shell output: %v", err)
+	}
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	if err := sb.Wrap(cmd); err != nil {
+		stdin.Close()
+		reader.Close()
+		writer.Close()
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		reader.Close()
+		writer.Close()
+		return nil, fmt.Errorf("failed to start shell: %v", err)
+	}
+	writer.Close()
+
+	token := make([]byte, 8)
+	_, _ = rand.Read(token)
+
+	s := &shellSession{
+		cmd:      cmd,
+		stdin:    stdin,
+		lines:    make(chan string, 64),
+		done:     make(chan struct{}),
+		sentinel: "__AUTONOMY_DONE_" + hex.EncodeToString(token) + "__",
+		dir:      dir,
+		sandbox:  sb,
+	}
+
+	go func() {
+		defer close(s.lines)
+		defer reader.Close()
+
+		r := bufio.NewReader(reader)
+		for {
+			line, err := r.ReadString('\n')
+			if line != "" {
+				s.lines <- line
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		_ = cmd.Wait()
+		close(s.done)
+	}()
+
+	return s, nil
+}
+
+// run executes a command in the session and returns its output and exit code.
+// The session is unusable after an error and has to be closed.
+func (s *shellSession) run(ctx context.Context, command string) (string, int, error) {
+	// stdin of the command is detached, so it cannot swallow the lines that follow it
+	script := fmt.Sprintf("{\n%s\n} </dev/null\nprintf '%%s %%d %%s\\n' '%s' \"$?\" \"$PWD\"\n", command, s.sentinel)
+	if _, err := io.WriteString(s.stdin, script); err != nil {
+		return "", 0, fmt.Errorf("shell is not running: %v", err)
+	}
+
+	stream := outputFrom(ctx)
+
+	var output strings.Builder
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				return output.String(), 0, fmt.Errorf("shell exited")
+			}
+
+			if idx := strings.Index(line, s.sentinel); idx >= 0 {
+				output.WriteString(line[:idx])
+				if stream != nil && idx > 0 {
+					stream(line[:idx])
+				}
+				return output.String(), s.parseStatus(line[idx+len(s.sentinel):]), nil
+			}
+
+			output.WriteString(line)
+			if stream != nil {
+				stream(line)
+			}
+
+		case <-ctx.Done():
+			return output.String(), 0, ctx.Err()
+		}
+	}
+}
+
+// parseStatus reads the exit code and working directory that follow the sentinel
+func (s *shellSession) parseStatus(status string) int {
+	fields := strings.SplitN(strings.TrimSpace(status), " ", 2)
+
+	code, _ := strconv.Atoi(fields[0])
+	if len(fields) == 2 {
+		s.dir = fields[1]
+	}
+
+	return code
+}
+
+func (s *shellSession) close() {
+	// unblock the reader, output nobody waits for is discarded
+	go func() {
+		for range s.lines {
+		}
+	}()
+
+	_ = s.stdin.Close()
+	_ = signalGroup(s.cmd, syscall.SIGKILL)
+	<-s.done
+}
+
+// runInShell runs a command in the task's shell session, starting one if needed
+func runInShell(ctx context.Context, command string) (string, int, error) {
+	// a command that does not parse would leave the shell waiting for more input
+	if out, err := exec.CommandContext(ctx, "bash", "-n", "-c", command).CombinedOutput(); err != nil {
+		return "", 0, fmt.Errorf("syntax error: %s", strings.TrimSpace(string(out)))
+	}
+
+	p := processesFrom(ctx)
+	p.shellMu.Lock()
+	defer p.shellMu.Unlock()
+
+	// the sandbox is reloaded on every run and may have changed since the shell started
+	sb := sandbox.FromContext(ctx)
+	if p.shell != nil && p.shell.sandbox != sb {
+		p.shell.close()
+		p.shell = nil
+	}
+
+	if p.shell == nil {
+		s, err := startShell(sb, workDir(ctx))
+		if err != nil {
+			return "", 0, err
+		}
+		p.shell = s
+	}
+
+	output, code, err := p.shell.run(ctx, command)
+	if err != nil {
+		// a canceled command may still be running, and an exited shell is gone: start over next time
+		p.shell.close()
+		p.shell = nil
+		return output, 0, fmt.Errorf("%v; the shell session was reset, working directory and variables are lost", err)
+	}
+
+	return output, code, nil
+}
+
+// shellDir returns the working directory of the shell session, or "" when no shell runs
+func (p *Processes) shellDir() string {
+	p.shellMu.Lock()
+	defer p.shellMu.Unlock()
+
+	if p.shell == nil {
+		return ""
+	}
+	return p.shell.dir
+}
+
+func (p *Processes) closeShell() {
+	p.shellMu.Lock()
+	defer p.shellMu.Unlock()
+
+	if p.shell != nil {
+		p.shell.close()
+		p.shell = nil
+	}
+}
+
+func resetShell(ctx context.Context, _ map[string]interface{}) (string, error) {
+	processesFrom(ctx).closeShell()
+	return "shell session reset", nil
+}
+
+// shellDirNote warns the model when the shell and the file tools resolve relative paths differently
+func shellDirNote(ctx context.Context, dir string) string {
+	wd := workDir(ctx)
+	if wd == "" || dir == "" || filepath.Clean(dir) == filepath.Clean(wd) {
+		return ""
+	}
+	return fmt.Sprintf("\n[shell working directory: %s; file tools resolve relative paths from %s]", dir, wd)
+}
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadiminshakov/autonomy/core/sandbox"
+	"github.com/vadiminshakov/autonomy/core/workspace"
+)
+
+// the test binary starts the sandboxed commands of the sandbox tests
+func TestMain(m *testing.M) {
+	sandbox.Main()
+	os.Exit(m.Run())
+}
+
+func TestShellKeepsState(t *testing.T) {
+	t.Cleanup(CloseShell)
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o755))
+
+	setup := "cd " + dir + "/sub && export GREETING=hi && greet() { echo \"$GREETING $1\"; }"
+	_, err := Execute("bash", map[string]interface{}{"command": setup})
+	require.NoError(t, err)
+
+	result, err := Execute("bash", map[string]interface{}{"command": "pwd; greet there"})
+	require.NoError(t, err)
+	require.Contains(t, result, dir+"/sub\nhi there")
+	require.Contains(t, result, "[shell working directory: "+dir+"/sub")
+
+	_, err = Execute("reset_shell", map[string]interface{}{})
+	require.NoError(t, err)
+
+	result, err = Execute("bash", map[string]interface{}{"command": "echo \"[$GREETING]\""})
+	require.NoError(t, err)
+	require.Equal(t, "[]", result)
+}
+
+func TestCommandsStartInWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	ws, err := workspace.New(workspace.Config{}, dir)
+	require.NoError(t, err)
+
+	processes := NewProcesses()
+	t.Cleanup(processes.Close)
+	ctx := WithProcesses(workspace.WithWorkspace(context.Background(), ws), processes)
+
+	result, err := bashCommand(ctx, map[string]interface{}{"command": "pwd"})
+	require.NoError(t, err)
+	require.Equal(t, dir, result, "the shell starts in the project and adds no note about it")
+
+	result, err = InterruptCommandContext(ctx, map[string]interface{}{"command": "pwd"})
+	require.NoError(t, err)
+	require.Contains(t, result, dir)
+}
+
+func TestShellExitCodes(t *testing.T) {
+	t.Cleanup(CloseShell)
+
+	result, err := Execute("bash", map[string]interface{}{"command": "printf partial; false"})
+	require.EqualError(t, err, "command failed: exit status 1")
+	require.Equal(t, "partial", result)
+
+	_, err = Execute("bash", map[string]interface{}{"command": "echo 'unterminated"})
+	require.ErrorContains(t, err, "syntax error")
+
+	_, err = Execute("bash", map[string]interface{}{"command": "export KEPT=1; exit 7"})
+	require.ErrorContains(t, err, "shell session was reset")
+
+	// the next command gets a fresh shell
+	result, err = Execute("bash", map[string]interface{}{"command": "echo \"ok$KEPT\""})
+	require.NoError(t, err)
+	require.Equal(t, "ok", result)
+}
+
+func TestShellCommandCannotReadScript(t *testing.T) {
+	t.Cleanup(CloseShell)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := bashCommand(ctx, map[string]interface{}{"command": "cat; echo after"})
+	require.NoError(t, err)
+	require.Equal(t, "after", result)
+}
+
+func TestShellSandbox(t *testing.T) {
+	t.Cleanup(CloseShell)
+
+	project := t.TempDir()
+	sb := sandbox.New(sandbox.Config{Enabled: true, Backend: sandbox.BackendNative}, project)
+	if err := sb.Check(); err != nil {
+		t.Skipf("sandbox is not available: %v", err)
+	}
+	ctx := sandbox.WithSandbox(context.Background(), sb)
+
+	_, err := ExecuteContext(ctx, "bash", map[string]interface{}{"command": "cd " + project + " && echo hi > a.txt"})
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(project, "a.txt"))
+
+	// the sandboxed shell is a separate session without network interfaces besides loopback
+	_, err = Execute("bash", map[string]interface{}{"command": "export PLAIN=1"})
+	require.NoError(t, err)
+	result, err := ExecuteContext(ctx, "bash", map[string]interface{}{"command": "echo \"[$PLAIN]\"; tail -n +3 /proc/net/dev | wc -l"})
+	require.NoError(t, err)
+	require.Equal(t, "[]\n1", result)
+
+	for _, tool := range []string{"bash", "interrupt_command"} {
+		result, err = ExecuteContext(ctx, tool, map[string]interface{}{"command": "touch /usr/autonomy-sandbox-test"})
+		require.Error(t, err, tool)
+		require.Contains(t, result, "Read-only file system", tool)
+	}
+	require.NoFileExists(t, "/usr/autonomy-sandbox-test")
+}
@@ -0,0 +1,356 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/vadiminshakov/autonomy/core/workspace"
+)
+
+const (
+	// snippetContext is the number of unchanged lines shown around a replacement
+	snippetContext = 3
+	// maxSnippetLines bounds the snippet returned after a replacement
+	maxSnippetLines = 40
+)
+
+func init() {
+	RegisterTool(&FuncTool{
+		ToolName: "str_replace",
+		ToolDescription: "Replace exact text in an EXISTING file. old_string must match the file exactly, including " +
+			"indentation, and be unique unless replace_all is set; include a few surrounding lines to make it unique. " +
+			"Preferred for targeted edits, since it does not depend on line numbers",
+		InputSchema: objectSchema(map[string]any{
+			"path":        map[string]string{"type": "string"},
+			"old_string":  map[string]string{"type": "string"},
+			"new_string":  map[string]string{"type": "string"},
+			"replace_all": map[string]string{"type": "boolean"},
+		}, "path", "old_string", "new_string"),
+		Fn: strReplace,
+	})
+}
+
+// span is the byte range of a match
+type span struct {
+	start, end int
+}
+
+//nolint:gocyclo
+func strReplace(ctx context.Context, args map[string]interface{}) (string, error) {
+	pathVal, ok := args["path"].(string)
+	if !ok || strings.TrimSpace(pathVal) == "" {
+		return "", fmt.Errorf("parameter 'path' must be a non-empty string")
+	}
+
+	oldString, ok := args["old_string"].(string)
+	if !ok || oldString == "" {
+		return "", fmt.Errorf("parameter 'old_string' must be a non-empty string")
+	}
+
+	newString, ok := args["new_string"].(string)
+	if !ok {
+		return "", fmt.Errorf("parameter 'new_string' must be a string")
+	}
+
+	if oldString == newString {
+		return "", fmt.Errorf("old_string and new_string are identical, nothing to replace")
+	}
+
+	replaceAll, _ := args["replace_all"].(bool)
+
+	path, err := workspace.FromContext(ctx).Resolve(strings.TrimSpace(pathVal))
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("file does not exist: %s", pathVal)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get info for file %s: %v", pathVal, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("path %s points to a directory, not a file", pathVal)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+
+	r, err := replaceString(pathVal, string(data), oldString, newString, replaceAll)
+	if err != nil {
+		return "", err
+	}
+
+	var warning string
+	if r.loose {
+		warning = fmt.Sprintf("warning: old_string did not match exactly; it was matched ignoring whitespace at line %s. "+
+			"The replacement was reindented to match the file\n", r.lines)
+	}
+
+	if err := os.WriteFile(path, []byte(r.content), info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("failed to write modified file: %v", err)
+	}
+
+	if strings.HasSuffix(path, ".go") {
+		if err := validateGoSyntax(path); err != nil {
+			// restore the original on syntax error
+			if rerr := os.WriteFile(path, data, info.Mode().Perm()); rerr != nil {
+				return "", fmt.Errorf("syntax validation failed: %v; also failed to restore the file: %v", err, rerr)
+			}
+			return "", fmt.Errorf("syntax validation failed, the file is unchanged: %v", err)
+		}
+	}
+
+	getTaskState().RecordFileModified(filepath.Clean(pathVal))
+
+	return fmt.Sprintf("%sreplaced %d occurrence(s) in %s:\n%s", warning, r.count, pathVal,
+		numberedSnippet(r.content, r.first)), nil
+}
+
+// replacement is the outcome of a str_replace edit
+type replacement struct {
+	content string
+	// first is the span of the first replacement in content
+	first span
+	count int
+	// lines lists the lines the matches started at
+	lines string
+	// loose is set when old text only matched ignoring whitespace
+	loose bool
+}
+
+// ReplaceString applies a str_replace edit of the file name to its content, matching the text the way the tool does
+func ReplaceString(name, content, oldString, newString string, replaceAll bool) (string, error) {
+	r, err := replaceString(name, content, oldString, newString, replaceAll)
+	return r.content, err
+}
+
+func replaceString(name, content, oldString, newString string, replaceAll bool) (replacement, error) {
+	r := replacement{}
+
+	matches := exactMatches(content, oldString)
+	replacements := make([]string, len(matches))
+	for i := range replacements {
+		replacements[i] = newString
+	}
+
+	if len(matches) == 0 {
+		matches, replacements = looseMatches(content, oldString, newString)
+		if len(matches) == 0 {
+			return r, fmt.Errorf("old_string not found in %s; read the file again and copy the text exactly, "+
+				"including indentation", name)
+		}
+		r.loose = true
+	}
+
+	r.count, r.lines = len(matches), matchLines(content, matches)
+	if len(matches) > 1 && !replaceAll {
+		return r, fmt.Errorf("old_string matches %d times in %s (lines %s); include more surrounding lines to make it unique, "+
+			"or set replace_all", len(matches), name, r.lines)
+	}
+
+	r.content, r.first = replaceSpans(content, matches, replacements)
+	return r, nil
+}
+
+// exactMatches returns the non-overlapping occurrences of s in content
+func exactMatches(content, s string) []span {
+	var matches []span
+	for offset := 0; ; {
+		idx := strings.Index(content[offset:], s)
+		if idx < 0 {
+			return matches
+		}
+		start := offset + idx
+		matches = append(matches, span{start, start + len(s)})
+		offset = start + len(s)
+	}
+}
+
+// looseMatches finds the lines of old text whose words match but whose whitespace differs, e.g. tabs
+// instead of spaces or a different indentation. Only whole lines match, so "if a" does not match "if ab".
+// Each match gets the new text reindented from the indentation of old text to the one of the file.
+func looseMatches(content, oldString, newString string) ([]span, []string) {
+	oldLines := trimBlankLines(strings.Split(oldString, "\n"))
+	if len(oldLines) == 0 {
+		return nil, nil
+	}
+	newLines := trimBlankLines(strings.Split(newString, "\n"))
+
+	lines := strings.SplitAfter(content, "\n")
+	offsets := make([]int, len(lines)+1)
+	for i, line := range lines {
+		offsets[i+1] = offsets[i] + len(line)
+	}
+
+	var matches []span
+	var replacements []string
+
+	for i := 0; i+len(oldLines) <= len(lines); i++ {
+		matched := lines[i : i+len(oldLines)]
+		if !sameWords(matched, oldLines) {
+			continue
+		}
+
+		last := matched[len(matched)-1]
+		m := span{offsets[i], offsets[i+len(oldLines)-1] + len(strings.TrimRight(last, "\r\n"))}
+
+		sep := "\n"
+		if strings.HasSuffix(last, "\r\n") {
+			sep = "\r\n"
+		}
+		if len(newLines) == 0 {
+			// deleted lines take their line break with them
+			m.end = offsets[i+len(oldLines)]
+		}
+
+		matches = append(matches, m)
+		replacements = append(replacements, strings.Join(reindent(newLines, oldLines, matched), sep))
+		i += len(oldLines) - 1
+	}
+
+	return matches, replacements
+}
+
+// sameWords reports whether the lines have the same words, ignoring whitespace
+func sameWords(lines, other []string) bool {
+	for i := range lines {
+		if strings.Join(strings.Fields(lines[i]), " ") != strings.Join(strings.Fields(other[i]), " ") {
+			return false
+		}
+	}
+	return true
+}
+
+// trimBlankLines drops the blank lines at both ends
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// reindent moves new lines from the indentation of the old lines to the one of the file lines they matched:
+// a line indented like an old line gets the indentation of its file line, deeper lines one file level per old level
+func reindent(newLines, oldLines, fileLines []string) []string {
+	mapped := make(map[string]string)
+	var indents []string
+	for i, line := range oldLines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		from := indentation(line)
+		if _, ok := mapped[from]; !ok {
+			mapped[from] = indentation(fileLines[i])
+			indents = append(indents, from)
+		}
+	}
+	oldStep, fileStep := indentStep(indents, mapped)
+
+	out := make([]string, len(newLines))
+	for i, line := range newLines {
+		indent := indentation(line)
+		body := strings.TrimRight(line[len(indent):], "\r")
+		if body == "" {
+			continue
+		}
+
+		// the deepest old indentation the line starts with
+		base, found := "", false
+		for _, from := range indents {
+			if strings.HasPrefix(indent, from) && (!found || len(from) > len(base)) {
+				base, found = from, true
+			}
+		}
+		if !found {
+			out[i] = indent + body
+			continue
+		}
+
+		rest := indent[len(base):]
+		if levels := len(rest) / max(len(oldStep), 1); oldStep != "" && rest == strings.Repeat(oldStep, levels) {
+			rest = strings.Repeat(fileStep, levels)
+		}
+		out[i] = mapped[base] + rest + body
+	}
+
+	return out
+}
+
+// indentStep finds one level of indentation in old text and in the file, e.g. four spaces and a tab
+func indentStep(indents []string, mapped map[string]string) (string, string) {
+	var oldStep, fileStep string
+	for _, a := range indents {
+		for _, b := range indents {
+			deeper := len(b) > len(a) && strings.HasPrefix(b, a) && strings.HasPrefix(mapped[b], mapped[a])
+			if deeper && (oldStep == "" || len(b)-len(a) < len(oldStep)) {
+				oldStep, fileStep = b[len(a):], mapped[b][len(mapped[a]):]
+			}
+		}
+	}
+	return oldStep, fileStep
+}
+
+// indentation returns the leading spaces and tabs of a line
+func indentation(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// replaceSpans replaces each match with its replacement and returns the new content with the span of the first one
+func replaceSpans(content string, matches []span, replacements []string) (string, span) {
+	var out strings.Builder
+	var first span
+
+	last := 0
+	for i, m := range matches {
+		out.WriteString(content[last:m.start])
+		if i == 0 {
+			first = span{out.Len(), out.Len() + len(replacements[i])}
+		}
+		out.WriteString(replacements[i])
+		last = m.end
+	}
+	out.WriteString(content[last:])
+
+	return out.String(), first
+}
+
+// matchLines lists the lines the matches start at
+func matchLines(content string, matches []span) string {
+	lines := make([]string, len(matches))
+	for i, m := range matches {
+		lines[i] = strconv.Itoa(strings.Count(content[:m.start], "\n") + 1)
+	}
+	return strings.Join(lines, ", ")
+}
+
+// numberedSnippet shows the lines of a span with a few lines around it, numbered like cat -n
+func numberedSnippet(content string, s span) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	startLine := strings.Count(content[:s.start], "\n") + 1
+	endLine := startLine + strings.Count(content[s.start:s.end], "\n")
+
+	from := max(1, startLine-snippetContext)
+	to := min(len(lines), endLine+snippetContext)
+
+	var out strings.Builder
+	for n := from; n <= to; n++ {
+		if n-from == maxSnippetLines {
+			fmt.Fprintf(&out, "   ... %d more lines\n", to-n+1)
+			break
+		}
+		fmt.Fprintf(&out, "%6d\t%s\n", n, lines[n-1])
+	}
+
+	return out.String()
+}
@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// replaceIn runs str_replace on a new file with content and returns the result and the file afterwards
+func replaceIn(t *testing.T, content string, args map[string]interface{}) (string, string, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if name, ok := args["path"].(string); ok {
+		path = filepath.Join(filepath.Dir(path), name)
+	}
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o640))
+	args["path"] = path
+
+	result, err := strReplace(context.Background(), args)
+
+	data, rerr := os.ReadFile(path)
+	require.NoError(t, rerr)
+	info, rerr := os.Stat(path)
+	require.NoError(t, rerr)
+	require.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+
+	return result, string(data), err
+}
+
+func TestStrReplace(t *testing.T) {
+	content := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\n"
+
+	result, data, err := replaceIn(t, content, map[string]interface{}{"old_string": "four\nfive", "new_string": "4\n5\n5.5"})
+	require.NoError(t, err)
+	require.Equal(t, "one\ntwo\nthree\n4\n5\n5.5\nsix\nseven\neight\n", data)
+	require.Regexp(t, `^replaced 1 occurrence\(s\) in /.*file\.txt:\n`, result)
+	require.Contains(t, result, "     1\tone\n     2\ttwo\n     3\tthree\n     4\t4\n     5\t5\n     6\t5.5\n"+
+		"     7\tsix\n     8\tseven\n     9\teight\n")
+	require.NotContains(t, result, "warning")
+}
+
+func TestStrReplaceAnchors(t *testing.T) {
+	content := "a := 1\nb := 1\nc := 2\n"
+
+	_, data, err := replaceIn(t, content, map[string]interface{}{"old_string": "d := 3", "new_string": "d := 4"})
+	require.ErrorContains(t, err, "old_string not found")
+	require.Equal(t, content, data)
+
+	_, data, err = replaceIn(t, content, map[string]interface{}{"old_string": ":= 1", "new_string": ":= 0"})
+	require.ErrorContains(t, err, "old_string matches 2 times")
+	require.ErrorContains(t, err, "lines 1, 2")
+	require.Equal(t, content, data)
+
+	result, data, err := replaceIn(t, content, map[string]interface{}{"old_string": ":= 1", "new_string": ":= 0", "replace_all": true})
+	require.NoError(t, err)
+	require.Equal(t, "a := 0\nb := 0\nc := 2\n", data)
+	require.Contains(t, result, "replaced 2 occurrence(s)")
+
+	_, _, err = replaceIn(t, content, map[string]interface{}{"old_string": "c := 2", "new_string": "c := 2"})
+	require.ErrorContains(t, err, "identical")
+}
+
+func TestStrReplaceIgnoringWhitespace(t *testing.T) {
+	content := "func main() {\n\tif ok {\n\t\tprintln(\"hi\")\n\t}\n}\n"
+
+	// the model indented with spaces where the file uses tabs
+	result, data, err := replaceIn(t, content, map[string]interface{}{
+		"old_string": "    if ok {\n        println(\"hi\")\n    }",
+		"new_string": "    if ok {\n        println(\"hello\")\n    }",
+	})
+	require.NoError(t, err)
+	require.Contains(t, result, "warning: old_string did not match exactly; it was matched ignoring whitespace at line 2")
+	require.Equal(t, "func main() {\n\tif ok {\n\t\tprintln(\"hello\")\n\t}\n}\n", data)
+
+	// the replacement takes the indentation of the file, including lines nested deeper than old text
+	_, data, err = replaceIn(t, content, map[string]interface{}{
+		"old_string": "  if ok {\n    println(\"hi\")",
+		"new_string": "  if ok {\n    for {\n      println(\"hi\")\n    }",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "func main() {\n\tif ok {\n\t\tfor {\n\t\t\tprintln(\"hi\")\n\t\t}\n\t}\n}\n", data)
+
+	// only whole lines match
+	_, data, err = replaceIn(t, "if ab {\n}\n", map[string]interface{}{"old_string": "if  a", "new_string": "if b"})
+	require.ErrorContains(t, err, "old_string not found")
+	require.Equal(t, "if ab {\n}\n", data)
+}
+
+func TestStrReplaceKeepsValidGo(t *testing.T) {
+	content := "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"
+
+	_, data, err := replaceIn(t, content, map[string]interface{}{
+		"path":       "main.go",
+		"old_string": "println(\"hi\")\n}",
+		"new_string": "println(\"hi\"",
+	})
+	require.ErrorContains(t, err, "syntax validation failed")
+	require.Equal(t, content, data)
+}
@@ -11,11 +11,25 @@ import (
 )
 
 func init() {
-	Register("decompose_task", DecomposeTask)
+	RegisterTool(&FuncTool{
+		ToolName: "decompose_task",
+		ToolDescription: "Task decomposition: breaks complex tasks into executable steps using intelligent analysis. " +
+			"Use for multi-step tasks",
+		InputSchema: objectSchema(map[string]any{
+			"task_description": map[string]any{
+				"type":        "string",
+				"description": "Detailed description of the complex task to be broken down into steps",
+			},
+		}, "task_description"),
+		IsReadOnly:     true,
+		DefaultTimeout: 9 * time.Minute,
+		Fn:             DecomposeTask,
+	})
 }
 
-// DecomposeTask breaks down a complex task into executable steps using AI
-func DecomposeTask(args map[string]interface{}) (string, error) {
+// DecomposeTask breaks down a complex task into executable steps using AI;
+// the tool timeout and task cancellation apply through ctx
+func DecomposeTask(ctx context.Context, args map[string]interface{}) (string, error) {
 	taskDesc, ok := args["task_description"].(string)
 	if !ok || taskDesc == "" {
 		return "", fmt.Errorf("parameter 'task_description' must be a non-empty string")
@@ -37,9 +51,6 @@ func DecomposeTask(args map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("failed to create task decomposer: %v", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-	defer cancel()
-
 	result, err := decomposer.DecomposeTask(ctx, taskDesc)
 	if err != nil {
 		return "", fmt.Errorf("failed to decompose task: %v", err)
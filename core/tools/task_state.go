@@ -1,16 +1,36 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
 
 func init() {
-	Register("get_task_state", getTaskStateAsJSON)
-	Register("reset_task_state", resetTaskState)
-	Register("check_tool_usage", checkToolUsage)
+	RegisterTool(&FuncTool{
+		ToolName:        "get_task_state",
+		ToolDescription: "Get current task execution state as JSON. Use to track what has been done",
+		IsReadOnly:      true,
+		Fn:              getTaskStateAsJSON,
+	})
+	RegisterTool(&FuncTool{
+		ToolName:        "reset_task_state",
+		ToolDescription: "Reset task execution state. Use carefully",
+		IsReadOnly:      true,
+		Fn:              resetTaskState,
+	})
+	RegisterTool(&FuncTool{
+		ToolName:        "check_tool_usage",
+		ToolDescription: "Check if and how many times a specific tool has been used",
+		InputSchema: objectSchema(map[string]any{
+			"tool": map[string]string{"type": "string"},
+		}, "tool"),
+		IsReadOnly: true,
+		Fn:         checkToolUsage,
+	})
 }
 
 // TaskState tracks the state of task execution
@@ -34,7 +54,7 @@ var (
 )
 
 // getTaskStateAsJSON returns the current task state as JSON
-func getTaskStateAsJSON(args map[string]interface{}) (string, error) {
+func getTaskStateAsJSON(_ context.Context, args map[string]interface{}) (string, error) {
 	state := getTaskState()
 
 	data, err := json.MarshalIndent(state, "", "  ")
@@ -46,14 +66,14 @@ func getTaskStateAsJSON(args map[string]interface{}) (string, error) {
 }
 
 // resetTaskState resets the task state
-func resetTaskState(args map[string]interface{}) (string, error) {
+func resetTaskState(_ context.Context, args map[string]interface{}) (string, error) {
 	state := getTaskState()
 	state.Reset()
 	return "Task state has been reset", nil
 }
 
 // checkToolUsage checks if a tool has been used and how many times
-func checkToolUsage(args map[string]interface{}) (string, error) {
+func checkToolUsage(_ context.Context, args map[string]interface{}) (string, error) {
 	toolName, ok := args["tool"].(string)
 	if !ok {
 		return "", fmt.Errorf("tool parameter is required")
@@ -193,6 +213,52 @@ func (ts *TaskState) Reset() {
 	ts.Context = make(map[string]interface{})
 }
 
+// Snapshot returns a copy of the state, to restore it when the same work continues later
+func (ts *TaskState) Snapshot() *TaskState {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	snapshot := &TaskState{
+		StartTime:        ts.StartTime,
+		CompletedTools:   make(map[string]int, len(ts.CompletedTools)),
+		CreatedFiles:     append([]string{}, ts.CreatedFiles...),
+		ModifiedFiles:    append([]string{}, ts.ModifiedFiles...),
+		ReadFiles:        append([]string{}, ts.ReadFiles...),
+		ExecutedCommands: append([]string{}, ts.ExecutedCommands...),
+		Errors:           append([]string{}, ts.Errors...),
+		LastToolResult:   ts.LastToolResult,
+		LastToolSuccess:  ts.LastToolSuccess,
+		Context:          make(map[string]interface{}, len(ts.Context)),
+	}
+	for k, v := range ts.CompletedTools {
+		snapshot.CompletedTools[k] = v
+	}
+	for k, v := range ts.Context {
+		snapshot.Context[k] = v
+	}
+
+	return snapshot
+}
+
+// Restore replaces the state with a snapshot taken earlier
+func (ts *TaskState) Restore(snapshot *TaskState) {
+	c := snapshot.Snapshot()
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.StartTime = c.StartTime
+	ts.CompletedTools = c.CompletedTools
+	ts.CreatedFiles = c.CreatedFiles
+	ts.ModifiedFiles = c.ModifiedFiles
+	ts.ReadFiles = c.ReadFiles
+	ts.ExecutedCommands = c.ExecutedCommands
+	ts.Errors = c.Errors
+	ts.LastToolResult = c.LastToolResult
+	ts.LastToolSuccess = c.LastToolSuccess
+	ts.Context = c.Context
+}
+
 // ClearErrors clears only errors, leaving other state
 func (ts *TaskState) ClearErrors() {
 	ts.mu.Lock()
@@ -206,3 +272,56 @@ func (ts *TaskState) LastToolSucceeded() bool {
 	defer ts.mu.RUnlock()
 	return ts.LastToolSuccess
 }
+
+// ChangedFiles returns created and modified files without duplicates
+func (ts *TaskState) ChangedFiles() []string {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var files []string
+
+	for _, list := range [][]string{ts.CreatedFiles, ts.ModifiedFiles} {
+		for _, f := range list {
+			if !seen[f] {
+				seen[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+
+	return files
+}
+
+// Summary returns a compact, human-readable description of the task state
+func (ts *TaskState) Summary() string {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	var parts []string
+
+	if len(ts.CreatedFiles) > 0 {
+		parts = append(parts, fmt.Sprintf("Created files: %s", strings.Join(ts.CreatedFiles, ", ")))
+	}
+
+	if len(ts.ModifiedFiles) > 0 {
+		parts = append(parts, fmt.Sprintf("Modified files: %s", strings.Join(ts.ModifiedFiles, ", ")))
+	}
+
+	if len(ts.ExecutedCommands) > 0 {
+		parts = append(parts, fmt.Sprintf("Recent commands: %s", strings.Join(lastN(ts.ExecutedCommands, 5), "; ")))
+	}
+
+	if len(ts.Errors) > 0 {
+		parts = append(parts, fmt.Sprintf("Recent errors:\n%s", strings.Join(lastN(ts.Errors, 3), "\n")))
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+func lastN(items []string, n int) []string {
+	if len(items) > n {
+		return items[len(items)-n:]
+	}
+	return items
+}
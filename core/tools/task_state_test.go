@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
@@ -57,7 +58,7 @@ func TestTaskStateTools(t *testing.T) {
 	state.Reset()
 
 	// test get_task_state tool
-	result, err := getTaskStateAsJSON(map[string]interface{}{})
+	result, err := getTaskStateAsJSON(context.Background(), map[string]interface{}{})
 	require.NoError(t, err, "get_task_state failed")
 
 	var stateData map[string]interface{}
@@ -68,27 +69,27 @@ func TestTaskStateTools(t *testing.T) {
 	require.True(t, ok, "Task state should contain start_time")
 
 	// test check_tool_usage tool for unused tool first
-	usage, err := checkToolUsage(map[string]interface{}{"tool": "test_tool"})
+	usage, err := checkToolUsage(context.Background(), map[string]interface{}{"tool": "test_tool"})
 	require.NoError(t, err, "check_tool_usage failed")
 	require.Contains(t, usage, "has not been used yet", "Unused tool check incorrect")
 
 	// test check_tool_usage tool after recording usage
 	state.RecordToolUse("test_tool", true, "success")
-	usage, err = checkToolUsage(map[string]interface{}{"tool": "test_tool"})
+	usage, err = checkToolUsage(context.Background(), map[string]interface{}{"tool": "test_tool"})
 	require.NoError(t, err, "check_tool_usage failed")
 	require.Contains(t, usage, "has been used 1 times", "Tool usage check incorrect")
 
 	// test checking unused tool
-	usage, err = checkToolUsage(map[string]interface{}{"tool": "unused_tool"})
+	usage, err = checkToolUsage(context.Background(), map[string]interface{}{"tool": "unused_tool"})
 	require.NoError(t, err, "check_tool_usage failed")
 	require.Contains(t, usage, "has not been used yet", "Unused tool check incorrect")
 
 	// test missing parameter
-	_, err = checkToolUsage(map[string]interface{}{})
+	_, err = checkToolUsage(context.Background(), map[string]interface{}{})
 	require.Error(t, err, "check_tool_usage should fail without tool parameter")
 
 	// test reset_task_state tool
-	_, err = resetTaskState(map[string]interface{}{})
+	_, err = resetTaskState(context.Background(), map[string]interface{}{})
 	require.NoError(t, err, "reset_task_state failed")
 	require.Equal(t, 0, len(state.CompletedTools), "State should be reset")
 }
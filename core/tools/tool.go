@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"time"
+)
+
+// Tool is an action the agent can take, together with the definition the model sees
+type Tool interface {
+	Name() string
+	Description() string
+	// Schema is the JSON schema of the arguments
+	Schema() map[string]any
+	// ReadOnly reports whether the tool leaves the workspace unchanged and runs no commands
+	ReadOnly() bool
+	// Timeout is how long a call may run; zero means the task default
+	Timeout() time.Duration
+	Execute(ctx context.Context, args map[string]any) (string, error)
+}
+
+// FuncTool adapts a function and its metadata to Tool
+type FuncTool struct {
+	ToolName        string
+	ToolDescription string
+	InputSchema     map[string]any
+	IsReadOnly      bool
+	DefaultTimeout  time.Duration
+	Fn              ContextToolFunc
+}
+
+func (t *FuncTool) Name() string           { return t.ToolName }
+func (t *FuncTool) Description() string    { return t.ToolDescription }
+func (t *FuncTool) ReadOnly() bool         { return t.IsReadOnly }
+func (t *FuncTool) Timeout() time.Duration { return t.DefaultTimeout }
+
+func (t *FuncTool) Schema() map[string]any {
+	if t.InputSchema == nil {
+		return objectSchema(nil)
+	}
+	return t.InputSchema
+}
+
+func (t *FuncTool) Execute(ctx context.Context, args map[string]any) (string, error) {
+	return t.Fn(ctx, args)
+}
+
+// objectSchema builds the JSON schema of an arguments object
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	if required == nil {
+		required = []string{}
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// requiredArgs lists the required argument names of a schema
+func requiredArgs(schema map[string]any) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []any:
+		names := make([]string, 0, len(required))
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	default:
+		return nil
+	}
+}
@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -85,18 +86,21 @@ func (fve *FileValidationEngine) ValidateFile(ctx context.Context, filePath stri
 	return results
 }
 
-// ValidateModifiedFiles validates all recently modified files
+// ValidateModifiedFiles validates all files created or modified during the task; relative paths
+// start from the working directory of the workspace in ctx. Files that no longer exist are skipped.
 func (fve *FileValidationEngine) ValidateModifiedFiles(ctx context.Context) map[string][]*ValidationResult {
-	state := getTaskState()
-	state.mu.RLock()
-	modifiedFiles := make([]string, len(state.ModifiedFiles))
-	copy(modifiedFiles, state.ModifiedFiles)
-	state.mu.RUnlock()
-
 	results := make(map[string][]*ValidationResult)
 
-	for _, filePath := range modifiedFiles {
-		fileResults := fve.ValidateFile(ctx, filePath)
+	for _, filePath := range getTaskState().ChangedFiles() {
+		path := filePath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workDir(ctx), path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		fileResults := fve.ValidateFile(ctx, path)
 		if len(fileResults) > 0 {
 			results[filePath] = fileResults
 		}
@@ -105,6 +109,19 @@ func (fve *FileValidationEngine) ValidateModifiedFiles(ctx context.Context) map[
 	return results
 }
 
+// HasValidationErrors reports whether any validator failed
+func HasValidationErrors(results map[string][]*ValidationResult) bool {
+	for _, fileResults := range results {
+		for _, result := range fileResults {
+			if !result.Success {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // GetFileExtension returns the file extension without the dot
 func GetFileExtension(filePath string) string {
 	ext := filepath.Ext(filePath)
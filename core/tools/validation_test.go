@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadiminshakov/autonomy/core/workspace"
+)
+
+type fakeValidator struct {
+	validated []string
+}
+
+func (fv *fakeValidator) Name() string { return "fake" }
+
+func (fv *fakeValidator) CanValidate(filePath string) bool {
+	return GetFileExtension(filePath) == "txt"
+}
+
+func (fv *fakeValidator) Validate(_ context.Context, filePath string) *ValidationResult {
+	fv.validated = append(fv.validated, filePath)
+	return &ValidationResult{ValidatorName: fv.Name(), FilePath: filePath, Success: false, Errors: []string{"bad"}}
+}
+
+func TestValidateModifiedFiles(t *testing.T) {
+	state := getTaskState()
+	state.Reset()
+
+	dir := t.TempDir()
+	created := filepath.Join(dir, "created.txt")
+	modified := filepath.Join(dir, "modified.txt")
+	require.NoError(t, os.WriteFile(created, []byte("a"), 0600))
+	require.NoError(t, os.WriteFile(modified, []byte("b"), 0600))
+
+	state.RecordFileCreated(created)
+	state.RecordFileModified(modified)
+	state.RecordFileModified(created)
+	state.RecordFileModified(filepath.Join(dir, "deleted.txt"))
+
+	validator := &fakeValidator{}
+	engine := &FileValidationEngine{}
+	engine.RegisterValidator(validator)
+
+	results := engine.ValidateModifiedFiles(context.Background())
+
+	require.Len(t, results, 2)
+	require.ElementsMatch(t, []string{created, modified}, validator.validated)
+	require.True(t, HasValidationErrors(results))
+
+	// relative paths start from the working directory of the workspace, not of the process
+	state.Reset()
+	state.RecordFileCreated("relative.txt")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "relative.txt"), []byte("c"), 0600))
+
+	ws, err := workspace.New(workspace.Config{}, dir)
+	require.NoError(t, err)
+
+	validator.validated = nil
+	results = engine.ValidateModifiedFiles(workspace.WithWorkspace(context.Background(), ws))
+	require.Contains(t, results, "relative.txt")
+	require.Equal(t, []string{filepath.Join(dir, "relative.txt")}, validator.validated)
+}
+
+func TestHasValidationErrors(t *testing.T) {
+	require.False(t, HasValidationErrors(nil))
+	require.False(t, HasValidationErrors(map[string][]*ValidationResult{
+		"a.go": {{Success: true}},
+	}))
+}
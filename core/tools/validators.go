@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -105,8 +106,8 @@ func (gv *GoValidator) checkGoFmt(ctx context.Context, filePath string) error {
 
 func (gv *GoValidator) checkGoVet(ctx context.Context, filePath string) error {
 	// run go vet on the directory containing the file
-	dir := filepath.Dir(filePath)
-	cmd := exec.CommandContext(ctx, "go", "vet", dir)
+	cmd := exec.CommandContext(ctx, "go", "vet", ".")
+	cmd.Dir = filepath.Dir(filePath)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
@@ -119,8 +120,8 @@ func (gv *GoValidator) checkGoVet(ctx context.Context, filePath string) error {
 
 func (gv *GoValidator) checkCompilation(ctx context.Context, filePath string) error {
 	// try to build the package containing the file
-	dir := filepath.Dir(filePath)
-	cmd := exec.CommandContext(ctx, "go", "build", "-o", "/dev/null", dir)
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", os.DevNull, ".")
+	cmd.Dir = filepath.Dir(filePath)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
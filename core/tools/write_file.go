@@ -1,38 +1,39 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/vadiminshakov/autonomy/core/workspace"
 )
 
 func init() {
-	Register("write_file", WriteFile)
+	RegisterTool(&FuncTool{
+		ToolName: "write_file",
+		ToolDescription: "Create a NEW file or FULLY REPLACE an entire file ONLY when explicitly instructed. " +
+			"Do NOT use for partial edits. If the file exists and only changes are needed, use str_replace or lsp_edit instead",
+		InputSchema: objectSchema(map[string]any{
+			"path":    map[string]string{"type": "string"},
+			"content": map[string]string{"type": "string"},
+		}, "path", "content"),
+		Fn: WriteFile,
+	})
 }
 
 //nolint:gocyclo
-func WriteFile(args map[string]interface{}) (string, error) {
-	var pathVal string
-	var ok bool
-	if pathVal, ok = args["path"].(string); !ok {
-		if pathVal, ok = args["file"].(string); !ok {
-			if pathVal, ok = args["fileName"].(string); !ok {
-				if pathVal, ok = args["file_path"].(string); !ok {
-					return "", fmt.Errorf("parameter path, file, fileName or file_path must be a non-empty string")
-				}
-			}
-		}
+func WriteFile(ctx context.Context, args map[string]interface{}) (string, error) {
+	pathVal, ok := args["path"].(string)
+	if !ok || strings.TrimSpace(pathVal) == "" {
+		return "", fmt.Errorf("parameter 'path' must be a non-empty string")
 	}
-	// support aliases: content, code, fileContent
-	var contentVal string
-	if contentVal, ok = args["content"].(string); !ok {
-		if contentVal, ok = args["code"].(string); !ok {
-			if contentVal, ok = args["fileContent"].(string); !ok {
-				return "", fmt.Errorf("parameter content, code or fileContent must be a non-empty string")
-			}
-		}
+
+	contentVal, ok := args["content"].(string)
+	if !ok {
+		return "", fmt.Errorf("parameter 'content' must be a string")
 	}
 
 	// ensure content is not empty or whitespace
@@ -40,8 +41,10 @@ func WriteFile(args map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("parameter 'content' is empty – file will not be created")
 	}
 
-	// clean path to prevent directory traversal
-	cleanPath := filepath.Clean(pathVal)
+	cleanPath, err := workspace.FromContext(ctx).Resolve(pathVal)
+	if err != nil {
+		return "", err
+	}
 
 	// check if file exists with the same content
 	if existingContent, err := os.ReadFile(cleanPath); err == nil {
@@ -86,9 +89,9 @@ func WriteFile(args map[string]interface{}) (string, error) {
 
 	state := getTaskState()
 	if _, err := os.Stat(cleanPath); err == nil {
-		state.RecordFileModified(cleanPath)
+		state.RecordFileModified(filepath.Clean(pathVal))
 	} else {
-		state.RecordFileCreated(cleanPath)
+		state.RecordFileCreated(filepath.Clean(pathVal))
 	}
 
 	return fmt.Sprintf("file %s successfully written (%d bytes)", pathVal, len(contentVal)), nil
@@ -96,7 +99,8 @@ func WriteFile(args map[string]interface{}) (string, error) {
 
 // validateGoFile validates Go file syntax
 func validateGoFile(filePath string) error {
-	cmd := exec.Command("go", "build", "-o", "/dev/null", filePath)
+	cmd := exec.Command("go", "build", "-o", "/dev/null", filepath.Base(filePath))
+	cmd.Dir = filepath.Dir(filePath)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		// extract only the important part of the error
 		errorMsg := string(output)
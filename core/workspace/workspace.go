@@ -0,0 +1,310 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/vadiminshakov/autonomy/core/instructions"
+)
+
+// ErrDenied is wrapped by the errors of paths the file tools must not use
+var ErrDenied = errors.New("access denied")
+
+// DefaultDeny are the globs of secrets and repository internals that are always denied
+var DefaultDeny = []string{
+	"**/.git/**",
+	".ssh", ".gnupg",
+	".env", ".env.local", ".env.production",
+	"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519",
+	"private.key", "server.key", "cert.key",
+}
+
+// Config is the "workspace" section of config.json
+type Config struct {
+	// Allow lists directories outside the workspace root the file tools may use; "~" is the home directory
+	Allow []string `json:"allow,omitempty"`
+	// Deny lists globs of paths the file tools must not use, in addition to DefaultDeny.
+	// A glob without a slash matches any path element, e.g. "*.pem"; one with a slash is
+	// matched from the workspace root, e.g. "config/secrets/**", unless it is absolute or starts with "~/".
+	Deny []string `json:"deny,omitempty"`
+}
+
+// Workspace confines the file tools to the root of a project and the allowed directories
+type Workspace struct {
+	root    string
+	dir     string
+	allowed []string
+	deny    []rule
+}
+
+// rule is a compiled deny glob
+type rule struct {
+	glob     string
+	re       *regexp.Regexp
+	element  bool
+	absolute bool
+}
+
+// New returns the workspace of a task working in dir: the repository containing dir,
+// or dir itself outside a repository
+func New(cfg Config, dir string) (*Workspace, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Workspace{root: instructions.FindRoot(dir), dir: dir}
+
+	for _, path := range append([]string{w.root}, cfg.Allow...) {
+		path = filepath.Clean(expandHome(path))
+		if !filepath.IsAbs(path) {
+			return nil, fmt.Errorf("allowed directory %q must be absolute", path)
+		}
+		w.allowed = append(w.allowed, path)
+		// symlinks are resolved before paths are checked, but deny globs also see paths as written
+		if real, err := resolveSymlinks(path); err == nil && real != path {
+			w.allowed = append(w.allowed, real)
+		}
+	}
+
+	for _, glob := range append(append([]string{}, DefaultDeny...), cfg.Deny...) {
+		r, err := compileRule(glob)
+		if err != nil {
+			return nil, err
+		}
+		w.deny = append(w.deny, r)
+	}
+
+	return w, nil
+}
+
+// Default returns the workspace used without a task: any path may be used, but the default deny globs apply
+func Default() *Workspace {
+	w := &Workspace{}
+	for _, glob := range DefaultDeny {
+		r, _ := compileRule(glob)
+		w.deny = append(w.deny, r)
+	}
+	return w
+}
+
+// Root returns the root of the workspace, or "" when paths are not confined
+func (w *Workspace) Root() string {
+	return w.root
+}
+
+// Resolve returns the absolute path the file tools use for path. Relative paths start from the
+// working directory of the task. The path must lie in the workspace after following symlinks,
+// including the ones a new file would be created through, and must not match a deny glob.
+func (w *Workspace) Resolve(path string) (string, error) {
+	abs, err := w.abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	real, err := resolveSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+
+	if w.root != "" && !w.contains(real) {
+		if w.contains(abs) {
+			return "", fmt.Errorf("%w: %s leads through a symlink to %s, outside the workspace %s", ErrDenied, path, real, w.root)
+		}
+		return "", fmt.Errorf("%w: %s is outside the workspace %s", ErrDenied, path, w.root)
+	}
+
+	for _, candidate := range []string{abs, real} {
+		if glob, ok := w.denied(candidate); ok {
+			return "", fmt.Errorf("%w: %s matches the denied pattern %q", ErrDenied, path, glob)
+		}
+	}
+
+	return abs, nil
+}
+
+// Denied reports whether a path matches a deny glob, without following symlinks;
+// tools that walk directories use it to skip files
+func (w *Workspace) Denied(path string) bool {
+	abs, err := w.abs(path)
+	if err != nil {
+		return true
+	}
+	_, ok := w.denied(abs)
+	return ok
+}
+
+// Dir returns the directory relative paths are resolved from; "" is the working directory of the process
+func (w *Workspace) Dir() string {
+	return w.dir
+}
+
+func (w *Workspace) abs(path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", errors.New("empty path")
+	}
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path), nil
+	}
+	if w.dir == "" {
+		return filepath.Abs(path)
+	}
+	return filepath.Join(w.dir, path), nil
+}
+
+func (w *Workspace) contains(path string) bool {
+	for _, dir := range w.allowed {
+		if within(path, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Workspace) denied(path string) (string, bool) {
+	rel := filepath.ToSlash(w.relative(path))
+	abs := filepath.ToSlash(path)
+
+	for _, r := range w.deny {
+		switch {
+		case r.absolute:
+			if r.re.MatchString(abs) {
+				return r.glob, true
+			}
+		case r.element:
+			for _, element := range strings.Split(rel, "/") {
+				if r.re.MatchString(element) {
+					return r.glob, true
+				}
+			}
+		default:
+			if r.re.MatchString(rel) {
+				return r.glob, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// relative returns path relative to the allowed directory that contains it, or path itself
+func (w *Workspace) relative(path string) string {
+	for _, dir := range w.allowed {
+		if rel, err := filepath.Rel(dir, path); err == nil && within(path, dir) {
+			return rel
+		}
+	}
+	return strings.TrimPrefix(path, string(filepath.Separator))
+}
+
+func within(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// compileRule turns a glob into a regular expression: "**" matches any number of path
+// elements, "*" and "?" match within one element
+func compileRule(glob string) (rule, error) {
+	r := rule{glob: glob}
+
+	pattern := filepath.ToSlash(expandHome(glob))
+	switch {
+	case strings.HasPrefix(pattern, "/"):
+		r.absolute = true
+	case !strings.Contains(pattern, "/"):
+		r.element = true
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "/**") && i+3 == len(pattern):
+			expr.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expr.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return rule{}, fmt.Errorf("invalid deny pattern %q: %w", glob, err)
+	}
+	r.re = re
+
+	return r, nil
+}
+
+// resolveSymlinks follows the symlinks of a path that may not exist yet: the existing part is
+// resolved, and a dangling symlink is followed to where a new file would be created
+func resolveSymlinks(path string) (string, error) {
+	rest := ""
+	for i := 0; i < 255; i++ {
+		real, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(real, rest), nil
+		}
+
+		if target, err := os.Readlink(path); err == nil {
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+			path = target
+			continue
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(path, rest), nil
+		}
+		rest = filepath.Join(filepath.Base(path), rest)
+		path = parent
+	}
+
+	return "", errors.New("too many levels of symbolic links")
+}
+
+func expandHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	if path == "~" {
+		return home
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		return filepath.Join(home, rest)
+	}
+	return path
+}
+
+type workspaceKey struct{}
+
+// WithWorkspace returns a context whose file tools are confined to w
+func WithWorkspace(ctx context.Context, w *Workspace) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, w)
+}
+
+// FromContext returns the workspace of ctx, or the default one
+func FromContext(ctx context.Context) *Workspace {
+	if w, ok := ctx.Value(workspaceKey{}).(*Workspace); ok && w != nil {
+		return w
+	}
+	return Default()
+}
@@ -0,0 +1,120 @@
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newRepo creates a repository with a "sub" directory and returns its root
+func newRepo(t *testing.T) string {
+	t.Helper()
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sub"), 0o755))
+
+	return root
+}
+
+func TestResolve(t *testing.T) {
+	root := newRepo(t)
+	extra := t.TempDir()
+
+	w, err := New(Config{Allow: []string{extra}}, filepath.Join(root, "sub"))
+	require.NoError(t, err)
+	require.Equal(t, root, w.Root())
+
+	for path, want := range map[string]string{
+		"a.go":                            filepath.Join(root, "sub", "a.go"),
+		"../main.go":                      filepath.Join(root, "main.go"),
+		filepath.Join(root, "new/dir/x"):  filepath.Join(root, "new", "dir", "x"),
+		filepath.Join(extra, "cache.bin"): filepath.Join(extra, "cache.bin"),
+	} {
+		got, err := w.Resolve(path)
+		require.NoError(t, err, path)
+		require.Equal(t, want, got)
+	}
+
+	for _, path := range []string{"../../outside.txt", "/etc/passwd", ""} {
+		_, err := w.Resolve(path)
+		require.Error(t, err, path)
+	}
+
+	_, err = w.Resolve("../..")
+	require.True(t, errors.Is(err, ErrDenied))
+	require.ErrorContains(t, err, "outside the workspace "+root)
+}
+
+func TestResolveSymlinks(t *testing.T) {
+	root := newRepo(t)
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644))
+
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "new.txt"), filepath.Join(root, "dangling")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "sub"), filepath.Join(root, "inside")))
+
+	w, err := New(Config{}, root)
+	require.NoError(t, err)
+
+	// through a symlinked directory, and through a symlink a new file would be created at
+	for _, path := range []string{"escape/secret.txt", "escape/new/file.txt", "dangling"} {
+		_, err := w.Resolve(path)
+		require.ErrorContains(t, err, "through a symlink", path)
+	}
+
+	got, err := w.Resolve("inside/a.go")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, "inside", "a.go"), got)
+}
+
+func TestDeny(t *testing.T) {
+	root := newRepo(t)
+
+	w, err := New(Config{Deny: []string{"*.pem", "config/secrets/**"}}, root)
+	require.NoError(t, err)
+
+	denied := []string{
+		".git/config", "sub/vendor/.git/HEAD", ".env", "sub/.env.local", "deploy/.ssh/known_hosts", "id_ed25519",
+		"tls/server.pem", "config/secrets/db.yaml", "config/secrets",
+	}
+	for _, path := range denied {
+		_, err := w.Resolve(path)
+		require.True(t, errors.Is(err, ErrDenied), path)
+		require.True(t, w.Denied(path), path)
+	}
+
+	allowed := []string{".gitignore", ".env.example", "sub/config/secrets/x", "server.key.go", "config/secrets.go"}
+	for _, path := range allowed {
+		_, err := w.Resolve(path)
+		require.NoError(t, err, path)
+		require.False(t, w.Denied(path), path)
+	}
+
+	// a symlink cannot hide a denied file behind an innocent name
+	require.NoError(t, os.Symlink(filepath.Join(root, ".git", "config"), filepath.Join(root, "git-config")))
+	_, err = w.Resolve("git-config")
+	require.ErrorContains(t, err, `denied pattern "**/.git/**"`)
+
+	_, err = New(Config{Allow: []string{"relative/dir"}}, root)
+	require.ErrorContains(t, err, "must be absolute")
+}
+
+func TestDefault(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	w := Default()
+	require.Empty(t, w.Root())
+
+	_, err := w.Resolve(filepath.Join(t.TempDir(), "anywhere.txt"))
+	require.NoError(t, err)
+
+	_, err = w.Resolve(filepath.Join(home, ".ssh", "id_rsa"))
+	require.True(t, errors.Is(err, ErrDenied))
+}
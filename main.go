@@ -1,22 +1,44 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/vadiminshakov/autonomy/core/ai"
 	"github.com/vadiminshakov/autonomy/core/config"
 	"github.com/vadiminshakov/autonomy/core/index"
+	"github.com/vadiminshakov/autonomy/core/sandbox"
+	"github.com/vadiminshakov/autonomy/server"
 	"github.com/vadiminshakov/autonomy/terminal"
 	"github.com/vadiminshakov/autonomy/ui"
 )
 
 func main() {
+	// sandboxed commands start through this binary, which sets up the sandbox and runs them
+	sandbox.Main()
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		case "run":
+			os.Exit(runOnce(os.Args[2:]))
+		case "trust":
+			os.Exit(runTrust(os.Args[2:]))
+		}
+	}
+
 	var headless = flag.Bool("headless", false, "Run in headless mode (for VS Code extension)")
+	var protocol = flag.String("protocol", "text", "Headless protocol: text or jsonrpc")
 	var version = flag.Bool("version", false, "Show version information")
 	flag.Parse()
 
@@ -25,16 +47,25 @@ func main() {
 		os.Exit(0)
 	}
 
-	runProgram(*headless)
+	runProgram(*headless, *protocol)
 }
 
-func runProgram(headless bool) {
+func runProgram(headless bool, protocol string) {
 	if headless {
 		if vscodePID := os.Getenv("VSCODE_PID"); vscodePID != "" {
 			go monitorVSCodeProcess(vscodePID)
 		}
 
-		if err := terminal.RunHeadlessWithInit(); err != nil {
+		run := terminal.RunHeadlessWithInit
+		switch protocol {
+		case "text":
+		case "jsonrpc":
+			run = terminal.RunJSONRPC
+		default:
+			log.Fatalf("unknown protocol %q: use text or jsonrpc", protocol)
+		}
+
+		if err := run(); err != nil {
 			log.Fatal(err)
 		}
 
@@ -68,6 +99,130 @@ func runProgram(headless bool) {
 	}
 }
 
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	var addr = fs.String("addr", "127.0.0.1:7878", "Address to listen on")
+	var token = fs.String("token", os.Getenv("AUTONOMY_TOKEN"), "API token (generated when empty, also read from AUTONOMY_TOKEN)")
+	_ = fs.Parse(args)
+
+	cfg, err := config.LoadConfigFile()
+	if err != nil {
+		log.Fatal(ui.Error("failed to load configuration, run autonomy once to set it up: " + err.Error()))
+	}
+
+	client, err := ai.ProvideAiClient(cfg)
+	if err != nil {
+		log.Fatal(ui.Error("failed to create AI client: " + err.Error()))
+	}
+
+	if *token == "" {
+		if *token, err = server.GenerateToken(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := server.Run(*addr, *token, client); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
+
+// runTrust lets the hooks of a project run; they come with the repository, so they are off until the user trusts it
+func runTrust(args []string) int {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	if err := config.Trust(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to trust %s: %v\n", dir, err)
+		return terminal.ExitFailed
+	}
+
+	fmt.Println("Project hooks enabled")
+	return terminal.ExitSuccess
+}
+
+func runOnce(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: autonomy run [flags] "<task>" | -`)
+		fs.PrintDefaults()
+	}
+	var file = fs.String("file", "", "Read the task from a file")
+	var maxIterations = fs.Int("max-iterations", 0, "Maximum agent iterations (default from task config)")
+	var timeout = fs.Duration("timeout", 0, "Abort the task after this duration, e.g. 30m")
+	var autoApprove = fs.String("auto-approve", terminal.ApproveNone, "Approval policy: none (exit when approval is needed) or all")
+	var output = fs.String("output", "text", "Output format: text or json")
+	_ = fs.Parse(args)
+
+	if *autoApprove != terminal.ApproveAll && *autoApprove != terminal.ApproveNone {
+		fmt.Fprintf(os.Stderr, "unknown auto-approve policy %q\n", *autoApprove)
+		return terminal.ExitUsage
+	}
+	if *output != "text" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "unknown output format %q\n", *output)
+		return terminal.ExitUsage
+	}
+
+	taskText, err := readTask(fs.Args(), *file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fs.Usage()
+		return terminal.ExitUsage
+	}
+
+	cfg, err := config.LoadConfigFile()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.Error("failed to load configuration, run autonomy once to set it up: "+err.Error()))
+		return terminal.ExitFailed
+	}
+
+	client, err := ai.ProvideAiClient(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.Error("failed to create AI client: "+err.Error()))
+		return terminal.ExitFailed
+	}
+
+	return terminal.RunOneShot(client, terminal.OneShotOptions{
+		Task:          taskText,
+		MaxIterations: *maxIterations,
+		Timeout:       *timeout,
+		AutoApprove:   *autoApprove,
+		JSON:          *output == "json",
+	})
+}
+
+// readTask takes the task from the arguments, a file, or stdin when the argument is "-" or stdin is piped
+func readTask(args []string, file string) (string, error) {
+	var data []byte
+	var err error
+
+	switch {
+	case file != "":
+		data, err = os.ReadFile(file)
+	case len(args) == 1 && args[0] == "-":
+		data, err = io.ReadAll(os.Stdin)
+	case len(args) > 0:
+		data = []byte(strings.Join(args, " "))
+	default:
+		info, statErr := os.Stdin.Stat()
+		if statErr != nil || info.Mode()&os.ModeCharDevice != 0 {
+			return "", errors.New("no task given")
+		}
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read task: %w", err)
+	}
+
+	taskText := strings.TrimSpace(string(data))
+	if taskText == "" {
+		return "", errors.New("task is empty")
+	}
+
+	return taskText, nil
+}
+
 func monitorVSCodeProcess(vscodePIDStr string) {
 	vscodePID, err := strconv.Atoi(vscodePIDStr)
 	if err != nil {
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/vadiminshakov/autonomy/core/events"
+)
+
+const (
+	historyLimit = 1000
+	// totalHistoryLimit bounds the history of all sessions; the sessions quiet the longest lose theirs first
+	totalHistoryLimit = 20000
+	subscriberBuffer  = 256
+)
+
+// streamEvent is an event numbered for SSE replay
+type streamEvent struct {
+	ID    int64
+	Event events.Event
+}
+
+// hub fans session events out to SSE subscribers and keeps a bounded history,
+// so clients that connect late or reconnect with Last-Event-ID miss nothing.
+type hub struct {
+	mu      sync.Mutex
+	nextID  int64
+	history map[string][]streamEvent
+	total   int
+	subs    map[string]map[chan streamEvent]struct{}
+}
+
+func newHub() *hub {
+	return &hub{
+		history: make(map[string][]streamEvent),
+		subs:    make(map[string]map[chan streamEvent]struct{}),
+	}
+}
+
+func (h *hub) publish(sessionID string, e events.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	se := streamEvent{ID: h.nextID, Event: e}
+
+	history := append(h.history[sessionID], se)
+	h.total++
+	if len(history) > historyLimit {
+		h.total -= len(history) - historyLimit
+		history = history[len(history)-historyLimit:]
+	}
+	h.history[sessionID] = history
+
+	for h.total > totalHistoryLimit {
+		if !h.dropOldest(sessionID) {
+			break
+		}
+	}
+
+	for ch := range h.subs[sessionID] {
+		select {
+		case ch <- se:
+		default:
+			// a stalled client is dropped; it reconnects and replays from history
+			delete(h.subs[sessionID], ch)
+			close(ch)
+		}
+	}
+}
+
+// dropOldest forgets the history of the session whose last event is the oldest, other than keep
+func (h *hub) dropOldest(keep string) bool {
+	oldest, last := "", int64(0)
+	for id, history := range h.history {
+		if id != keep && (oldest == "" || history[len(history)-1].ID < last) {
+			oldest, last = id, history[len(history)-1].ID
+		}
+	}
+	if oldest == "" {
+		return false
+	}
+
+	h.total -= len(h.history[oldest])
+	delete(h.history, oldest)
+	return true
+}
+
+// subscribe returns the events after the given id and a channel with the ones that follow.
+// The channel is closed when the subscriber falls behind; cancel releases it.
+func (h *hub) subscribe(sessionID string, after int64) ([]streamEvent, <-chan streamEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var backlog []streamEvent
+	for _, se := range h.history[sessionID] {
+		if se.ID > after {
+			backlog = append(backlog, se)
+		}
+	}
+
+	ch := make(chan streamEvent, subscriberBuffer)
+	if h.subs[sessionID] == nil {
+		h.subs[sessionID] = make(map[chan streamEvent]struct{})
+	}
+	h.subs[sessionID][ch] = struct{}{}
+
+	cancel := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if _, ok := h.subs[sessionID][ch]; ok {
+			delete(h.subs[sessionID], ch)
+			close(ch)
+		}
+	}
+
+	return backlog, ch, cancel
+}
@@ -0,0 +1,370 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/vadiminshakov/autonomy/core/ai"
+	"github.com/vadiminshakov/autonomy/core/session"
+)
+
+const (
+	maxBodyBytes      = 1 << 20
+	heartbeatInterval = 15 * time.Second
+)
+
+// Server exposes the session manager over REST and streams task events with Server-Sent Events
+type Server struct {
+	manager    *session.Manager
+	hub        *hub
+	token      string
+	defaultDir string
+	mux        *http.ServeMux
+	done       chan struct{}
+}
+
+// New creates a server; every request must carry the token
+func New(client ai.AIClient, token string) (*Server, error) {
+	// sessions without a project directory work where the daemon started
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect working directory: %w", err)
+	}
+
+	s := &Server{
+		hub:        newHub(),
+		token:      token,
+		defaultDir: wd,
+		mux:        http.NewServeMux(),
+		done:       make(chan struct{}),
+	}
+	s.manager = session.NewManager(s.hub.publish)
+	s.manager.SetClient(client)
+
+	s.mux.HandleFunc("GET /sessions", s.listSessions)
+	s.mux.HandleFunc("POST /sessions", s.createSession)
+	s.mux.HandleFunc("GET /sessions/{id}", s.getSession)
+	s.mux.HandleFunc("POST /sessions/{id}/messages", s.sendMessage)
+	s.mux.HandleFunc("POST /sessions/{id}/cancel", s.cancel)
+	s.mux.HandleFunc("POST /sessions/{id}/approvals/{approval}", s.approve)
+	s.mux.HandleFunc("GET /sessions/{id}/events", s.streamEvents)
+
+	return s, nil
+}
+
+// Run serves on addr until SIGINT or SIGTERM
+func Run(addr, token string, client ai.AIClient) error {
+	s, err := New(client, token)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Addr: addr, Handler: s, ReadHeaderTimeout: 10 * time.Second}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	fmt.Printf("Autonomy API listening on http://%s\n", addr)
+	fmt.Printf("Token: %s\n", token)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.Close()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return srv.Shutdown(shutdownCtx)
+}
+
+// GenerateToken returns a random token for local authentication
+func GenerateToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Close ends open event streams, cancels the running task and stops the processes of all sessions
+func (s *Server) Close() {
+	select {
+	case <-s.done:
+		return
+	default:
+		close(s.done)
+	}
+
+	s.manager.Close()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "missing or invalid token")
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized accepts the token as a bearer header or, for EventSource clients, a query parameter
+func (s *Server) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+type createSessionRequest struct {
+	ProjectDir string `json:"project_dir"`
+	Task       string `json:"task"`
+}
+
+type messageRequest struct {
+	Message string `json:"message"`
+}
+
+func (s *Server) listSessions(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"sessions": s.manager.List()})
+}
+
+// createSession opens a session in its project directory and starts its first task, if given.
+// The daemon runs one task at a time: a task started while another session runs gets 409 Conflict.
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	var req createSessionRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	dir, err := s.projectDir(req.ProjectDir)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// refuse before creating the session, so a busy daemon does not leave it behind
+	if req.Task != "" && s.manager.Running() != "" {
+		writeSessionError(w, session.ErrBusy)
+		return
+	}
+
+	sess, err := s.manager.Create(dir)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	if req.Task != "" {
+		if _, err := s.manager.Send(sess.ID(), req.Task); err != nil {
+			writeSessionError(w, err)
+			return
+		}
+	}
+
+	s.writeInfo(w, http.StatusCreated, sess.ID())
+}
+
+func (s *Server) getSession(w http.ResponseWriter, r *http.Request) {
+	s.writeInfo(w, http.StatusOK, r.PathValue("id"))
+}
+
+// sendMessage queues a message for the running task of the session or starts its next task;
+// starting one while another session runs gets 409 Conflict
+func (s *Server) sendMessage(w http.ResponseWriter, r *http.Request) {
+	var req messageRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Message == "" {
+		writeError(w, http.StatusBadRequest, "message is required")
+		return
+	}
+
+	queued, err := s.manager.Send(r.PathValue("id"), req.Message)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]bool{"queued": queued})
+}
+
+func (s *Server) cancel(w http.ResponseWriter, r *http.Request) {
+	if err := s.manager.Cancel(r.PathValue("id")); err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]bool{"canceled": true})
+}
+
+func (s *Server) approve(w http.ResponseWriter, r *http.Request) {
+	var decision session.Decision
+	if !decodeBody(w, r, &decision) {
+		return
+	}
+
+	err := s.manager.Approve(r.PathValue("id"), r.PathValue("approval"), decision)
+	switch {
+	case errors.Is(err, session.ErrNotFound), errors.Is(err, session.ErrApprovalNotFound):
+		writeSessionError(w, err)
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, map[string]bool{"resolved": true})
+	}
+}
+
+// streamEvents sends the session's events as SSE, replaying the ones after Last-Event-ID
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := s.manager.Get(id); err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	var after int64
+	if last := r.Header.Get("Last-Event-ID"); last != "" {
+		after, _ = strconv.ParseInt(last, 10, 64)
+	}
+
+	backlog, ch, cancel := s.hub.subscribe(id, after)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, se := range backlog {
+		if err := writeEvent(w, se); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case se, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, se); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func (s *Server) projectDir(dir string) (string, error) {
+	if dir == "" {
+		return s.defaultDir, nil
+	}
+
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(s.defaultDir, dir)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid project_dir: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("invalid project_dir: %s is not a directory", dir)
+	}
+
+	return filepath.Clean(dir), nil
+}
+
+func (s *Server) writeInfo(w http.ResponseWriter, status int, id string) {
+	info, err := s.manager.Info(id)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	writeJSON(w, status, info)
+}
+
+func writeEvent(w http.ResponseWriter, se streamEvent) error {
+	data, err := json.Marshal(se.Event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", se.ID, se.Event.Type, data)
+	return err
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeSessionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, session.ErrNotFound), errors.Is(err, session.ErrApprovalNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, session.ErrBusy):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, session.ErrNoClient):
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadiminshakov/autonomy/core/ai"
+	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/events"
+	"github.com/vadiminshakov/autonomy/core/session"
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+const testToken = "secret"
+
+// scriptedClient returns canned responses in order
+type scriptedClient struct {
+	mu        sync.Mutex
+	responses []*entity.AIResponse
+}
+
+func (c *scriptedClient) GenerateCode(ctx context.Context, _ entity.PromptData) (*entity.AIResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	response := c.responses[0]
+	c.responses = c.responses[1:]
+	return response, nil
+}
+
+// blockingClient answers only when the task is canceled
+type blockingClient struct{}
+
+func (blockingClient) GenerateCode(ctx context.Context, _ entity.PromptData) (*entity.AIResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func newTestServer(t *testing.T, responses ...*entity.AIResponse) *httptest.Server {
+	return newTestServerWith(t, &scriptedClient{responses: responses})
+}
+
+func newTestServerWith(t *testing.T, client ai.AIClient) *httptest.Server {
+	s, err := New(client, testToken)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(s)
+	t.Cleanup(func() {
+		s.Close()
+		ts.Close()
+	})
+
+	return ts
+}
+
+func do(t *testing.T, method, url, body string) *http.Response {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/sessions")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = http.Get(ts.URL + "/sessions?token=" + testToken)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServerRunsTaskAndStreamsEvents(t *testing.T) {
+	tools.GetTaskState().Reset()
+
+	ts := newTestServer(t,
+		&entity.AIResponse{ToolCalls: []entity.ToolCall{
+			{ID: "1", Name: "attempt_completion", Args: map[string]any{"summary": "done"}},
+		}},
+		&entity.AIResponse{Content: `{"complete": true}`},
+	)
+
+	dir := t.TempDir()
+	body, err := json.Marshal(map[string]string{"project_dir": dir, "task": "finish"})
+	require.NoError(t, err)
+
+	resp := do(t, http.MethodPost, ts.URL+"/sessions", string(body))
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var info session.Info
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+	resp.Body.Close()
+	require.Equal(t, dir, info.Dir)
+	require.Equal(t, "finish", info.Title)
+
+	// the stream replays events published before the client connected
+	stream := do(t, http.MethodGet, ts.URL+"/sessions/"+info.ID+"/events", "")
+	defer stream.Body.Close()
+	require.Equal(t, "text/event-stream", stream.Header.Get("Content-Type"))
+
+	var received []events.Type
+	scanner := bufio.NewScanner(stream.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		var e events.Event
+		require.NoError(t, json.Unmarshal([]byte(data), &e))
+		received = append(received, e.Type)
+		if e.Type == events.TaskCompleted {
+			break
+		}
+	}
+
+	require.Equal(t, events.TaskStarted, received[0])
+	require.Equal(t, events.TaskCompleted, received[len(received)-1])
+
+	resp = do(t, http.MethodGet, ts.URL+"/sessions", "")
+	var list struct {
+		Sessions []session.Info `json:"sessions"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	resp.Body.Close()
+	require.Len(t, list.Sessions, 1)
+}
+
+func TestServerErrors(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp := do(t, http.MethodGet, ts.URL+"/sessions/session-42", "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = do(t, http.MethodPost, ts.URL+"/sessions", `{"project_dir": "/does/not/exist"}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = do(t, http.MethodPost, ts.URL+"/sessions", `{}`)
+	var info session.Info
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+	resp.Body.Close()
+	require.Equal(t, session.StatusIdle, info.Status)
+
+	resp = do(t, http.MethodPost, ts.URL+"/sessions/"+info.ID+"/approvals/approval-1", `{"decision": "accept"}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = do(t, http.MethodPost, ts.URL+"/sessions/"+info.ID+"/messages", `{}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestServerRunsOneTaskAtATime(t *testing.T) {
+	tools.GetTaskState().Reset()
+	ts := newTestServerWith(t, blockingClient{})
+
+	create := func(task string) (*http.Response, session.Info) {
+		body, err := json.Marshal(map[string]string{"project_dir": t.TempDir(), "task": task})
+		require.NoError(t, err)
+
+		resp := do(t, http.MethodPost, ts.URL+"/sessions", string(body))
+		defer resp.Body.Close()
+
+		var info session.Info
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+		return resp, info
+	}
+
+	resp, running := create("run")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// a task of another project waits for the running one, and no session is left behind
+	resp = do(t, http.MethodPost, ts.URL+"/sessions", `{"task": "run too"}`)
+	var failure map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&failure))
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	require.Contains(t, failure["error"], "only one task runs at a time")
+
+	resp, idle := create("")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, session.StatusIdle, idle.Status)
+
+	resp = do(t, http.MethodPost, ts.URL+"/sessions/"+idle.ID+"/messages", `{"message": "run too"}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = do(t, http.MethodGet, ts.URL+"/sessions", "")
+	var list struct {
+		Sessions []session.Info `json:"sessions"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	resp.Body.Close()
+	require.Len(t, list.Sessions, 2)
+
+	resp = do(t, http.MethodPost, ts.URL+"/sessions/"+running.ID+"/cancel", "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+}
+
+func TestHubBoundsHistory(t *testing.T) {
+	h := newHub()
+
+	sessions := totalHistoryLimit/historyLimit + 1
+	for i := 0; i < sessions; i++ {
+		for j := 0; j < historyLimit+1; j++ {
+			h.publish(fmt.Sprintf("session-%d", i), events.Event{Type: events.Notice})
+		}
+	}
+
+	// the session that was quiet the longest lost its history
+	backlog, _, cancel := h.subscribe("session-0", 0)
+	cancel()
+	require.Empty(t, backlog)
+
+	backlog, _, cancel = h.subscribe(fmt.Sprintf("session-%d", sessions-1), 0)
+	cancel()
+	require.Len(t, backlog, historyLimit)
+	require.LessOrEqual(t, h.total, totalHistoryLimit)
+}
@@ -0,0 +1,363 @@
+package terminal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/vadiminshakov/autonomy/core/ai"
+	"github.com/vadiminshakov/autonomy/core/config"
+	"github.com/vadiminshakov/autonomy/core/events"
+	"github.com/vadiminshakov/autonomy/core/session"
+	"github.com/vadiminshakov/autonomy/core/tools"
+)
+
+// ProtocolVersion is the version of the JSON-RPC headless protocol
+const ProtocolVersion = "1.0"
+
+// JSON-RPC 2.0 error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+	codeNotInitialized = -32002
+	codeSessionBusy    = -32003
+	codeNotFound       = -32004
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// eventParams is the payload of the "event" notification
+type eventParams struct {
+	SessionID string       `json:"sessionId"`
+	Event     events.Event `json:"event"`
+}
+
+type initializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion,omitempty"`
+	Config          *config.Config `json:"config,omitempty"`
+}
+
+type submitTaskParams struct {
+	Task string `json:"task"`
+}
+
+type sessionParams struct {
+	SessionID string `json:"sessionId"`
+}
+
+type sendMessageParams struct {
+	SessionID string `json:"sessionId"`
+	Message   string `json:"message"`
+}
+
+type approveToolParams struct {
+	SessionID  string `json:"sessionId"`
+	ApprovalID string `json:"approvalId"`
+	session.Decision
+}
+
+// rpcServer serves the JSON-RPC protocol for one client
+type rpcServer struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+
+	manager     *session.Manager
+	initialized bool
+	newClient   func(cfg config.Config) (ai.AIClient, error)
+}
+
+// RunJSONRPC serves the JSON-RPC 2.0 headless protocol over stdio.
+// Every other write to stdout is redirected to stderr to keep the stream clean.
+func RunJSONRPC() error {
+	out := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = out }()
+
+	return serveJSONRPC(os.Stdin, out, newAIClient)
+}
+
+func serveJSONRPC(in io.Reader, out io.Writer, newClient func(cfg config.Config) (ai.AIClient, error)) error {
+	s := &rpcServer{enc: json.NewEncoder(out), newClient: newClient}
+	s.manager = session.NewManager(s.publishEvent)
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		s.handleLine(line)
+	}
+
+	// the client went away: stop whatever is still running
+	if running := s.manager.Running(); running != "" {
+		_ = s.manager.Cancel(running)
+	}
+
+	return scanner.Err()
+}
+
+func (s *rpcServer) handleLine(line []byte) {
+	var req rpcRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		s.write(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"),
+			Error: &rpcError{Code: codeParseError, Message: fmt.Sprintf("parse error: %v", err)}})
+		return
+	}
+
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		s.reply(req.ID, nil, &rpcError{Code: codeInvalidRequest, Message: "invalid request"})
+		return
+	}
+
+	result, err := s.dispatch(req.Method, req.Params)
+
+	// notifications get no response
+	if len(req.ID) == 0 {
+		return
+	}
+
+	s.reply(req.ID, result, err)
+}
+
+func (s *rpcServer) dispatch(method string, params json.RawMessage) (any, *rpcError) {
+	if method != "initialize" && !s.initialized {
+		return nil, &rpcError{Code: codeNotInitialized, Message: "initialize must be called first"}
+	}
+
+	switch method {
+	case "initialize":
+		return s.initialize(params)
+	case "submitTask":
+		return s.submitTask(params)
+	case "cancel":
+		return s.cancel(params)
+	case "sendMessage":
+		return s.sendMessage(params)
+	case "approveTool":
+		return s.approveTool(params)
+	case "listSessions":
+		return map[string]any{"sessions": s.manager.List()}, nil
+	case "getState":
+		return s.getState(params)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", method)}
+	}
+}
+
+func (s *rpcServer) initialize(raw json.RawMessage) (any, *rpcError) {
+	var params initializeParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+
+	var cfg config.Config
+	if params.Config != nil {
+		cfg = *params.Config
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = config.DefaultBaseURL(cfg.Provider)
+		}
+		if err := cfg.Validate(); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		config.Override(cfg)
+	} else {
+		loaded, err := config.LoadConfigFile()
+		if err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("no config given and config file unavailable: %v", err)}
+		}
+		cfg = loaded
+	}
+
+	client, err := s.newClient(cfg)
+	if err != nil {
+		return nil, &rpcError{Code: codeInternalError, Message: fmt.Sprintf("failed to create AI client: %v", err)}
+	}
+
+	s.manager.SetClient(client)
+	s.initialized = true
+
+	return map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"serverInfo":      map[string]string{"name": "autonomy"},
+		"capabilities": map[string]any{
+			"methods":       []string{"initialize", "submitTask", "cancel", "sendMessage", "approveTool", "listSessions", "getState"},
+			"notifications": []string{"event"},
+		},
+	}, nil
+}
+
+func (s *rpcServer) submitTask(raw json.RawMessage) (any, *rpcError) {
+	var params submitTaskParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.Task == "" {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "task is required"}
+	}
+
+	sess, err := s.manager.Submit(params.Task)
+	if err != nil {
+		return nil, sessionError(err)
+	}
+
+	return map[string]string{"sessionId": sess.ID()}, nil
+}
+
+func (s *rpcServer) cancel(raw json.RawMessage) (any, *rpcError) {
+	var params sessionParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+
+	if err := s.manager.Cancel(params.SessionID); err != nil {
+		return nil, sessionError(err)
+	}
+
+	return map[string]bool{"canceled": true}, nil
+}
+
+func (s *rpcServer) sendMessage(raw json.RawMessage) (any, *rpcError) {
+	var params sendMessageParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.Message == "" {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "message is required"}
+	}
+
+	queued, err := s.manager.Send(params.SessionID, params.Message)
+	if err != nil {
+		return nil, sessionError(err)
+	}
+
+	return map[string]bool{"queued": queued}, nil
+}
+
+func (s *rpcServer) approveTool(raw json.RawMessage) (any, *rpcError) {
+	var params approveToolParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+
+	if err := s.manager.Approve(params.SessionID, params.ApprovalID, params.Decision); err != nil {
+		return nil, sessionError(err)
+	}
+
+	return map[string]bool{"resolved": true}, nil
+}
+
+func (s *rpcServer) getState(raw json.RawMessage) (any, *rpcError) {
+	var params sessionParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+
+	state := map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"running":         s.manager.Running(),
+	}
+
+	if params.SessionID != "" {
+		info, err := s.manager.Info(params.SessionID)
+		if err != nil {
+			return nil, sessionError(err)
+		}
+		state["session"] = info
+		state["taskState"] = tools.GetTaskState().Summary()
+	}
+
+	return state, nil
+}
+
+// publishEvent forwards session events to the client as notifications
+func (s *rpcServer) publishEvent(sessionID string, e events.Event) {
+	s.write(rpcNotification{JSONRPC: "2.0", Method: "event", Params: eventParams{SessionID: sessionID, Event: e}})
+}
+
+func (s *rpcServer) reply(id json.RawMessage, result any, err *rpcError) {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+
+	resp := rpcResponse{JSONRPC: "2.0", ID: id}
+	if err != nil {
+		resp.Error = err
+	} else {
+		if result == nil {
+			result = map[string]any{}
+		}
+		resp.Result = result
+	}
+
+	s.write(resp)
+}
+
+func (s *rpcServer) write(msg any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// a client that stopped reading cannot be reported to
+	_ = s.enc.Encode(msg)
+}
+
+func decodeParams(raw json.RawMessage, v any) *rpcError {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+	}
+
+	return nil
+}
+
+func sessionError(err error) *rpcError {
+	switch {
+	case errors.Is(err, session.ErrNotFound), errors.Is(err, session.ErrApprovalNotFound):
+		return &rpcError{Code: codeNotFound, Message: err.Error()}
+	case errors.Is(err, session.ErrBusy):
+		return &rpcError{Code: codeSessionBusy, Message: err.Error()}
+	case errors.Is(err, session.ErrNoClient):
+		return &rpcError{Code: codeNotInitialized, Message: err.Error()}
+	default:
+		return &rpcError{Code: codeInternalError, Message: err.Error()}
+	}
+}
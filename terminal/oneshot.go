@@ -0,0 +1,222 @@
+package terminal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vadiminshakov/autonomy/core/ai"
+	"github.com/vadiminshakov/autonomy/core/decomposition"
+	"github.com/vadiminshakov/autonomy/core/entity"
+	"github.com/vadiminshakov/autonomy/core/events"
+	"github.com/vadiminshakov/autonomy/core/task"
+	"github.com/vadiminshakov/autonomy/core/tools"
+	"github.com/vadiminshakov/autonomy/ui"
+)
+
+// Exit codes of the one-shot mode
+const (
+	ExitSuccess        = 0
+	ExitFailed         = 1
+	ExitUsage          = 2
+	ExitBudgetExceeded = 3
+	ExitNeedsApproval  = 4
+	ExitCanceled       = 130
+)
+
+// Auto-approve policies of the one-shot mode; none is the default, so unattended runs never
+// change anything the approval mode would ask about
+const (
+	ApproveAll  = "all"
+	ApproveNone = "none"
+)
+
+// ErrNeedsApproval is returned when a plan or a tool call needs approval the policy does not grant
+var ErrNeedsApproval = errors.New("approval required")
+
+// OneShotOptions configures a single non-interactive run
+type OneShotOptions struct {
+	Task          string
+	MaxIterations int
+	Timeout       time.Duration
+	AutoApprove   string
+	JSON          bool
+}
+
+// OneShotResult is the outcome of a run, printed with --output json
+type OneShotResult struct {
+	Status       string       `json:"status"`
+	ExitCode     int          `json:"exit_code"`
+	Summary      string       `json:"summary,omitempty"`
+	ChangedFiles []string     `json:"changed_files"`
+	Usage        entity.Usage `json:"usage"`
+	Error        string       `json:"error,omitempty"`
+	Duration     string       `json:"duration"`
+}
+
+// denyingPlanReviewer stops the run at the first plan that needs approval
+type denyingPlanReviewer struct{}
+
+func (denyingPlanReviewer) ReviewPlan(*decomposition.DecompositionResult) (task.PlanDecision, error) {
+	return task.PlanDecision{}, ErrNeedsApproval
+}
+
+// denyingToolApprover stops the run at the first tool call that needs approval
+type denyingToolApprover struct {
+	cancel func()
+
+	mu   sync.Mutex
+	tool string
+}
+
+func (a *denyingToolApprover) ApproveTool(req task.ToolApproval) (task.ToolDecision, error) {
+	a.mu.Lock()
+	if a.tool == "" {
+		a.tool = req.Tool
+	}
+	a.mu.Unlock()
+
+	a.cancel()
+	return task.ToolDecision{}, ErrNeedsApproval
+}
+
+// denied returns the tool whose call needed approval, if any
+func (a *denyingToolApprover) denied() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.tool
+}
+
+// RunOneShot runs a single task without interaction and returns the process exit code
+func RunOneShot(client ai.AIClient, opts OneShotOptions) int {
+	if opts.AutoApprove == "" {
+		opts.AutoApprove = ApproveNone
+	}
+	if opts.AutoApprove != ApproveNone && opts.AutoApprove != ApproveAll {
+		fmt.Fprintf(os.Stderr, "unknown auto-approve policy %q, expected %s or %s\n", opts.AutoApprove, ApproveNone, ApproveAll)
+		return ExitUsage
+	}
+
+	// with JSON output stdout carries the result only, progress goes to stderr
+	progress := io.Writer(os.Stdout)
+	if opts.JSON {
+		progress = os.Stderr
+	}
+
+	cfg := task.DefaultConfig()
+	if opts.MaxIterations > 0 {
+		cfg.MaxIterations = opts.MaxIterations
+	}
+
+	tools.GetTaskState().Reset()
+
+	t := task.NewTaskWithConfig(client, cfg)
+	defer t.Close()
+	if opts.JSON {
+		t.SetEventBus(events.NewBus(task.NewTerminalRendererTo(progress)))
+	}
+	t.SetOriginalTask(opts.Task)
+	t.AddUserMessage(opts.Task)
+
+	approver := &denyingToolApprover{cancel: t.Cancel}
+	switch opts.AutoApprove {
+	case ApproveNone:
+		t.SetPlanReviewer(denyingPlanReviewer{})
+		t.SetToolApprover(approver)
+	case ApproveAll:
+		// everything is approved up front, only the command policy can still refuse a command
+		t.SetApprovalMode(task.ApprovalAuto)
+	}
+
+	var (
+		usageMu sync.Mutex
+		usage   entity.Usage
+	)
+	t.Events().Subscribe(events.SinkFunc(func(e events.Event) {
+		if e.Type == events.Usage && e.Usage != nil {
+			usageMu.Lock()
+			usage = entity.Usage{InputTokens: e.Usage.TotalInputTokens, OutputTokens: e.Usage.TotalOutputTokens}
+			usageMu.Unlock()
+		}
+	}))
+
+	var timedOut atomic.Bool
+	if opts.Timeout > 0 {
+		timer := time.AfterFunc(opts.Timeout, func() {
+			timedOut.Store(true)
+			t.Cancel()
+		})
+		defer timer.Stop()
+	}
+
+	if !opts.JSON {
+		ui.ShowTaskStart(opts.Task)
+	}
+
+	start := time.Now()
+	err := runInterruptible(t, progress)
+
+	if timedOut.Load() && errors.Is(err, task.ErrCanceled) {
+		err = fmt.Errorf("%w: timed out after %v", task.ErrBudgetExceeded, opts.Timeout)
+	}
+	if tool := approver.denied(); tool != "" {
+		err = fmt.Errorf("%w: tool %s", ErrNeedsApproval, tool)
+	}
+
+	result := OneShotResult{
+		ChangedFiles: tools.GetTaskState().ChangedFiles(),
+		Duration:     time.Since(start).Round(time.Millisecond).String(),
+	}
+	result.Status, result.ExitCode = classifyResult(err)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if report, ok := tools.GetCompletionReport(); ok {
+		result.Summary = report.Summary
+	}
+	if result.ChangedFiles == nil {
+		result.ChangedFiles = []string{}
+	}
+
+	usageMu.Lock()
+	result.Usage = usage
+	usageMu.Unlock()
+
+	if opts.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write result: %v\n", err)
+		}
+		return result.ExitCode
+	}
+
+	if err != nil {
+		ui.ShowError(err)
+	} else {
+		ui.ShowTaskComplete()
+	}
+
+	return result.ExitCode
+}
+
+// classifyResult maps the outcome of a run to its status and exit code
+func classifyResult(err error) (string, int) {
+	switch {
+	case err == nil:
+		return "success", ExitSuccess
+	case errors.Is(err, task.ErrBudgetExceeded):
+		return "budget_exceeded", ExitBudgetExceeded
+	case errors.Is(err, ErrNeedsApproval):
+		return "needs_approval", ExitNeedsApproval
+	case errors.Is(err, task.ErrCanceled):
+		return "canceled", ExitCanceled
+	default:
+		return "failed", ExitFailed
+	}
+}
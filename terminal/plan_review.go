@@ -0,0 +1,129 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vadiminshakov/autonomy/core/decomposition"
+	"github.com/vadiminshakov/autonomy/core/task"
+	"github.com/vadiminshakov/autonomy/ui"
+)
+
+// replPlanReviewer asks the user at the REPL to approve a plan before execution
+type replPlanReviewer struct {
+	repl terminalIO
+}
+
+func newREPLPlanReviewer(repl terminalIO) *replPlanReviewer {
+	return &replPlanReviewer{repl: repl}
+}
+
+func (r *replPlanReviewer) ReviewPlan(plan *decomposition.DecompositionResult) (task.PlanDecision, error) {
+	ui.ShowPlanReview(plan.GetStepSummary())
+
+	for {
+		answer, err := r.repl.Prompt(ui.BrightCyan("Run this plan? [a]ccept / [r]eject / [e]dit: "))
+		if err != nil {
+			return task.PlanDecision{Action: task.PlanReject}, nil
+		}
+
+		switch strings.ToLower(answer) {
+		case "a", "accept", "y", "yes", "":
+			return task.PlanDecision{Action: task.PlanAccept}, nil
+
+		case "r", "reject", "n", "no":
+			feedback, err := r.repl.Prompt(ui.BrightCyan("What should change? (empty to cancel the task): "))
+			if err != nil {
+				feedback = ""
+			}
+			return task.PlanDecision{Action: task.PlanReject, Feedback: feedback}, nil
+
+		case "e", "edit":
+			edited, err := r.edit(plan)
+			if err != nil {
+				fmt.Println(ui.Error(err.Error()))
+				continue
+			}
+			return task.PlanDecision{Action: task.PlanEdit, Plan: edited}, nil
+
+		default:
+			fmt.Println(ui.Warning("Please answer accept, reject or edit"))
+		}
+	}
+}
+
+func (r *replPlanReviewer) edit(plan *decomposition.DecompositionResult) (*decomposition.DecompositionResult, error) {
+	content, err := decomposition.MarshalPlanForEdit(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	var edited []byte
+	if err := r.repl.Suspend(func() error {
+		var editErr error
+		edited, editErr = ui.EditInEditor(content, "autonomy-plan-*.yaml")
+		return editErr
+	}); err != nil {
+		return nil, err
+	}
+
+	return decomposition.ParsePlan(edited, plan.OriginalTask)
+}
+
+// lineReader supplies input lines to prompts raised while a task runs
+type lineReader interface {
+	ReadLine() (string, bool)
+}
+
+// headlessPlanReviewer exchanges plan review messages over the headless line protocol
+type headlessPlanReviewer struct {
+	input lineReader
+}
+
+func newHeadlessPlanReviewer(input lineReader) *headlessPlanReviewer {
+	return &headlessPlanReviewer{input: input}
+}
+
+func (r *headlessPlanReviewer) ReviewPlan(plan *decomposition.DecompositionResult) (task.PlanDecision, error) {
+	fmt.Println("PLAN_REVIEW")
+	fmt.Println(plan.GetStepSummary())
+	fmt.Println(`Reply with "accept", "reject <feedback>" or "edit <plan as single-line JSON>".`)
+
+	for {
+		line, ok := r.input.ReadLine()
+		if !ok {
+			return task.PlanDecision{}, fmt.Errorf("input closed during plan review")
+		}
+
+		decision, err := parsePlanReply(line, plan.OriginalTask)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			continue
+		}
+		return decision, nil
+	}
+}
+
+// parsePlanReply parses a headless plan review reply
+func parsePlanReply(line, originalTask string) (task.PlanDecision, error) {
+	command, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+	rest = strings.TrimSpace(rest)
+
+	switch strings.ToLower(command) {
+	case "accept", "approve":
+		return task.PlanDecision{Action: task.PlanAccept}, nil
+
+	case "reject":
+		return task.PlanDecision{Action: task.PlanReject, Feedback: rest}, nil
+
+	case "edit":
+		edited, err := decomposition.ParsePlan([]byte(rest), originalTask)
+		if err != nil {
+			return task.PlanDecision{}, err
+		}
+		return task.PlanDecision{Action: task.PlanEdit, Plan: edited}, nil
+
+	default:
+		return task.PlanDecision{}, fmt.Errorf("unknown plan review reply %q", command)
+	}
+}
@@ -0,0 +1,144 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/vadiminshakov/autonomy/core/task"
+	"github.com/vadiminshakov/autonomy/ui"
+)
+
+// terminalIO is the part of the REPL that prompts raised by a task use
+type terminalIO interface {
+	Prompt(prompt string) (string, error)
+	Suspend(fn func() error) error
+}
+
+// replRequest asks the REPL loop for a line of input or to release the terminal
+type replRequest struct {
+	prompt  string
+	suspend func() error
+	reply   chan replReply
+}
+
+type replReply struct {
+	line string
+	err  error
+}
+
+// replEvent is sent by the task goroutine to the REPL loop
+type replEvent struct {
+	request *replRequest
+	done    bool
+	err     error
+}
+
+// replTaskSession keeps the REPL reading while a task runs in the background.
+// Typed lines are queued for the agent, Ctrl-C cancels the task and prompts
+// raised by the task are served by the same readline instance.
+type replTaskSession struct {
+	repl   *ui.REPLCommands
+	events chan replEvent
+	closed bool
+}
+
+func newREPLTaskSession(repl *ui.REPLCommands) *replTaskSession {
+	return &replTaskSession{
+		repl:   repl,
+		events: make(chan replEvent, 1),
+	}
+}
+
+// Prompt reads a line through the REPL loop
+func (s *replTaskSession) Prompt(prompt string) (string, error) {
+	return s.request(&replRequest{prompt: prompt, reply: make(chan replReply, 1)})
+}
+
+// Suspend releases the terminal to fn through the REPL loop
+func (s *replTaskSession) Suspend(fn func() error) error {
+	_, err := s.request(&replRequest{suspend: fn, reply: make(chan replReply, 1)})
+	return err
+}
+
+func (s *replTaskSession) request(req *replRequest) (string, error) {
+	s.send(replEvent{request: req})
+
+	reply := <-req.reply
+	return reply.line, reply.err
+}
+
+// send hands an event to the REPL loop and interrupts its pending read
+func (s *replTaskSession) send(event replEvent) {
+	s.events <- event
+	s.repl.Wake()
+}
+
+// run executes the task and serves the terminal until the task finishes
+func (s *replTaskSession) run(t *task.Task) error {
+	go func() {
+		err := runInterruptible(t, os.Stdout)
+		s.send(replEvent{done: true, err: err})
+	}()
+
+	var pending *replRequest
+
+	for {
+		prompt := ui.Dim("↳ ")
+		if pending != nil {
+			prompt = pending.prompt
+		}
+
+		line, err := s.repl.Prompt(prompt)
+		switch {
+		case errors.Is(err, ui.ErrWoken):
+			event := <-s.events
+			if event.done {
+				return event.err
+			}
+
+			if event.request.suspend != nil {
+				event.request.reply <- replReply{err: s.repl.Suspend(event.request.suspend)}
+				continue
+			}
+			pending = event.request
+
+		case errors.Is(err, ui.ErrInterrupt):
+			fmt.Println(ui.Warning("Canceling task..."))
+			t.Cancel()
+			if pending != nil {
+				pending.reply <- replReply{err: err}
+				pending = nil
+			}
+
+		case err != nil:
+			// input closed: stop the task and leave the REPL once it returns
+			s.closed = true
+			t.Cancel()
+			if pending != nil {
+				pending.reply <- replReply{err: err}
+			}
+			return s.drain(err)
+
+		case pending != nil:
+			pending.reply <- replReply{line: line}
+			pending = nil
+
+		case line != "":
+			t.QueueMessage(line)
+			fmt.Println(ui.Dim(fmt.Sprintf("⏳ Queued for the agent (%d pending): %s", len(t.QueuedMessages()), line)))
+		}
+	}
+}
+
+// drain answers the task's remaining requests with err until it finishes
+func (s *replTaskSession) drain(err error) error {
+	for event := range s.events {
+		if event.done {
+			return event.err
+		}
+		event.request.reply <- replReply{err: err}
+	}
+
+	return nil
+}
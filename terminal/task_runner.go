@@ -0,0 +1,131 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/vadiminshakov/autonomy/core/ai"
+	"github.com/vadiminshakov/autonomy/core/task"
+	"github.com/vadiminshakov/autonomy/ui"
+)
+
+// nextTask returns the task that handles input. A canceled task keeps its
+// conversation, so the next input redirects it instead of starting over.
+func nextTask(client ai.AIClient, canceled *task.Task, input string) *task.Task {
+	if canceled != nil {
+		canceled.AddUserMessage(input)
+		return canceled
+	}
+
+	t := task.NewTask(client)
+	t.SetOriginalTask(input)
+	t.AddUserMessage(input)
+
+	return t
+}
+
+// runInterruptible runs the task until it finishes or the user presses Ctrl-C; the notice goes to out
+func runInterruptible(t *task.Task, out io.Writer) error {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-sigs:
+			fmt.Fprintln(out)
+			fmt.Fprintln(out, ui.Warning("Canceling task..."))
+			t.Cancel()
+		case <-done:
+		}
+	}()
+
+	return t.ProcessTask()
+}
+
+// taskInput hands headless input lines to prompts raised by a running task
+type taskInput struct {
+	requests chan chan string
+}
+
+func newTaskInput() *taskInput {
+	return &taskInput{requests: make(chan chan string)}
+}
+
+// ReadLine waits for the next input line; false means input was closed or the task canceled
+func (in *taskInput) ReadLine() (string, bool) {
+	reply := make(chan string, 1)
+	in.requests <- reply
+
+	line, ok := <-reply
+	return line, ok
+}
+
+// runHeadlessTask runs the task while routing input: "cancel" stops it, other lines
+// answer its prompts or are queued for the agent. It reports whether the input was closed meanwhile.
+func runHeadlessTask(t *task.Task, lines <-chan string, input *taskInput) (bool, error) {
+	done := make(chan error, 1)
+	go func() {
+		done <- t.ProcessTask()
+	}()
+
+	var pending chan string
+	inputClosed := false
+
+	cancel := func() {
+		t.Cancel()
+		if pending != nil {
+			close(pending)
+			pending = nil
+		}
+	}
+
+	for {
+		select {
+		case err := <-done:
+			return inputClosed, err
+
+		case reply := <-input.requests:
+			if inputClosed {
+				close(reply)
+				continue
+			}
+			pending = reply
+
+		case line, ok := <-lines:
+			if !ok {
+				inputClosed = true
+				lines = nil
+				cancel()
+				continue
+			}
+
+			switch {
+			case line == "cancel":
+				fmt.Println("TASK_CANCELING")
+				cancel()
+			case pending != nil:
+				pending <- line
+				pending = nil
+			case line != "":
+				t.QueueMessage(line)
+				fmt.Printf("MESSAGE_QUEUED (%d pending): %s\n", len(t.QueuedMessages()), line)
+			}
+		}
+	}
+}
+
+// showCanceled tells the user how to continue after a canceled task
+func showCanceled(headless bool) {
+	if headless {
+		fmt.Println(`TASK_CANCELED: send a message to redirect the task or "new" to start over`)
+		return
+	}
+
+	fmt.Println(ui.Dim(`Type a message to redirect the task, or "new" to start over.`))
+}
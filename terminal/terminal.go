@@ -1,7 +1,7 @@
 package terminal
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -21,6 +21,19 @@ func RunTerminal(client ai.AIClient) error {
 	defer repl.Close()
 	repl.ShowWelcome()
 
+	var canceled *task.Task
+	// a canceled task keeps its shell and background processes for the next input
+	defer func() {
+		if canceled != nil {
+			canceled.Close()
+		}
+	}()
+
+	mode, err := task.ConfiguredApprovalMode()
+	if err != nil {
+		ui.ShowError(err)
+	}
+
 	for {
 		input, shouldExit, isReconfig := repl.ReadInput()
 		if shouldExit {
@@ -46,65 +59,122 @@ func RunTerminal(client ai.AIClient) error {
 			continue
 		}
 
-		ui.ShowTaskStart(input)
+		if input == "new" {
+			if canceled != nil {
+				canceled.Close()
+				canceled = nil
+			}
+			fmt.Println(ui.Info("Starting a new conversation"))
+			continue
+		}
 
-		t := task.NewTask(client)
-		t.SetOriginalTask(input)
-		defer t.Close()
+		if modeCommand(input, &mode) {
+			continue
+		}
 
-		t.AddUserMessage(input)
+		ui.ShowTaskStart(input)
 
-		err := t.ProcessTask()
-		if err != nil {
+		t := nextTask(client, canceled, input)
+		canceled = nil
+
+		session := newREPLTaskSession(repl)
+		t.SetPlanReviewer(newREPLPlanReviewer(session))
+		t.SetToolApprover(newREPLToolApprover(session))
+		t.SetApprovalMode(mode)
+
+		err := session.run(t)
+		switch {
+		case errors.Is(err, task.ErrCanceled):
+			canceled = t
+			showCanceled(false)
+		case err != nil:
+			t.Close()
 			ui.ShowError(err)
-		} else {
+		default:
+			t.Close()
 			ui.ShowTaskComplete()
 		}
+
+		if session.closed {
+			break
+		}
 	}
 
 	return nil
 }
 
+// modeCommand shows or sets the approval mode on "mode [name]"; it reports whether input was that command
+func modeCommand(input string, mode *task.ApprovalMode) bool {
+	fields := strings.Fields(input)
+	if fields[0] != "mode" || len(fields) > 2 {
+		return false
+	}
+
+	if len(fields) == 2 {
+		newMode, err := task.ParseApprovalMode(fields[1])
+		if err != nil {
+			ui.ShowError(err)
+			return true
+		}
+		*mode = newMode
+	}
+
+	fmt.Println(ui.Info(fmt.Sprintf("Approval mode: %s", *mode)))
+	return true
+}
+
 func RunHeadless(client ai.AIClient) error {
 	// Send ready signal for webview
 	fmt.Println("🤖 Autonomy agent is ready! Enter your programming tasks or commands.")
 
-	// Use a channel to handle stdin reading non-blocking
-	inputChan := make(chan string)
-	go func() {
-		scanner := bufio.NewScanner(os.Stdin)
+	inputChan := readLines(os.Stdin)
+	input := newTaskInput()
 
-		for scanner.Scan() {
-			input := strings.TrimSpace(scanner.Text())
-			inputChan <- input
+	var canceled *task.Task
+	// a canceled task keeps its shell and background processes for the next input
+	defer func() {
+		if canceled != nil {
+			canceled.Close()
 		}
-		close(inputChan)
 	}()
 
-	for input := range inputChan {
-		if input == "" {
+	for line := range inputChan {
+		if line == "" {
 			continue
 		}
 
-		switch input {
+		switch line {
 		case "exit", "quit":
 			return nil
-		case "status":
+		case "status", "ping", "cancel":
 			continue
-		case "ping":
+		case "new":
+			if canceled != nil {
+				canceled.Close()
+				canceled = nil
+			}
 			continue
 		}
 
-		t := task.NewTask(client)
-		t.SetOriginalTask(input)
-
-		t.AddUserMessage(input)
-
-		err := t.ProcessTask()
-		t.Close()
-
-		if err != nil {
+		t := nextTask(client, canceled, line)
+		t.SetPlanReviewer(newHeadlessPlanReviewer(input))
+		t.SetToolApprover(newHeadlessToolApprover(input))
+		canceled = nil
+
+		inputClosed, err := runHeadlessTask(t, inputChan, input)
+		switch {
+		case errors.Is(err, task.ErrCanceled):
+			canceled = t
+			showCanceled(true)
+		case err != nil:
+			t.Close()
 			fmt.Println(ui.Error(fmt.Sprintf("Task failed: %v", err)))
+		default:
+			t.Close()
+		}
+
+		if inputClosed {
+			return nil
 		}
 	}
 
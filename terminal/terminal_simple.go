@@ -3,7 +3,9 @@ package terminal
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -33,15 +35,31 @@ func RunHeadlessWithInit() error {
 	fmt.Println("Autonomy agent is ready! Enter your programming tasks or commands.")
 	fmt.Fprintf(os.Stderr, "Autonomy agent is ready! Enter your programming tasks or commands.\n")
 
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		input := strings.TrimSpace(scanner.Text())
+	lines := readLines(os.Stdin)
+	taskInput := newTaskInput()
+
+	var canceled *task.Task
+	// a canceled task keeps its shell and background processes for the next input
+	defer func() {
+		if canceled != nil {
+			canceled.Close()
+		}
+	}()
 
+	for input := range lines {
 		if input == "exit" || input == "quit" {
 			break
 		}
 
-		if input == "" {
+		if input == "" || input == "cancel" {
+			continue
+		}
+
+		if input == "new" {
+			if canceled != nil {
+				canceled.Close()
+				canceled = nil
+			}
 			continue
 		}
 
@@ -92,18 +110,44 @@ func RunHeadlessWithInit() error {
 			continue
 		}
 
-		// Process the task
-		t := task.NewTask(client)
-		t.SetOriginalTask(input)
-		t.AddUserMessage(input)
-
-		err := t.ProcessTask()
-		t.Close()
-
-		if err != nil {
+		// Process the task, a canceled one continues with the new input
+		t := nextTask(client, canceled, input)
+		t.SetPlanReviewer(newHeadlessPlanReviewer(taskInput))
+		t.SetToolApprover(newHeadlessToolApprover(taskInput))
+		canceled = nil
+
+		inputClosed, err := runHeadlessTask(t, lines, taskInput)
+		switch {
+		case errors.Is(err, task.ErrCanceled):
+			canceled = t
+			showCanceled(true)
+		case err != nil:
+			t.Close()
 			fmt.Printf("❌ Task failed: %v\n", err)
+		default:
+			t.Close()
+		}
+
+		if inputClosed {
+			break
 		}
 	}
 
 	return nil
 }
+
+// readLines streams trimmed input lines until the reader is exhausted
+func readLines(r io.Reader) <-chan string {
+	lines := make(chan string)
+
+	go func() {
+		defer close(lines)
+
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- strings.TrimSpace(scanner.Text())
+		}
+	}()
+
+	return lines
+}
@@ -0,0 +1,123 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vadiminshakov/autonomy/core/task"
+	"github.com/vadiminshakov/autonomy/ui"
+)
+
+// replToolApprover asks the user at the REPL to approve tool calls
+type replToolApprover struct {
+	repl terminalIO
+}
+
+func newREPLToolApprover(repl terminalIO) *replToolApprover {
+	return &replToolApprover{repl: repl}
+}
+
+func (r *replToolApprover) ApproveTool(req task.ToolApproval) (task.ToolDecision, error) {
+	ui.ShowToolApproval(req.Tool, req.Preview, req.Reason)
+
+	prompt, choices := "Allow? [y]es / [n]o: ", "yes or no"
+	if req.Pattern != "" {
+		fmt.Println(ui.Dim(fmt.Sprintf("[s] always allow %s %q in this session, [p] always allow it in this project",
+			req.Tool, req.Pattern)))
+		prompt, choices = "Allow? [y]es / [n]o / [s]ession / [p]roject: ", "yes, no, session or project"
+	}
+
+	for {
+		answer, err := r.repl.Prompt(ui.BrightCyan(prompt))
+		if err != nil {
+			return task.ToolDecision{}, err
+		}
+
+		switch strings.ToLower(answer) {
+		case "y", "yes", "":
+			return task.ToolDecision{Action: task.ToolApprove}, nil
+
+		case "n", "no":
+			reason, err := r.repl.Prompt(ui.BrightCyan("Why not? (optional, passed to the agent): "))
+			if err != nil {
+				reason = ""
+			}
+			return task.ToolDecision{Action: task.ToolDeny, Reason: reason}, nil
+
+		case "s", "session":
+			if req.Pattern != "" {
+				return task.ToolDecision{Action: task.ToolAllowSession}, nil
+			}
+
+		case "p", "project":
+			if req.Pattern != "" {
+				return task.ToolDecision{Action: task.ToolAllowProject}, nil
+			}
+		}
+
+		fmt.Println(ui.Warning("Please answer " + choices))
+	}
+}
+
+// headlessToolApprover exchanges tool approval messages over the headless line protocol
+type headlessToolApprover struct {
+	input lineReader
+}
+
+func newHeadlessToolApprover(input lineReader) *headlessToolApprover {
+	return &headlessToolApprover{input: input}
+}
+
+func (r *headlessToolApprover) ApproveTool(req task.ToolApproval) (task.ToolDecision, error) {
+	fmt.Println("TOOL_APPROVAL")
+	fmt.Println(req.Tool)
+	if req.Reason != "" {
+		fmt.Println(req.Reason)
+	}
+	fmt.Println(req.Preview)
+	if req.Pattern != "" {
+		fmt.Printf("Reply with \"approve\", \"deny <reason>\", \"always session\" or \"always project\" to allow %q.\n", req.Pattern)
+	} else {
+		fmt.Println(`Reply with "approve" or "deny <reason>".`)
+	}
+
+	for {
+		line, ok := r.input.ReadLine()
+		if !ok {
+			return task.ToolDecision{}, fmt.Errorf("input closed during tool approval")
+		}
+
+		decision, err := parseToolReply(line)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			continue
+		}
+		return decision, nil
+	}
+}
+
+// parseToolReply parses a headless tool approval reply
+func parseToolReply(line string) (task.ToolDecision, error) {
+	command, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+	rest = strings.TrimSpace(rest)
+
+	switch strings.ToLower(command) {
+	case "approve", "accept", "yes":
+		return task.ToolDecision{Action: task.ToolApprove}, nil
+
+	case "deny", "reject", "no":
+		return task.ToolDecision{Action: task.ToolDeny, Reason: rest}, nil
+
+	case "always":
+		switch strings.ToLower(rest) {
+		case "session":
+			return task.ToolDecision{Action: task.ToolAllowSession}, nil
+		case "project":
+			return task.ToolDecision{Action: task.ToolAllowProject}, nil
+		}
+		return task.ToolDecision{}, fmt.Errorf(`expected "always session" or "always project"`)
+
+	default:
+		return task.ToolDecision{}, fmt.Errorf("unknown tool approval reply %q", command)
+	}
+}
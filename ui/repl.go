@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/chzyer/readline"
@@ -14,8 +16,15 @@ import (
 type REPLCommands struct {
 	history  []string
 	readline *readline.Instance
+	waking   atomic.Bool
 }
 
+// ErrInterrupt is returned by Prompt when the user presses Ctrl-C
+var ErrInterrupt = readline.ErrInterrupt
+
+// ErrWoken is returned by Prompt when the read was interrupted by Wake
+var ErrWoken = errors.New("read interrupted by wake")
+
 func createReadline() (*readline.Instance, error) {
 	return readline.NewEx(&readline.Config{
 		Prompt:            "",
@@ -44,6 +53,13 @@ var completer = readline.NewPrefixCompleter(
 	readline.PcItem("clear"),
 	readline.PcItem("history"),
 	readline.PcItem("reconfig"),
+	readline.PcItem("mode",
+		readline.PcItem("always"),
+		readline.PcItem("mutating"),
+		readline.PcItem("auto"),
+		readline.PcItem("plan"),
+	),
+	readline.PcItem("new"),
 	readline.PcItem("exit"),
 )
 
@@ -60,7 +76,7 @@ func (r *REPLCommands) ShowWelcome() {
 	fmt.Println(BrightCyan("AI programming assistant"))
 	fmt.Println()
 	fmt.Println(BrightBlue("Enter your programming tasks or commands"))
-	fmt.Println(Dim("Available commands: help, clear, history, reconfig, exit"))
+	fmt.Println(Dim("Available commands: help, clear, history, reconfig, mode, new, exit"))
 	fmt.Println()
 }
 
@@ -128,7 +144,12 @@ General:
   clear    – clear the screen
   history  – show command history
   reconfig – recreate configuration
-  exit     – quit the program`
+  mode     – show or set when tools need approval: always, mutating, auto or plan
+  new      – start over instead of continuing a canceled task
+  exit     – quit the program
+
+While a task runs, type a message to queue it for the agent
+or press Ctrl-C to cancel the task.`
 
 	fmt.Println(helpText)
 }
@@ -176,30 +197,90 @@ func ShowTaskStart(task string) {
 	fmt.Println(Dim(strings.Repeat("─", 50)))
 }
 
+func ShowPlanReview(summary string) {
+	fmt.Println()
+	fmt.Println(BrightCyan("Proposed plan:"))
+	fmt.Println(summary)
+}
+
+// ShowToolApproval shows a tool call waiting for the user's approval
+func ShowToolApproval(tool, preview, reason string) {
+	fmt.Println()
+	fmt.Println(BrightCyan(fmt.Sprintf("Approve %s?", tool)))
+	if reason != "" {
+		fmt.Println(Warning(reason))
+	}
+	fmt.Println(preview)
+}
+
 func ShowTaskComplete() {
 	fmt.Println(Dim(strings.Repeat("─", 50)))
 	fmt.Println(BrightGreen("✅ Task completed successfully!"))
 	fmt.Println()
 }
 
-func (r *REPLCommands) reconfig() bool {
-	fmt.Println()
+// Prompt reads a single line of input with a custom prompt.
+// Ctrl-C and EOF are reported as errors.
+func (r *REPLCommands) Prompt(prompt string) (string, error) {
+	r.readline.SetPrompt(prompt)
 
+	line, err := r.readline.Readline()
+	if err == readline.ErrInterrupt && r.waking.CompareAndSwap(true, false) {
+		// keep what the user was typing for the next read
+		if line != "" {
+			_, _ = r.readline.WriteStdin([]byte(line))
+		}
+		return "", ErrWoken
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(line), nil
+}
+
+// Wake interrupts a pending Prompt from another goroutine, which then returns ErrWoken.
+// A wake requested while no read is pending interrupts the next one.
+func (r *REPLCommands) Wake() {
+	if !r.waking.CompareAndSwap(false, true) {
+		return
+	}
+
+	_, _ = r.readline.WriteStdin([]byte{readline.CharInterrupt})
+}
+
+// Suspend releases the terminal while fn runs, so external programs
+// such as editors or interactive wizards can take over stdin
+func (r *REPLCommands) Suspend(fn func() error) error {
 	if r.readline != nil {
 		r.readline.Close()
 	}
 
-	_, err := config.InteractiveSetup()
+	fnErr := fn()
 
-	rl, reinitErr := createReadline()
-	if reinitErr != nil {
-		fmt.Println(BrightRed("failed to reinitialize readline: " + reinitErr.Error()))
-		return false
+	rl, err := createReadline()
+	if err != nil {
+		return fmt.Errorf("failed to reinitialize readline: %w", err)
 	}
 	r.readline = rl
 
-	if err != nil {
-		fmt.Println(BrightRed("failed to reconfigure: " + err.Error()))
+	return fnErr
+}
+
+func (r *REPLCommands) reconfig() bool {
+	fmt.Println()
+
+	var setupErr error
+	if err := r.Suspend(func() error {
+		_, setupErr = config.InteractiveSetup()
+		return nil
+	}); err != nil {
+		fmt.Println(BrightRed(err.Error()))
+		return false
+	}
+
+	if setupErr != nil {
+		fmt.Println(BrightRed("failed to reconfigure: " + setupErr.Error()))
 		fmt.Println()
 		return false
 	}